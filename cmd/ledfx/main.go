@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -10,18 +11,21 @@ import (
 	"runtime"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/LedFx/ledfx/pkg/audio"
 	"github.com/LedFx/ledfx/pkg/bridgeapi"
 	"github.com/LedFx/ledfx/pkg/color"
 	"github.com/LedFx/ledfx/pkg/config"
 	"github.com/LedFx/ledfx/pkg/constants"
+	"github.com/LedFx/ledfx/pkg/controlapi"
 	"github.com/LedFx/ledfx/pkg/controller"
 	"github.com/LedFx/ledfx/pkg/device"
 	"github.com/LedFx/ledfx/pkg/effect"
 	"github.com/LedFx/ledfx/pkg/event"
 	"github.com/LedFx/ledfx/pkg/frontend"
 	"github.com/LedFx/ledfx/pkg/logger"
+	"github.com/LedFx/ledfx/pkg/metrics"
 	"github.com/LedFx/ledfx/pkg/util"
 	"github.com/LedFx/ledfx/pkg/websocket"
 
@@ -30,7 +34,15 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-var wg sync.WaitGroup
+// how long the audio bridge gets to stop before shutdown carries on without it
+const bridgeShutdownTimeout = 5 * time.Second
+
+var (
+	wg sync.WaitGroup
+
+	// stopped on shutdown, nil if it failed to start
+	bridgeServer *bridgeapi.Server
+)
 
 func init() {
 	// Capture ctrl-c or sigterm to gracefully shutdown
@@ -94,12 +106,16 @@ func main() {
 	color.NewAPI(mux)
 	frontend.NewServer(mux)
 	websocket.Serve(mux)
-	bridgeServer, err := bridgeapi.NewServer(audio.Analyzer.BufferCallback, mux)
+	websocket.ServeLevels(mux, settings.LevelsRate)
+	if settings.Metrics {
+		metrics.Enable(mux)
+	}
+	bridgeServer, err = bridgeapi.NewServer(audio.Analyzer.BufferCallback, mux)
 	// Start audio bridge
 	if err != nil {
 		logger.Logger.WithField("context", "AudioBridge").Fatalf("Error initializing AudioBridge server: %v", err)
 	} else {
-		defer bridgeServer.Br.Stop()
+		controlapi.NewServer(bridgeServer.Br.JSONWrapper().CTL(), mux)
 		logger.Logger.WithField("context", "AudioBridge").Info("Initialised AudioBridge server")
 	}
 	// if err := bridgeServer.Br.StartAirPlayInput("LedFx", 7000); err != nil {
@@ -165,6 +181,36 @@ func main() {
 func shutdown() {
 	logger.Logger.WithField("context", "Shutdown Handler").Info("Shutting down LedFx")
 
+	// stop rendering before the devices go away. Saving is off so the running
+	// controllers are restored on the next start, rather than the stopped states.
+	config.AllowSaving = false
+	logger.Logger.WithField("context", "Shutdown Handler").Info("Stopping controllers")
+	for id, running := range controller.GetStates() {
+		if c, err := controller.Get(id); err == nil && running {
+			c.Stop()
+		}
+	}
+	logger.Logger.WithField("context", "Shutdown Handler").Info("Disconnecting devices")
+	for _, id := range device.GetIDs() {
+		d, err := device.Get(id)
+		if err != nil || d.State != device.Connected {
+			continue
+		}
+		if err := d.Disconnect(); err != nil {
+			logger.Logger.WithField("context", "Shutdown Handler").Warnf("Error disconnecting device '%s': %v", id, err)
+		}
+	}
+
+	// stops the input, whether capture or the AirPlay server, and the outputs
+	if bridgeServer != nil && bridgeServer.Br != nil {
+		logger.Logger.WithField("context", "Shutdown Handler").Info("Stopping audio bridge")
+		ctx, cancel := context.WithTimeout(context.Background(), bridgeShutdownTimeout)
+		if err := bridgeServer.Br.Controller().Shutdown(ctx); err != nil {
+			logger.Logger.WithField("context", "Shutdown Handler").Warnf("Error stopping audio bridge: %v", err)
+		}
+		cancel()
+	}
+
 	logger.Logger.WithField("context", "Shutdown Handler").Info("Cleaning up audio analyzer")
 	// kill analyzer
 	audio.Analyzer.Cleanup()
@@ -3,11 +3,14 @@ package audio
 import (
 	"fmt"
 	"math"
+	"sync"
 	"time"
 
 	log "github.com/LedFx/ledfx/pkg/logger"
+	"github.com/LedFx/ledfx/pkg/metrics"
 
 	"github.com/LedFx/aubio-go"
+	"go.uber.org/atomic"
 )
 
 const (
@@ -16,6 +19,8 @@ const (
 	BufferSize  uint    = 1024
 	SampleRate  uint    = 44100
 	RefreshRate float64 = float64(SampleRate) / float64(BufferSize)
+	// buffers shorter than this are treated as glitches rather than a new stream format
+	MinBufferSize int = 64
 	// volume normalisation streams
 	streamConstant float64 = 0.1
 	streamPow      float64 = 1
@@ -40,19 +45,42 @@ type analyzer struct {
 	pvoc        *aubio.PhaseVoc     // transforms audio data to fft
 	melbanks    map[string]*melbank // a melbank for each effect
 	RecentOnset time.Time           // onset for effects
+	Tempo       *TempoTracker       // tempo and beat phase, from the onsets
 	Vol         volumeStream        // volume stream source for effects. includes a normalised volume and a timestep.
+	Silence     *SilenceDetector    // how long the audio has been silent
+	levelsMel   *melbank            // melbank dedicated to metering
+	metering    *atomic.Bool        // whether levels are being computed
+	levels      levelsState         // latest levels snapshot
+
+	specMu       sync.Mutex
+	spectrograms []*Spectrogram // spectrograms recording the fft of each buffer
+
+	overlap *atomic.Float64 // requested overlap of successive fft frames
+	hopSize int             // samples between successive fft frames
+	chunk   []float32       // the current hop, handed to aubio
+	pending []float32       // samples not yet making up a whole hop
+	carry   Buffer          // samples of long buffers not yet making up a whole FftSize frame
 }
 
 func init() {
-	Analyzer = &analyzer{}
+	Analyzer = &analyzer{
+		metering: atomic.NewBool(false),
+		overlap:  atomic.NewFloat64(0),
+		Silence:  NewSilenceDetector(DefaultSilenceThreshold),
+		Tempo:    NewTempoTracker(),
+	}
 	initialise(int(BufferSize))
 }
 
 func initialise(bufSize int) {
-	uintBufSize := uint(bufSize)
 	Analyzer.bufSize = bufSize
+	Analyzer.hopSize = hopSize(bufSize, Analyzer.overlap.Load())
+	Analyzer.chunk = make([]float32, Analyzer.hopSize)
+	Analyzer.pending = make([]float32, 0, bufSize+Analyzer.hopSize)
+	// aubio processes a hop at a time
+	uintBufSize := uint(Analyzer.hopSize)
 	Analyzer.buf = aubio.NewSimpleBuffer(uintBufSize)
-	Analyzer.data = make([]float32, uintBufSize)
+	Analyzer.data = make([]float32, bufSize)
 	Analyzer.melbanks = make(map[string]*melbank)
 	Analyzer.RecentOnset = time.Now()
 	Analyzer.Vol = NewVolumeStream()
@@ -73,6 +101,11 @@ func initialise(bufSize int) {
 		log.Logger.WithField("context", "Audio Analyzer Init").Fatalf("Error creating new Aubio Pvoc: %v", err)
 	}
 
+	// Create metering melbank
+	if Analyzer.levelsMel, err = newMelbank(melMin, melMax, levelsIntensity, bufSize); err != nil {
+		log.Logger.WithField("context", "Audio Analyzer Init").Fatalf("Error creating metering melbank: %v", err)
+	}
+
 }
 
 type melbankArgs struct {
@@ -95,6 +128,7 @@ func (a *analyzer) reinitialise(bufSize int) {
 	a.buf.Free()
 	a.onset.Free()
 	a.pvoc.Free()
+	a.levelsMel.Free()
 	for id := range a.melbanks {
 		a.DeleteMelbank(id)
 	}
@@ -106,8 +140,35 @@ func (a *analyzer) reinitialise(bufSize int) {
 }
 
 // Takes a mono audio buffer and performs analysis.
-// Should be called around 60fps for smooth audio data for effects to use
+// Should be called around 60fps for smooth audio data for effects to use.
+//
+// Buffers of MinBufferSize to FftSize samples are analysed as they are; the size need not
+// be a power of two, as the phase vocoder windows each buffer into an FftSize frame.
+// Empty buffers are ignored and shorter buffers (e.g. during device transitions) are
+// zero-padded to the current buffer size. Longer buffers are analysed as successive
+// FftSize frames, with samples short of a whole frame carried into the next buffer.
 func (a *analyzer) BufferCallback(buf Buffer) {
+	switch {
+	case len(buf) == 0:
+		return
+	case len(buf) < MinBufferSize:
+		buf = buf.ZeroPad(a.bufSize)
+	case len(buf) > int(FftSize):
+		a.carry = append(a.carry, buf...)
+		n := 0
+		for ; len(a.carry)-n >= int(FftSize); n += int(FftSize) {
+			a.analyse(a.carry[n : n+int(FftSize)])
+		}
+		a.carry = append(a.carry[:0], a.carry[n:]...)
+		return
+	}
+	// samples carried from long buffers don't belong with buffers of another size
+	a.carry = a.carry[:0]
+	a.analyse(buf)
+}
+
+// analyse a buffer of MinBufferSize to FftSize samples
+func (a *analyzer) analyse(buf Buffer) {
 	// if the buffer changes size, we need to clean up and reinitialise
 	if len(buf) != a.bufSize {
 		log.Logger.WithField("context", "Audio Analyzer").Warnf("Audio buffer changed size [%d->%d]. Reinitialising.", a.bufSize, len(buf))
@@ -115,32 +176,94 @@ func (a *analyzer) BufferCallback(buf Buffer) {
 		log.Logger.WithField("context", "Audio Analyzer").Debug("Reinitialised.")
 		return
 	}
+	if hop := hopSize(a.bufSize, a.overlap.Load()); hop != a.hopSize {
+		log.Logger.WithField("context", "Audio Analyzer").Infof("FFT hop changed [%d->%d]. Reinitialising.", a.hopSize, hop)
+		a.reinitialise(a.bufSize)
+		return
+	}
 
 	// Get our audio data as float32
 	for i := 0; i < a.bufSize; i++ {
 		a.data[i] = float32(buf[i])
 	}
 
-	// set the data of the aubio buffer (optimised)
-	a.buf.SetDataFast(a.data)
+	metering := a.metering.Load()
+	var level float64
+	if metering || metrics.Enabled() {
+		level = rms(a.data)
+		metrics.AudioRMS.Set(level)
+	}
 
-	// update volume normaliser
-	a.Vol.update(aubio.DbSpl(a.buf))
+	// Perform FFT and onset detection for each hop. Samples short of a whole hop wait for the next buffer.
+	now := time.Now()
+	a.pending = append(a.pending, a.data...)
+	n := 0
+	for ; len(a.pending)-n >= a.hopSize; n += a.hopSize {
+		copy(a.chunk, a.pending[n:n+a.hopSize])
+		// set the data of the aubio buffer (optimised)
+		a.buf.SetDataFast(a.chunk)
+
+		a.eq.DoOutplace(a.buf)
+		a.pvoc.Do(a.eq.Buffer())
+		a.updateSpectrograms()
+
+		// do onset analysis, timed by where the hop ends in the buffer
+		a.onset.Do(a.buf)
+		if a.onset.OnsetNow() {
+			behind := len(a.pending) - (n + a.hopSize)
+			a.RecentOnset = now.Add(-time.Duration(behind) * time.Second / time.Duration(SampleRate))
+			a.Tempo.Onset(a.RecentOnset)
+		}
+	}
+	a.pending = append(a.pending[:0], a.pending[n:]...)
 
-	// Perform FFT of each audio stream
-	a.eq.DoOutplace(a.buf)
-	a.pvoc.Do(a.eq.Buffer())
+	// update volume normaliser and silence detection
+	db := aubio.DbSpl(a.buf)
+	a.Vol.update(db)
+	a.Silence.Update(db)
 
-	// Perform melbank frequency analysis
+	// Perform melbank frequency analysis on the latest frame, once per buffer so effect smoothing is unchanged
 	for _, mb := range a.melbanks {
 		mb.Do(a.pvoc.Grain())
 	}
+	if metering {
+		a.levelsMel.Do(a.pvoc.Grain())
+		a.updateLevels(level, peak(a.data), a.levelsMel.Data)
+	}
+}
 
-	// do onset analysis
-	a.onset.Do(a.buf)
-	if a.onset.OnsetNow() {
-		a.RecentOnset = time.Now()
+// SetOverlap sets how much successive FFT frames overlap, from 0 up to 0.9375.
+// Frames are FftSize samples and advance by at most one buffer, so the
+// overlap is never less than 1-bufSize/FftSize (75% for 1024 sample buffers). Higher
+// overlaps run FFT, EQ and onset detection several times per buffer, improving beat
+// timing at a CPU cost proportional to 1/(1-overlap). Melbanks are still updated once per buffer.
+func (a *analyzer) SetOverlap(overlap float64) error {
+	if overlap < 0 || overlap > 0.9375 {
+		return fmt.Errorf("overlap %f must be between 0 and 0.9375", overlap)
+	}
+	a.overlap.Store(overlap)
+	return nil
+}
+
+// samples between successive FFT frames for a buffer size and requested overlap
+func hopSize(bufSize int, overlap float64) int {
+	hop := int(float64(FftSize) * (1 - overlap))
+	if hop > bufSize || hop < 1 {
+		return bufSize
+	}
+	return hop
+}
+
+// rms returns the root mean square of int16-scaled samples, normalised to 0-1
+func rms(data []float32) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range data {
+		sum += float64(x) * float64(x)
 	}
+	return math.Sqrt(sum/float64(len(data))) / float64(rawMax)
 }
 
 func (a *analyzer) Cleanup() {
@@ -148,6 +271,7 @@ func (a *analyzer) Cleanup() {
 	a.buf.Free()
 	a.onset.Free()
 	a.pvoc.Free()
+	a.levelsMel.Free()
 
 	for id := range a.melbanks {
 		a.DeleteMelbank(id)
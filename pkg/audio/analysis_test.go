@@ -1,6 +1,7 @@
 package audio
 
 import (
+	"math"
 	"testing"
 )
 
@@ -19,3 +20,115 @@ func BenchmarkAnalysis(t *testing.B) {
 	}
 	Analyzer.Cleanup()
 }
+
+func TestLevels(t *testing.T) {
+	buf := make(Buffer, BufferSize)
+	for i := range buf {
+		buf[i] = int16(16384 * math.Sin(2*math.Pi*440*float64(i)/float64(SampleRate)))
+	}
+	Analyzer.EnableLevels(true)
+	defer Analyzer.EnableLevels(false)
+	// first callback may only reinitialise the analyzer for the new buffer size
+	Analyzer.BufferCallback(buf)
+	Analyzer.BufferCallback(buf)
+
+	levels := Analyzer.Levels()
+	if levels.Peak < 0.49 || levels.Peak > 0.51 {
+		t.Errorf("Expected: peak ~0.5\r\n Got: %f", levels.Peak)
+	}
+	if levels.RMS < 0.34 || levels.RMS > 0.36 {
+		t.Errorf("Expected: rms ~0.354\r\n Got: %f", levels.RMS)
+	}
+	if len(levels.Bands) != int(melBins) {
+		t.Errorf("Expected: %d bands\r\n Got: %d", melBins, len(levels.Bands))
+	}
+}
+
+func TestShortBuffers(t *testing.T) {
+	Analyzer.BufferCallback(make(Buffer, BufferSize))
+	for _, n := range []int{0, 1} {
+		Analyzer.BufferCallback(make(Buffer, n))
+		if Analyzer.bufSize != int(BufferSize) {
+			t.Errorf("Expected: buffer size %d after %d-sample buffer\r\n Got: %d", BufferSize, n, Analyzer.bufSize)
+		}
+	}
+}
+
+func TestZeroPad(t *testing.T) {
+	b := Buffer{1, 2, 3}.ZeroPad(5)
+	if len(b) != 5 || b[2] != 3 || b[4] != 0 {
+		t.Errorf("Expected: [1 2 3 0 0]\r\n Got: %v", b)
+	}
+	if b := (Buffer{1, 2, 3}).ZeroPad(2); len(b) != 3 {
+		t.Errorf("Expected: unchanged buffer\r\n Got: %v", b)
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	for _, tt := range []struct {
+		bufSize int
+		overlap float64
+		want    int
+	}{
+		{1024, 0, 1024},
+		{1024, 0.75, 1024},
+		{1024, 0.875, 512},
+		{735, 0.9375, 256},
+	} {
+		if got := hopSize(tt.bufSize, tt.overlap); got != tt.want {
+			t.Errorf("hopSize(%d, %f)\r\n Expected: %d\r\n Got: %d", tt.bufSize, tt.overlap, tt.want, got)
+		}
+	}
+	if err := Analyzer.SetOverlap(1); err == nil {
+		t.Error("Expected: error for an overlap of 1")
+	}
+
+	s, _ := NewSpectrogram(16, 4)
+	Analyzer.AttachSpectrogram(s)
+	defer Analyzer.DetachSpectrogram(s)
+	defer Analyzer.SetOverlap(0)
+	Analyzer.SetOverlap(0.875)
+	buf := make(Buffer, BufferSize)
+	// the first callbacks may only reinitialise for the buffer size and hop
+	for i := 0; i < 3; i++ {
+		Analyzer.BufferCallback(buf)
+	}
+	before := len(s.Snapshot())
+	Analyzer.BufferCallback(buf)
+	if Analyzer.hopSize != 512 || len(s.Snapshot())-before != 2 {
+		t.Errorf("Expected: two 512 sample hops per buffer\r\n Got: hop %d, %d frames", Analyzer.hopSize, len(s.Snapshot())-before)
+	}
+}
+
+func TestLongBuffers(t *testing.T) {
+	s, _ := NewSpectrogram(16, 4)
+	Analyzer.AttachSpectrogram(s)
+	defer Analyzer.DetachSpectrogram(s)
+	defer Analyzer.BufferCallback(make(Buffer, BufferSize))
+
+	frame := int(FftSize)
+	// the first frame may only reinitialise the analyzer for the frame size
+	Analyzer.BufferCallback(make(Buffer, 2*frame))
+	for _, tt := range []struct {
+		size, frames, carry int
+	}{
+		{frame + frame/2, 1, frame / 2},
+		{frame + frame/2, 2, 0},
+		{frame + 1, 1, 1},
+	} {
+		before := len(s.Snapshot())
+		Analyzer.BufferCallback(make(Buffer, tt.size))
+		if Analyzer.bufSize != frame {
+			t.Errorf("Expected: buffer size %d\r\n Got: %d", frame, Analyzer.bufSize)
+		}
+		if got := len(s.Snapshot()) - before; got != tt.frames || len(Analyzer.carry) != tt.carry {
+			t.Errorf("Expected: %d frames and %d samples carried from a %d sample buffer\r\n Got: %d frames, %d carried", tt.frames, tt.carry, tt.size, got, len(Analyzer.carry))
+		}
+	}
+
+	// a buffer of another size drops the carried samples
+	Analyzer.BufferCallback(make(Buffer, BufferSize))
+	if len(Analyzer.carry) != 0 {
+		t.Errorf("Expected: no samples carried\r\n Got: %d", len(Analyzer.carry))
+	}
+}
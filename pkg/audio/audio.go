@@ -9,6 +9,7 @@ import (
 	"unsafe"
 
 	log "github.com/LedFx/ledfx/pkg/logger"
+	"github.com/LedFx/ledfx/pkg/metrics"
 	"github.com/LedFx/portaudio"
 )
 
@@ -29,17 +30,20 @@ func Terminate() {
 type AsyncMultiWriter struct {
 	mu             *sync.Mutex
 	writers        []io.Writer
+	formats        []Format // format of each writer, by index
 	mapMu          *sync.Mutex
 	indexMap       map[string]int
 	asyncThreshold int
 	writeFn        func(p []byte) (n int, err error)
 	wg             *sync.WaitGroup
+	profiler       *writeProfiler // nil unless profiling
 }
 
 func NewAsyncMultiWriter() *AsyncMultiWriter {
 	nmw := &AsyncMultiWriter{
 		mu:             &sync.Mutex{},
 		writers:        make([]io.Writer, 0),
+		formats:        make([]Format, 0),
 		mapMu:          &sync.Mutex{},
 		indexMap:       make(map[string]int),
 		asyncThreshold: 2,
@@ -60,6 +64,11 @@ func (bw *AsyncMultiWriter) SetAsyncThreshold(threshold int) {
 
 func (bw *AsyncMultiWriter) checkAsyncThreshold() {
 	// We only check if it's equal since checking for >= would spam the log.
+	if bw.profiler != nil {
+		// profiling measures writers one at a time
+		bw.writeFn = bw.writeSeq
+		return
+	}
 	if len(bw.writers) == bw.asyncThreshold {
 		log.Logger.WithField("context", "Audio MultiWriter").Infof("Writer threshold reached! (Writers: %d || Threshold: %d)", len(bw.writers), bw.asyncThreshold)
 		log.Logger.WithField("context", "Audio MultiWriter").Infoln("Enabling asynchronous streaming to compensate for threshold delay...")
@@ -71,12 +80,22 @@ func (bw *AsyncMultiWriter) checkAsyncThreshold() {
 
 // AddWriter adds a writer and ties the writer index to the provided name.
 func (bw *AsyncMultiWriter) AddWriter(writer io.Writer, name string) error {
+	return bw.AddWriterFormat(writer, name, FormatInt16)
+}
+
+// AddWriterFormat adds a writer which receives audio converted to format.
+// Writers sharing a format share a single conversion of each write.
+func (bw *AsyncMultiWriter) AddWriterFormat(writer io.Writer, name string, format Format) error {
 	if name == "" {
 		return ErrNameCannotBeOmitted
 	}
+	if !format.valid() {
+		return ErrUnknownFormat
+	}
 	bw.mu.Lock()
 	defer bw.mu.Unlock()
 	bw.writers = append(bw.writers, writer)
+	bw.formats = append(bw.formats, format)
 
 	bw.indexMap[name] = len(bw.writers) - 1
 
@@ -100,6 +119,7 @@ func (bw *AsyncMultiWriter) RemoveWriter(id string) error {
 	}
 
 	bw.writers = append(bw.writers[:index], bw.writers[index+1:]...)
+	bw.formats = append(bw.formats[:index], bw.formats[index+1:]...)
 	delete(bw.indexMap, id)
 
 	for key, val := range bw.indexMap {
@@ -113,23 +133,39 @@ func (bw *AsyncMultiWriter) RemoveWriter(id string) error {
 	return nil
 }
 
-func (bw *AsyncMultiWriter) removeByIndex(index int) error {
-	bw.mapMu.Lock()
-	defer bw.mapMu.Unlock()
-
-	var id string
+// nameByIndex must be called with mapMu held
+func (bw *AsyncMultiWriter) nameByIndex(index int) string {
 	for key, val := range bw.indexMap {
 		if val == index {
-			id = key
-			break
+			return key
 		}
 	}
+	return ""
+}
+
+// recordDrop counts a frame that the writer at index failed to accept
+func (bw *AsyncMultiWriter) recordDrop(index int) {
+	if !metrics.Enabled() {
+		return
+	}
+	bw.mapMu.Lock()
+	name := bw.nameByIndex(index)
+	bw.mapMu.Unlock()
+	metrics.DroppedFrames.IncLabel(name)
+}
+
+func (bw *AsyncMultiWriter) removeByIndex(index int) error {
+	bw.mapMu.Lock()
+	defer bw.mapMu.Unlock()
+
+	id := bw.nameByIndex(index)
 
 	if id == "" {
 		return ErrWriterNotFound
 	}
 
 	bw.writers = append(bw.writers[:index], bw.writers[index+1:]...)
+	bw.formats = append(bw.formats[:index], bw.formats[index+1:]...)
 	delete(bw.indexMap, id)
 	for key, val := range bw.indexMap {
 		if val > index {
@@ -145,6 +181,7 @@ func (bw *AsyncMultiWriter) RemoveAll() {
 	bw.mu.Lock()
 	defer bw.mu.Unlock()
 	bw.writers = bw.writers[:0]
+	bw.formats = bw.formats[:0]
 	bw.indexMap = make(map[string]int)
 
 	bw.checkAsyncThreshold()
@@ -158,13 +195,21 @@ func (bw *AsyncMultiWriter) writeAsync(p []byte) (int, error) {
 	bw.mu.Lock()
 	defer bw.mu.Unlock()
 
+	// convert up front, the writers run concurrently
+	var conv conversions
+	bufs := make([][]byte, len(bw.writers))
+	for i := range bw.writers {
+		bufs[i] = conv.get(p, bw.formats[i])
+	}
+
 	bw.wg.Add(len(bw.writers))
 
 	for i := range bw.writers {
 		go func(i2 int) {
 			defer bw.wg.Done()
-			if _, err := bw.writers[i2].Write(p); err != nil {
+			if _, err := bw.writers[i2].Write(bufs[i2]); err != nil {
 				log.Logger.WithField("context", "Named MultiWriter").Errorf("Error writing to writer with index %d: %v", i2, err)
+				bw.recordDrop(i2)
 				if err = bw.removeByIndex(i2); err != nil {
 					log.Logger.WithField("context", "Named MultiWriter").Errorf("Error removing writer with index '%d': %v", i2, err)
 				}
@@ -179,9 +224,44 @@ func (bw *AsyncMultiWriter) writeSeq(p []byte) (int, error) {
 	bw.mu.Lock()
 	defer bw.mu.Unlock()
 
+	if bw.profiler != nil {
+		return bw.writeSeqProfiled(p)
+	}
+
+	var conv conversions
 	for i := range bw.writers {
-		if n, err := bw.writers[i].Write(p); err != nil {
+		if n, err := bw.writers[i].Write(conv.get(p, bw.formats[i])); err != nil {
 			log.Logger.WithField("context", "Named MultiWriter").Errorf("Error writing to writer with index %d: %v", i, err)
+			bw.recordDrop(i)
+			if err = bw.removeByIndex(i); err != nil {
+				log.Logger.WithField("context", "Named MultiWriter").Errorf("Error removing writer with index '%d': %v", i, err)
+			}
+			return n, err
+		}
+	}
+	return len(p), nil
+}
+
+// writeSeqProfiled is writeSeq measuring each writer. Must be called with mu held.
+func (bw *AsyncMultiWriter) writeSeqProfiled(p []byte) (int, error) {
+	bw.profiler.mu.Lock()
+	bw.profiler.writes++
+	bw.profiler.mu.Unlock()
+
+	var conv conversions
+	for i := range bw.writers {
+		bw.mapMu.Lock()
+		name := bw.nameByIndex(i)
+		bw.mapMu.Unlock()
+
+		var n int
+		var err error
+		bw.profiler.measure(name, func() {
+			n, err = bw.writers[i].Write(conv.get(p, bw.formats[i]))
+		})
+		if err != nil {
+			log.Logger.WithField("context", "Named MultiWriter").Errorf("Error writing to writer with index %d: %v", i, err)
+			bw.recordDrop(i)
 			if err = bw.removeByIndex(i); err != nil {
 				log.Logger.WithField("context", "Named MultiWriter").Errorf("Error removing writer with index '%d': %v", i, err)
 			}
@@ -201,12 +281,38 @@ func (b Buffer) AsFloat64() []float64 {
 	return out
 }
 
+// ZeroPad returns the buffer extended with silence to at least n samples
+func (b Buffer) ZeroPad(n int) Buffer {
+	if len(b) >= n {
+		return b
+	}
+	padded := make(Buffer, n)
+	copy(padded, b)
+	return padded
+}
+
+// AsBytes encodes the buffer as 16 bit little endian PCM, the byte order used throughout
+// the audio pipeline. It is the same as AsBytesLE.
 func (b Buffer) AsBytes() []byte {
+	return b.AsBytesLE()
+}
+
+// AsBytesLE encodes the buffer as 16 bit little endian PCM
+func (b Buffer) AsBytesLE() []byte {
+	return b.asBytes(binary.LittleEndian)
+}
+
+// AsBytesBE encodes the buffer as 16 bit big endian PCM, for consumers with a fixed network byte order
+func (b Buffer) AsBytesBE() []byte {
+	return b.asBytes(binary.BigEndian)
+}
+
+func (b Buffer) asBytes(order binary.ByteOrder) []byte {
 	byteBuf := make([]byte, len(b)*2)
 
 	var offset int
 	for i := range b {
-		binary.LittleEndian.PutUint16(byteBuf[offset:], uint16(b[i]))
+		order.PutUint16(byteBuf[offset:], uint16(b[i]))
 		offset += 2
 	}
 	return byteBuf
@@ -307,14 +413,23 @@ func HighestFloat(f []float64) float64 {
 	return highest
 }
 
+// BytesToAudioBuffer decodes 16 bit PCM in the host's byte order, which is the
+// little endian order of AsBytes on all supported platforms.
 func BytesToAudioBuffer(p []byte) (out Buffer) {
 	out = make([]int16, len(p))
+	DecodeBytes(out, p)
+	return
+}
+
+// DecodeBytes decodes 16 bit PCM in the host's byte order into dst, returning the number of samples written
+func DecodeBytes(dst Buffer, p []byte) int {
 	var offset int
-	for i := 0; i < len(p); i += 2 {
-		out[offset] = twoBytesToInt16Unsafe(p[i : i+2])
+	// a trailing odd byte cannot form a sample, so it is ignored
+	for i := 0; i+1 < len(p) && offset < len(dst); i += 2 {
+		dst[offset] = twoBytesToInt16Unsafe(p[i : i+2])
 		offset++
 	}
-	return
+	return offset
 }
 func twoBytesToInt16Unsafe(p []byte) (out int16) {
 	return *(*int16)(unsafe.Pointer(&p[0]))
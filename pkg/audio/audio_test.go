@@ -0,0 +1,740 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMultiWriterFormats(t *testing.T) {
+	in := Buffer{16384, -32768}.AsBytes()
+	floats := func(f ...float32) []byte {
+		out := make([]byte, 4*len(f))
+		for i := range f {
+			binary.LittleEndian.PutUint32(out[4*i:], math.Float32bits(f[i]))
+		}
+		return out
+	}
+
+	for _, async := range []bool{false, true} {
+		bw := NewAsyncMultiWriter()
+		var raw, f32, normA, normB bytes.Buffer
+		bw.AddWriterFormat(&raw, "raw", FormatInt16)
+		bw.AddWriterFormat(&f32, "float", FormatFloat32)
+		bw.AddWriterFormat(&normA, "normalized a", FormatNormalized)
+		bw.AddWriterFormat(&normB, "normalized b", FormatNormalized)
+		if async {
+			bw.SetAsyncThreshold(4)
+		}
+		bw.Write(in)
+
+		for _, tt := range []struct {
+			name      string
+			got, want []byte
+		}{
+			{"int16", raw.Bytes(), in},
+			{"float32", f32.Bytes(), floats(16384, -32768)},
+			{"normalized", normA.Bytes(), floats(0.5, -1)},
+			{"shared normalized", normB.Bytes(), floats(0.5, -1)},
+		} {
+			if !bytes.Equal(tt.got, tt.want) {
+				t.Errorf("%s (async %v)\r\n Expected: %v\r\n Got: %v", tt.name, async, tt.want, tt.got)
+			}
+		}
+	}
+
+	if err := NewAsyncMultiWriter().AddWriterFormat(&bytes.Buffer{}, "bad", numFormats); err != ErrUnknownFormat {
+		t.Errorf("Expected: %v\r\n Got: %v", ErrUnknownFormat, err)
+	}
+}
+
+func TestDownmixStereo(t *testing.T) {
+	got := DownmixStereo(Buffer{100, 200, 32767, 32767, -32768, -32768, 5})
+	want := Buffer{150, 32767, -32768}
+	if len(got) != len(want) {
+		t.Fatalf("Expected: %v\r\n Got: %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected: %v\r\n Got: %v", want, got)
+			break
+		}
+	}
+}
+
+func TestDownmixModes(t *testing.T) {
+	// centred, hard-panned, out of phase, full scale centred
+	in := Buffer{1000, 1000, 1000, 0, 1000, -1000, 32767, 32767}
+	tests := []struct {
+		mode DownmixMode
+		want Buffer
+	}{
+		{DownmixAverage, Buffer{1000, 500, 0, 32767}},
+		{DownmixPanLaw, Buffer{1414, 707, 0, 32767}},
+		{DownmixPeak, Buffer{1000, 1000, 0, 32767}},
+	}
+	for _, tt := range tests {
+		if got := DownmixStereo(in, tt.mode); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Expected: %s %v\r\n Got: %v", tt.mode, tt.want, got)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	bw := NewAsyncMultiWriter()
+	var out bytes.Buffer
+	bw.AddWriter(&out, "out")
+
+	c := NewChain(bw, Gain(2))
+	c.Append(ProcessorFunc(func(in Buffer) Buffer { return append(in, 1) }))
+	c.Write(Buffer{100, 20000, -20000}.AsBytes())
+	if want := (Buffer{200, 32767, -32768, 1}).AsBytes(); !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, out.Bytes())
+	}
+
+	out.Reset()
+	c.SetProcessors()
+	in := Buffer{1, 2}.AsBytes()
+	c.Write(in)
+	if !bytes.Equal(out.Bytes(), in) {
+		t.Errorf("Expected: %v\r\n Got: %v", in, out.Bytes())
+	}
+}
+
+func TestWriterProcessors(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		bw := NewAsyncMultiWriter()
+		var raw, gained, normalized bytes.Buffer
+		bw.AddWriter(&raw, "recorder")
+		bw.AddWriterProcessors(&gained, "effects", FormatInt16, Gain(2))
+		bw.AddWriterProcessors(&normalized, "normalized", FormatNormalized, Gain(0.5))
+		if async {
+			bw.SetAsyncThreshold(3)
+		}
+		in := Buffer{100, -200}.AsBytes()
+		bw.Write(in)
+
+		if !bytes.Equal(raw.Bytes(), in) {
+			t.Errorf("Expected: raw branch unchanged %v (async %v)\r\n Got: %v", in, async, raw.Bytes())
+		}
+		if want := (Buffer{200, -400}).AsBytes(); !bytes.Equal(gained.Bytes(), want) {
+			t.Errorf("Expected: gained branch %v (async %v)\r\n Got: %v", want, async, gained.Bytes())
+		}
+		if want := convert(Buffer{50, -100}.AsBytes(), FormatNormalized); !bytes.Equal(normalized.Bytes(), want) {
+			t.Errorf("Expected: processed then converted %v (async %v)\r\n Got: %v", want, async, normalized.Bytes())
+		}
+	}
+}
+
+func TestBiquad(t *testing.T) {
+	// a constant signal passes a low pass and is removed by a high pass
+	dc := func() Buffer {
+		b := make(Buffer, 4096)
+		for i := range b {
+			b[i] = 10000
+		}
+		return b
+	}
+	if got := NewLowPass(1000).Process(dc()); math.Abs(float64(got[len(got)-1])-10000) > 1 {
+		t.Errorf("Expected: low pass settles at 10000\r\n Got: %d", got[len(got)-1])
+	}
+	if got := NewHighPass(100).Process(dc()); math.Abs(float64(got[len(got)-1])) > 1 {
+		t.Errorf("Expected: high pass settles at 0\r\n Got: %d", got[len(got)-1])
+	}
+}
+
+func TestProfile(t *testing.T) {
+	bw := NewAsyncMultiWriter()
+	var a, b bytes.Buffer
+	bw.AddWriter(&a, "a")
+	bw.AddWriter(&b, "b")
+	bw.StartProfile()
+	b.Grow(1 << 20) // allocations anywhere while profiling are shared between the stages
+	bw.Write([]byte{1, 2})
+	bw.Write([]byte{3, 4})
+	writes, stages := bw.StopProfile()
+	if writes != 2 || len(stages) != 2 {
+		t.Fatalf("Expected: 2 writes to 2 stages\r\n Got: %d writes, %+v", writes, stages)
+	}
+	for i, name := range []string{"a", "b"} {
+		if stages[i].Name != name || stages[i].Calls != 2 {
+			t.Errorf("Expected: stage %s with 2 calls\r\n Got: %+v", name, stages[i])
+		}
+	}
+	if got := stages[0].AllocBytes + stages[1].AllocBytes; got < 1<<20 {
+		t.Errorf("Expected: at least %d bytes allocated while profiling\r\n Got: %d", 1<<20, got)
+	}
+	if writes, _ := bw.StopProfile(); writes != 0 {
+		t.Errorf("Expected: no writes once stopped\r\n Got: %d", writes)
+	}
+}
+
+func TestDriftCorrector(t *testing.T) {
+	clock := time.Unix(0, 0)
+	d := NewDriftCorrector(2, 1000, 100, 10)
+	d.now = func() time.Time { return clock }
+
+	frame := Buffer{1, 2, 3, 4, 5, 6}
+	if got := d.Correct(append(Buffer{}, frame...), 100); len(got) != len(frame) {
+		t.Errorf("Expected: no correction on target\r\n Got: %v", got)
+	}
+	// a queue that stays high drops frames once the smoothed level crosses the tolerance
+	var got Buffer
+	for i := 0; i < 100; i++ {
+		got = d.Correct(append(Buffer{}, frame...), 200)
+	}
+	if want := (Buffer{1, 2, 3, 4}); len(got) != len(want) || got[3] != 4 {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+	for i := 0; i < 200; i++ {
+		got = d.Correct(append(Buffer{}, frame...), 0)
+	}
+	if want := (Buffer{1, 2, 3, 4, 5, 6, 5, 6}); len(got) != len(want) || got[7] != 6 {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+
+	// 10100 frames over 10s at a nominal 1000Hz is 1% fast
+	d = NewDriftCorrector(1, 1000, 0, 1<<30)
+	d.now = func() time.Time { return clock }
+	d.Correct(Buffer{0}, 0)
+	clock = clock.Add(driftWindow)
+	d.Correct(make(Buffer, 10100), 0)
+	if ppm := d.DriftPPM(); math.Abs(ppm-10000) > 1 {
+		t.Errorf("Expected: 10000 ppm\r\n Got: %f", ppm)
+	}
+}
+
+func TestInterleave(t *testing.T) {
+	left, right := Buffer{1, 2, 3}, Buffer{-1, -2, -3}
+	got, err := Interleave(left, right)
+	want := Buffer{1, -1, 2, -2, 3, -3}
+	if err != nil || !equalBuffers(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v, %v", want, got, err)
+	}
+	if _, err := Interleave(left, Buffer{1}); err == nil {
+		t.Error("Expected: error for mismatched channel lengths")
+	}
+
+	planar, err := Deinterleave(got, 2)
+	if err != nil || len(planar) != 2 || !equalBuffers(planar[0], left) || !equalBuffers(planar[1], right) {
+		t.Errorf("Expected: %v %v\r\n Got: %v, %v", left, right, planar, err)
+	}
+	if _, err := Deinterleave(Buffer{1, 2, 3}, 2); err == nil {
+		t.Error("Expected: error for a buffer that doesn't divide into the channels")
+	}
+
+	// destinations with enough capacity are reused
+	dst := make(Buffer, 0, 8)
+	reused, _ := InterleaveInto(dst, left, right)
+	if &reused[0] != &dst[:1][0] {
+		t.Error("Expected: InterleaveInto to reuse dst")
+	}
+}
+
+func equalBuffers(a, b Buffer) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSpectrogram(t *testing.T) {
+	s, err := NewSpectrogram(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Push([]float64{1, 3, 5, 7})
+	s.Push([]float64{2})
+	s.Push([]float64{0, 0, 8, 8})
+
+	snap := s.Snapshot()
+	want := [][]float64{{2, 2}, {0, 8}}
+	if len(snap) != len(want) {
+		t.Fatalf("Expected: %v\r\n Got: %v", want, snap)
+	}
+	for i := range want {
+		for b := range want[i] {
+			if snap[i][b] != want[i][b] {
+				t.Errorf("Expected: %v\r\n Got: %v", want, snap)
+			}
+		}
+	}
+
+	var img bytes.Buffer
+	if err := s.WriteToImage(&img); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(img.Bytes(), []byte("\x89PNG")) {
+		t.Error("Expected: PNG output")
+	}
+	if _, err := NewSpectrogram(0, 1); err == nil {
+		t.Error("Expected: error for an empty history")
+	}
+}
+
+func TestAnalyzerSpectrogram(t *testing.T) {
+	// without overlap each buffer is one hop, so it records one frame. The first buffer
+	// reinitialises the analyzer if another test left it at a different size or hop.
+	overlap := Analyzer.overlap.Load()
+	defer Analyzer.SetOverlap(overlap)
+	if err := Analyzer.SetOverlap(0); err != nil {
+		t.Fatal(err)
+	}
+	buf := make(Buffer, BufferSize)
+	Analyzer.BufferCallback(buf)
+
+	s, _ := NewSpectrogram(4, 8)
+	Analyzer.AttachSpectrogram(s)
+	Analyzer.BufferCallback(buf)
+	Analyzer.BufferCallback(buf)
+	Analyzer.DetachSpectrogram(s)
+	Analyzer.BufferCallback(buf)
+	if n := len(s.Snapshot()); n != 2 {
+		t.Errorf("Expected: 2 frames while attached\r\n Got: %d", n)
+	}
+}
+
+func TestPeakMeter(t *testing.T) {
+	clock := time.Unix(0, 0)
+	m := NewPeakMeter(1, time.Second)
+	m.now = func() time.Time { return clock }
+
+	m.Update(Buffer{0, -16384})
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-3 }
+	if cur, peak := m.Levels(); !near(cur, 0.5) || !near(peak, 0.5) {
+		t.Errorf("Expected: 0.5 0.5\r\n Got: %f %f", cur, peak)
+	}
+	// the level falls at the decay rate while the peak is held
+	clock = clock.Add(250 * time.Millisecond)
+	if cur, peak := m.Levels(); !near(cur, 0.25) || !near(peak, 0.5) {
+		t.Errorf("Expected: 0.25 0.5\r\n Got: %f %f", cur, peak)
+	}
+	// the hold expires after 1s, then the peak falls too, however often it's sampled
+	for i := 0; i < 10; i++ {
+		clock = clock.Add(100 * time.Millisecond)
+		m.Levels()
+	}
+	if cur, peak := m.Levels(); cur != 0 || !near(peak, 0.25) {
+		t.Errorf("Expected: 0 0.25\r\n Got: %f %f", cur, peak)
+	}
+}
+
+func TestRamp(t *testing.T) {
+	r := NewRamp(20 * time.Millisecond)
+	in := func() Buffer {
+		b := make(Buffer, 64)
+		for i := range b {
+			b[i] = 1000
+		}
+		return b
+	}
+
+	// the first buffer starts the fade in from silence
+	if got := r.Process(in()); got[len(got)-1] != 0 {
+		t.Errorf("Expected: first buffer silent\r\n Got: %v", got[len(got)-1])
+	}
+	time.Sleep(30 * time.Millisecond)
+	got := r.Process(in())
+	if got[0] != 0 || got[len(got)-1] <= 900 {
+		t.Errorf("Expected: rising from 0 towards 1000\r\n Got: %v to %v", got[0], got[len(got)-1])
+	}
+	if !r.Bypass() {
+		t.Errorf("Expected: bypass once fully faded in\r\n Got: gain %v", r.Gain())
+	}
+
+	r.FadeOut()
+	r.Process(in())
+	time.Sleep(30 * time.Millisecond)
+	r.Process(in())
+	if got := r.Process(in()); got[0] != 0 || got[len(got)-1] != 0 {
+		t.Errorf("Expected: silence once faded out\r\n Got: %v", got)
+	}
+}
+
+func TestRampWait(t *testing.T) {
+	r := NewRamp(20 * time.Millisecond)
+	buf := make(Buffer, 64)
+
+	// nothing has been written, so there's nothing to wait for
+	start := time.Now()
+	r.FadeOut()
+	if !r.Wait(time.Second) || time.Since(start) > rampIdleAfter {
+		t.Errorf("Expected: Wait to return at once with nothing writing\r\n Got: %s", time.Since(start))
+	}
+
+	write := func(stop chan struct{}) {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(5 * time.Millisecond):
+				r.Process(buf)
+			}
+		}
+	}
+	r.FadeIn()
+	r.Process(buf)
+	stop := make(chan struct{})
+	go write(stop)
+	if !r.Wait(time.Second) || r.Gain() != 1 {
+		t.Errorf("Expected: Wait to return once faded in\r\n Got: gain %v", r.Gain())
+	}
+	r.SetDuration(time.Second)
+	r.FadeOut()
+	if r.Wait(50 * time.Millisecond) {
+		t.Errorf("Expected: Wait to time out part way through a fade\r\n Got: gain %v", r.Gain())
+	}
+	close(stop)
+}
+
+func TestAsBytesByteOrder(t *testing.T) {
+	b := Buffer{0x0102, -2}
+	if got, want := b.AsBytes(), []byte{0x02, 0x01, 0xFE, 0xFF}; !bytes.Equal(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+	if got, want := b.AsBytesLE(), b.AsBytes(); !bytes.Equal(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+	if got, want := b.AsBytesBE(), []byte{0x01, 0x02, 0xFF, 0xFE}; !bytes.Equal(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+}
+
+func TestSilenceDetector(t *testing.T) {
+	s := NewSilenceDetector(-60)
+	s.Update(-20)
+	if got := s.SilentFor(); got != 0 {
+		t.Errorf("Expected: not silent while loud\r\n Got: %v", got)
+	}
+	s.Update(-80)
+	time.Sleep(20 * time.Millisecond)
+	s.Update(-80)
+	if got := s.SilentFor(); got < 20*time.Millisecond {
+		t.Errorf("Expected: silent for at least 20ms\r\n Got: %v", got)
+	}
+	s.Update(-20)
+	if got := s.SilentFor(); got != 0 {
+		t.Errorf("Expected: silence reset by loud audio\r\n Got: %v", got)
+	}
+}
+
+func TestClipDetector(t *testing.T) {
+	now := time.Now()
+	c := NewClipDetector(time.Second, 0.1)
+	c.now = func() time.Time { return now }
+	c.winStart = now
+	var clipped []ClipStats
+	c.OnClip(func(stats ClipStats) { clipped = append(clipped, stats) })
+
+	b := Buffer{32767, -32768, -32767, 32766, 0, 100, 0, 0}
+	if n := c.Update(&b); n != 3 {
+		t.Errorf("Expected: 3 clipped samples\r\n Got: %d", n)
+	}
+	now = now.Add(time.Second)
+	c.Update(&Buffer{0, 0, 0, 0, 0, 0, 0, 0})
+	if got := c.Stats(); got.Total != 3 || got.Rate != 3.0/16 || len(clipped) != 1 {
+		t.Errorf("Expected: 3 clipped, rate 3/16, one callback\r\n Got: %+v, %d callbacks", got, len(clipped))
+	}
+
+	// a window under the threshold updates the rate without a callback
+	now = now.Add(time.Second)
+	c.Update(&Buffer{32767, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	if got := c.Stats(); got.Rate != 1.0/12 || len(clipped) != 1 {
+		t.Errorf("Expected: rate 1/12 without a callback\r\n Got: %+v, %d callbacks", got, len(clipped))
+	}
+}
+
+func TestClipDetectorInChain(t *testing.T) {
+	bw := NewAsyncMultiWriter()
+	var out bytes.Buffer
+	bw.AddWriter(&out, "out")
+
+	// the input is counted as it arrives, though the gain after brings it below full scale
+	c := NewClipDetector(time.Second, 0.1)
+	NewChain(bw, c, Gain(0.5)).Write(Buffer{32767, -32768, 100, 0}.AsBytes())
+	if got := c.Stats().Total; got != 2 {
+		t.Errorf("Expected: 2 clipped input samples\r\n Got: %d", got)
+	}
+	if want := (Buffer{16384, -16384, 50, 0}).AsBytes(); !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, out.Bytes())
+	}
+}
+
+func TestRMSChannels(t *testing.T) {
+	// left is a full scale square wave, right is silent
+	b := Buffer{32767, 0, -32767, 0, 32767, 0, -32767, 0}
+	got, err := RMSChannels(b, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 0 {
+		t.Errorf("Expected: [1 0]\r\n Got: %v", got)
+	}
+	if mixed := RMS(b); math.Abs(mixed-math.Sqrt(0.5)) > 1e-3 {
+		t.Errorf("Expected: %f\r\n Got: %f", math.Sqrt(0.5), mixed)
+	}
+	if _, err := RMSChannels(b[:3], 2); err == nil {
+		t.Error("Expected: error for a buffer not divisible by the channel count")
+	}
+	if _, err := RMSChannels(b, 0); err == nil {
+		t.Error("Expected: error for zero channels")
+	}
+}
+
+func TestCompressor(t *testing.T) {
+	loud := func() Buffer {
+		b := make(Buffer, 512)
+		for i := range b {
+			b[i] = 32767
+		}
+		return b
+	}
+
+	// a -6dB limiter holds full scale at half scale
+	l := NewLimiter(-6.0206, 10*time.Millisecond)
+	if got := l.Process(loud()); math.Abs(float64(got[len(got)-1])-16384) > 2 {
+		t.Errorf("Expected: limited to 16384\r\n Got: %d", got[len(got)-1])
+	}
+
+	// 2:1 above -12dB takes 0dB input down to -6dB
+	c, err := NewCompressor(CompressorParams{Threshold: -12, Ratio: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Process(loud())
+	if got := c.GainReduction(); math.Abs(got+6) > 1e-6 {
+		t.Errorf("Expected: -6dB gain reduction\r\n Got: %f", got)
+	}
+
+	// makeup gain can be changed live, and quiet audio is only affected by makeup
+	if err := c.SetParams(CompressorParams{Threshold: -12, Ratio: 2, Makeup: 6.0206}); err != nil {
+		t.Fatal(err)
+	}
+	quiet := Buffer{1000, 1000, 1000}
+	if got := c.Process(quiet); math.Abs(float64(got[len(got)-1])-2000) > 2 {
+		t.Errorf("Expected: makeup to double quiet audio\r\n Got: %d", got[len(got)-1])
+	}
+
+	if _, err := NewCompressor(CompressorParams{Ratio: 0.5}); err == nil {
+		t.Error("Expected: error for a ratio below 1")
+	}
+}
+
+func TestSoftClip(t *testing.T) {
+	got := SoftClip{Drive: 12}.Process(Buffer{1000, -1000, 8000, 16000, -32767})
+	// 12dB is about x4, so quiet audio is just louder
+	if math.Abs(float64(got[0])-3981) > 2 || got[1] != -got[0] {
+		t.Errorf("Expected: about ±3981 below the knee\r\n Got: %v", got[:2])
+	}
+	// overdriven audio rounds over, still increasing but never past full scale
+	if !(got[2] < got[3] && got[3] < 32767 && got[4] >= -32767) {
+		t.Errorf("Expected: saturated below full scale\r\n Got: %v", got[2:])
+	}
+
+	// 6dB of drive takes 20000 to about 40000, which hard clipping would square off at 32767
+	c, _ := NewCompressor(CompressorParams{Threshold: 0, Ratio: 1, SoftClip: true, Drive: 6.0206})
+	if out := c.Process(Buffer{20000}); out[0] < 30000 || out[0] > 32000 {
+		t.Errorf("Expected: compressor output soft clipped\r\n Got: %d", out[0])
+	}
+}
+
+func TestPitchDetector(t *testing.T) {
+	p := NewPitchDetector(50, 2000)
+	n := p.MinBufferSize()
+	sine := make(Buffer, n)
+	for i := range sine {
+		sine[i] = int16(10000 * math.Sin(2*math.Pi*220*float64(i)/float64(SampleRate)))
+	}
+	freq, conf := p.Detect(sine)
+	if math.Abs(freq-220) > 1 || conf < 0.9 {
+		t.Errorf("Expected: 220Hz with high confidence\r\n Got: %fHz, %f", freq, conf)
+	}
+	if name, cents := NearestNote(freq); name != "A3" || math.Abs(cents) > 10 {
+		t.Errorf("Expected: A3\r\n Got: %s %+.1f cents", name, cents)
+	}
+
+	if _, conf := p.Detect(make(Buffer, n)); conf != 0 {
+		t.Errorf("Expected: no confidence for silence\r\n Got: %f", conf)
+	}
+	if name, _ := NearestNote(261.63); name != "C4" {
+		t.Errorf("Expected: C4\r\n Got: %s", name)
+	}
+}
+
+func TestTempoTracker(t *testing.T) {
+	tt := NewTempoTracker()
+	start := time.Now().Add(-10 * time.Second)
+	beat := 500 * time.Millisecond // 120 BPM
+	for i := 0; i < 20; i++ {
+		at := start.Add(time.Duration(i) * beat)
+		// every fourth beat is also detected on the off-beat, which folding should absorb
+		tt.Onset(at)
+		if i%4 == 0 {
+			tt.Onset(at.Add(beat / 2))
+		}
+	}
+	if bpm := tt.BPM(); math.Abs(bpm-120) > 1 {
+		t.Errorf("Expected: 120 BPM\r\n Got: %f", bpm)
+	}
+	last := start.Add(19 * beat)
+	if phase := tt.PhaseAt(last.Add(beat / 4)); math.Abs(phase-0.25) > 0.05 {
+		t.Errorf("Expected: phase 0.25 a quarter beat after an onset\r\n Got: %f", phase)
+	}
+}
+
+func TestTempoTrackerPhaseContinuous(t *testing.T) {
+	tt := NewTempoTracker()
+	at := time.Now().Add(-time.Minute)
+	// a long run at 120 BPM, so a phase jump would be scaled by many beats
+	for i := 0; i < 60; i++ {
+		tt.Onset(at)
+		at = at.Add(500 * time.Millisecond)
+	}
+	beat := time.Duration(float64(time.Minute) / 128)
+	for i := 0; i < 40; i++ {
+		before := tt.PhaseAt(at)
+		tt.Onset(at)
+		// only the nudge towards the onset may move the phase
+		jump := math.Abs(tt.PhaseAt(at) - before)
+		if jump > 0.5 {
+			jump = 1 - jump
+		}
+		if jump > phaseSmoothing*0.25+1e-6 {
+			t.Fatalf("Expected: continuous phase at onset %d after the tempo change\r\n Got: jump of %f", i, jump)
+		}
+		at = at.Add(beat)
+	}
+	if bpm := tt.BPM(); math.Abs(bpm-128) > 1 {
+		t.Errorf("Expected: 128 BPM\r\n Got: %f", bpm)
+	}
+}
+
+func TestBufferPool(t *testing.T) {
+	bp := NewBufferPool()
+	b := bp.Get(4)
+	copy(b, Buffer{1, 2, 3, 4})
+	bp.Put(b)
+	// reused buffers come back zeroed, at the requested length
+	for i := 0; i < 2; i++ {
+		if got := bp.Get(3); len(got) != 3 || got[0] != 0 || got[2] != 0 {
+			t.Errorf("Expected: [0 0 0]\r\n Got: %v", got)
+		}
+	}
+
+	in := Buffer{1, -2, 300, -32768}
+	if got := bp.FromBytes(in.AsBytes()); !bytes.Equal(got.AsBytes(), in.AsBytes()) {
+		t.Errorf("Expected: %v\r\n Got: %v", in, got)
+	}
+}
+
+// run through the pipeline's byte to sample conversion at 48kHz, in BufferSize frames
+func BenchmarkBufferPool(b *testing.B) {
+	p := make(Buffer, BufferSize).AsBytes()
+	framesPerSecond := 48000 / float64(BufferSize)
+	b.Run("alloc", func(b *testing.B) {
+		decode := func() { _ = BytesToAudioBuffer(p)[:len(p)/2] }
+		b.ReportMetric(testing.AllocsPerRun(100, decode)*framesPerSecond, "allocs/s")
+		for i := 0; i < b.N; i++ {
+			decode()
+		}
+	})
+	b.Run("pool", func(b *testing.B) {
+		decode := func() { Buffers.Put(Buffers.FromBytes(p)) }
+		b.ReportMetric(testing.AllocsPerRun(100, decode)*framesPerSecond, "allocs/s")
+		for i := 0; i < b.N; i++ {
+			decode()
+		}
+	})
+}
+
+func TestDelayLine(t *testing.T) {
+	d, err := NewDelayLine(2, 1000) // 2 samples
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the delay carries across buffers
+	got := append(d.Process(Buffer{100, 200, 300}), d.Process(Buffer{400, 500})...)
+	if want := (Buffer{0, 0, 100, 200, 300}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+
+	// half a sample more interpolates between neighbours
+	if err := d.SetDelay(2.5); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.Process(Buffer{600}), (Buffer{350}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+
+	// stereo frames are delayed together
+	if err := d.SetChannels(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetDelay(1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := d.Process(Buffer{1, -1, 2, -2}), (Buffer{0, 0, 1, -1}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+
+	if err := d.SetDelay(MaxDelay + 1); err == nil {
+		t.Error("Expected: error for a delay over MaxDelay")
+	}
+}
+
+func TestMidSide(t *testing.T) {
+	in := Buffer{1000, 200, -300, -300, 32767, -32768}
+	mid, side := EncodeMS(in)
+	if want := (Buffer{600, -300, 0}); !reflect.DeepEqual(mid, want) {
+		t.Errorf("Expected: mid %v\r\n Got: %v", want, mid)
+	}
+	if want := (Buffer{400, 0, 32767}); !reflect.DeepEqual(side, want) {
+		t.Errorf("Expected: side %v\r\n Got: %v", want, side)
+	}
+	if got, want := DecodeMS(mid, side), (Buffer{1000, 200, -300, -300, 32767, -32767}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+
+	if got, want := Width(0).Process(Buffer{1000, 200}), (Buffer{600, 600}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected: mono %v\r\n Got: %v", want, got)
+	}
+	if got, want := Width(2).Process(Buffer{1000, 200, 30000, -30000}), (Buffer{1400, -200, 32767, -32768}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected: wide %v\r\n Got: %v", want, got)
+	}
+}
+
+func TestResampler(t *testing.T) {
+	// upsampling a ramp in pieces interpolates between its samples
+	r := NewResampler(22050, 44100)
+	var out Buffer
+	for _, in := range []Buffer{{0, 100}, {200, 300, 400}} {
+		out = append(out, r.Process(in)...)
+	}
+	want := Buffer{0, 50, 100, 150, 200, 250, 300, 350}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, out)
+	}
+
+	// a second of audio keeps its duration at the new rate
+	r = NewResampler(48000, 44100)
+	n := 0
+	for i := 0; i < 48; i++ {
+		n += len(r.Process(make(Buffer, 1000)))
+	}
+	if n < 44099 || n > 44101 {
+		t.Errorf("Expected: about 44100 samples\r\n Got: %d", n)
+	}
+
+	in := Buffer{1, 2, 3}
+	if got := NewResampler(44100, 44100).Process(in); &got[0] != &in[0] {
+		t.Error("Expected: matching rates to return the buffer unchanged")
+	}
+}
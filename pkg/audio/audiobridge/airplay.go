@@ -2,12 +2,23 @@ package audiobridge
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/LedFx/ledfx/pkg/integrations/airplay2"
 	log "github.com/LedFx/ledfx/pkg/logger"
+	"github.com/LedFx/ledfx/pkg/metrics"
 )
 
 func (br *Bridge) StartAirPlayInput(name string, port int) error {
+	return br.StartAirPlayInputConfig(airplay2.Config{
+		AdvertisementName: name,
+		RTSPPort:          port,
+	})
+}
+
+// StartAirPlayInputConfig starts an AirPlay server input with the full set of server options.
+func (br *Bridge) StartAirPlayInputConfig(conf airplay2.Config) error {
 	if br.inputType != -1 {
 		br.closeInput()
 	}
@@ -17,10 +28,7 @@ func (br *Bridge) StartAirPlayInput(name string, port int) error {
 		br.airplay = newAirPlayHandler()
 	}
 
-	br.airplay.server = airplay2.NewServer(airplay2.Config{
-		AdvertisementName: name,
-		Port:              port,
-	}, br.byteWriter)
+	br.airplay.server = airplay2.NewServer(conf, br.chain, br.byteWriter)
 
 	if err := br.airplay.server.Start(); err != nil {
 		return fmt.Errorf("error starting AirPlay server: %w", err)
@@ -28,6 +36,65 @@ func (br *Bridge) StartAirPlayInput(name string, port int) error {
 	return nil
 }
 
+// how long a restart waits for the old server to release its port
+const airPlayRestartTimeout = 5 * time.Second
+
+// RestartAirPlayInput replaces the running AirPlay server with one started with conf.
+// Senders are disconnected as the old server stops, and the new one is announced over
+// mDNS under its own name and port. AirPlay outputs and auto switch are carried over to
+// the new server. If it fails to start, the old server is started again with its own config.
+func (br *Bridge) RestartAirPlayInput(conf airplay2.Config) error {
+	if br.inputType != inputTypeAirPlayServer || br.airplay == nil || br.airplay.server == nil {
+		return fmt.Errorf("server %w", ErrNotActive)
+	}
+	old := br.airplay.server
+	oldConf := old.Config()
+	outputs := old.Clients()
+
+	br.fadeOut()
+	defer br.ramp.FadeIn()
+
+	// senders leaving the old server mustn't switch sources, or count against the new one
+	if as := br.currentAutoSwitch(); as != nil {
+		as.unwatchSenders()
+	}
+	if !old.Stopped() {
+		old.Stop()
+		if !old.WaitTimeout(airPlayRestartTimeout) {
+			log.Logger.WithField("context", "AirPlay Restart").Warnf("Old server didn't stop within %s, starting anyway", airPlayRestartTimeout)
+		}
+	}
+
+	if err := br.replaceAirPlayServer(conf, outputs); err != nil {
+		log.Logger.WithField("context", "AirPlay Restart").Warnf("Error starting new server, restarting the old one: %v", err)
+		if oldErr := br.replaceAirPlayServer(oldConf, outputs); oldErr != nil {
+			return fmt.Errorf("error starting AirPlay server: %w, and error restarting the old one: %v", err, oldErr)
+		}
+		return fmt.Errorf("error starting AirPlay server: %w", err)
+	}
+	log.Logger.WithField("context", "AirPlay Restart").Infof("Restarted AirPlay server as '%s' on port %d", conf.AdvertisementName, br.airplay.server.Port())
+	return nil
+}
+
+// replaceAirPlayServer starts a server with conf in place of the stopped one, relaying to outputs
+func (br *Bridge) replaceAirPlayServer(conf airplay2.Config, outputs []*airplay2.Client) error {
+	server := airplay2.NewServer(conf, br.chain, br.byteWriter)
+	if err := server.Start(); err != nil {
+		return err
+	}
+	br.airplay.server = server
+	for _, client := range outputs {
+		if err := server.AddClient(client); err != nil {
+			log.Logger.WithField("context", "AirPlay Restart").Warnf("Error adding output '%s' to the new server: %v", client.Name(), err)
+		}
+	}
+	if as := br.currentAutoSwitch(); as != nil {
+		br.watchSenders(as, server)
+		br.applyAutoSwitch(as)
+	}
+	return nil
+}
+
 func (br *Bridge) AddAirPlayOutput(searchKey string, searchType AirPlaySearchType) error {
 	if br.inputType == -1 {
 		return fmt.Errorf("an input source is required before an output source can be initialized")
@@ -37,10 +104,6 @@ func (br *Bridge) AddAirPlayOutput(searchKey string, searchType AirPlaySearchTyp
 		br.airplay = newAirPlayHandler()
 	}
 
-	if br.airplay.clients == nil {
-		br.airplay.clients = make([]*airplay2.Client, 0)
-	}
-
 	params := airplay2.ClientDiscoveryParameters{}
 
 	switch searchType {
@@ -58,10 +121,10 @@ func (br *Bridge) AddAirPlayOutput(searchKey string, searchType AirPlaySearchTyp
 	}
 
 	// Close any connections that would be a duplicate of our current connection.
-	for i := range br.airplay.clients {
-		if br.airplay.clients[i].RemoteIP().Equal(client.RemoteIP()) {
+	for _, cl := range br.airplay.clientList() {
+		if cl.RemoteIP().Equal(client.RemoteIP()) {
 			log.Logger.WithField("context", "AirPlay Client Init").Warnf("Closing previous session with matching remote address...")
-			br.airplay.clients[i].Close()
+			cl.Close()
 		}
 	}
 
@@ -69,7 +132,7 @@ func (br *Bridge) AddAirPlayOutput(searchKey string, searchType AirPlaySearchTyp
 		return fmt.Errorf("error confirming connection for AirPlay client: %w", err)
 	}
 
-	br.airplay.clients = append(br.airplay.clients, client)
+	br.airplay.addClient(client)
 
 	if err := br.wireAirPlayOutput(client); err != nil {
 		return fmt.Errorf("error wiring AirPlay output to input: %w", err)
@@ -78,7 +141,9 @@ func (br *Bridge) AddAirPlayOutput(searchKey string, searchType AirPlaySearchTyp
 }
 
 type AirPlayHandler struct {
-	server  *airplay2.Server
+	server *airplay2.Server
+
+	mu      sync.RWMutex
 	clients []*airplay2.Client
 }
 
@@ -86,13 +151,30 @@ func newAirPlayHandler() *AirPlayHandler {
 	return &AirPlayHandler{}
 }
 
+func (aph *AirPlayHandler) addClient(client *airplay2.Client) {
+	aph.mu.Lock()
+	defer aph.mu.Unlock()
+	aph.clients = append(aph.clients, client)
+	metrics.AirPlayClients.Set(float64(len(aph.clients)))
+}
+
+// clientList returns a copy of the output clients, safe to range over while clients are added
+func (aph *AirPlayHandler) clientList() []*airplay2.Client {
+	aph.mu.RLock()
+	defer aph.mu.RUnlock()
+	return append([]*airplay2.Client(nil), aph.clients...)
+}
+
 func (aph *AirPlayHandler) Stop() {
+	aph.mu.Lock()
+	defer aph.mu.Unlock()
 	if aph.clients != nil {
 		for i := range aph.clients {
 			aph.clients[i].Close()
 		}
+		metrics.AirPlayClients.Set(0)
 	}
-	if aph.server != nil {
+	if aph.server != nil && !aph.server.Stopped() {
 		aph.server.Stop()
 	}
 }
@@ -3,6 +3,7 @@ package audiobridge
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/LedFx/ledfx/pkg/integrations/airplay2"
 )
@@ -10,9 +11,47 @@ import (
 type AirPlayAction string
 
 const (
-	AirPlayActionStopServer AirPlayAction = "stop"
+	AirPlayActionStopServer         AirPlayAction = "stop"
+	AirPlayActionRouteToOutputs     AirPlayAction = "route"
+	AirPlayActionUnrouteFromOutputs AirPlayAction = "unroute"
+	AirPlayActionQueryRouting       AirPlayAction = "query_routing"
+	AirPlayActionReannounce         AirPlayAction = "reannounce"
+	AirPlayActionSetVolume          AirPlayAction = "set_volume"
+	AirPlayActionGetVolume          AirPlayAction = "get_volume"
+	AirPlayActionRestart            AirPlayAction = "restart"
+
+	// transport controls sent to the sender over DACP
+	AirPlayActionPlay     AirPlayAction = "play"
+	AirPlayActionPause    AirPlayAction = "pause"
+	AirPlayActionNext     AirPlayAction = "next"
+	AirPlayActionPrevious AirPlayAction = "previous"
+)
+
+// actions accepted by each AirPlay JsonCTL call
+var (
+	airPlaySetActions = []AirPlayAction{
+		AirPlayActionStopServer, AirPlayActionRouteToOutputs, AirPlayActionUnrouteFromOutputs, AirPlayActionReannounce,
+		AirPlayActionPlay, AirPlayActionPause, AirPlayActionNext, AirPlayActionPrevious,
+	}
+	airPlayRoutingActions = []AirPlayAction{AirPlayActionRouteToOutputs, AirPlayActionUnrouteFromOutputs, AirPlayActionQueryRouting}
+	airPlayVolumeActions  = []AirPlayAction{AirPlayActionSetVolume, AirPlayActionGetVolume}
 )
 
+// checkAction returns an error naming the accepted actions if action isn't one of them
+func checkAction(action AirPlayAction, accepted []AirPlayAction) error {
+	names := make([]string, len(accepted))
+	for i, a := range accepted {
+		if a == action {
+			return nil
+		}
+		names[i] = string(a)
+	}
+	if action == "" {
+		return fmt.Errorf("%w 'action': missing, expected one of %s", ErrInvalidField, strings.Join(names, ", "))
+	}
+	return fmt.Errorf("%w '%s' in field 'action', expected one of %s", ErrUnknownAction, action, strings.Join(names, ", "))
+}
+
 type AirPlayCTLJSON struct {
 	Action AirPlayAction `json:"action"`
 }
@@ -21,6 +60,45 @@ func (apctls AirPlayCTLJSON) AsJSON() ([]byte, error) {
 	return json.Marshal(&apctls)
 }
 
+type RoutingState struct {
+	Routed bool `json:"routed"`
+}
+
+func (rs *RoutingState) AsJSON() ([]byte, error) {
+	return json.Marshal(rs)
+}
+
+type AirPlayVolumeJSON struct {
+	Action AirPlayAction `json:"action"`
+	// Volume is between 0 and 1. Only used by set_volume.
+	Volume float64 `json:"volume,omitempty"`
+	// Curve, if set, changes how the volume maps to gain. Only used by set_volume.
+	Curve airplay2.VolumeCurve `json:"curve,omitempty"`
+}
+
+func (apvj AirPlayVolumeJSON) AsJSON() ([]byte, error) {
+	return json.Marshal(&apvj)
+}
+
+type VolumeState struct {
+	Volume float64              `json:"volume"`
+	Curve  airplay2.VolumeCurve `json:"curve"`
+}
+
+func (vs *VolumeState) AsJSON() ([]byte, error) {
+	return json.Marshal(vs)
+}
+
+type AirPlayRestartJSON struct {
+	Action AirPlayAction `json:"action"`
+	// Options are the marshalled AirPlayInputJSON fields to change. Fields left out keep their current values.
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+func (aprj AirPlayRestartJSON) AsJSON() ([]byte, error) {
+	return json.Marshal(&aprj)
+}
+
 type ClientList struct {
 	Clients []*airplay2.Client `json:"clients"`
 }
@@ -32,16 +110,136 @@ func (cl *ClientList) AsJSON() ([]byte, error) {
 // AirPlaySet takes a marshalled AirPlayCTLJSON
 func (j *JsonCTL) AirPlaySet(jsonData []byte) (err error) {
 	conf := AirPlayCTLJSON{}
-	if err := json.Unmarshal(jsonData, &conf); err != nil {
-		return fmt.Errorf("error unmarshalling JSON: %w", err)
+	if err := unmarshalStrict(jsonData, &conf); err != nil {
+		return err
+	}
+	if err := checkAction(conf.Action, airPlaySetActions); err != nil {
+		return err
 	}
 
 	switch conf.Action {
 	case AirPlayActionStopServer:
 		return j.w.br.Controller().AirPlay().StopServer()
+	case AirPlayActionRouteToOutputs:
+		return j.w.br.Controller().AirPlay().RouteToOutputs()
+	case AirPlayActionUnrouteFromOutputs:
+		return j.w.br.Controller().AirPlay().UnrouteFromOutputs()
+	case AirPlayActionReannounce:
+		return j.w.br.Controller().AirPlay().ReannounceService()
+	case AirPlayActionPlay, AirPlayActionPause, AirPlayActionNext, AirPlayActionPrevious:
+		return j.w.br.Controller().AirPlay().Remote(conf.Action)
+	}
+
+	return fmt.Errorf("%w '%s'", ErrUnknownAction, conf.Action)
+}
+
+// AirPlayRouting takes a marshalled AirPlayCTLJSON with a routing action
+// and returns the resulting marshalled RoutingState.
+func (j *JsonCTL) AirPlayRouting(jsonData []byte) (resultJson []byte, err error) {
+	conf := AirPlayCTLJSON{}
+	if err := unmarshalStrict(jsonData, &conf); err != nil {
+		return nil, err
+	}
+	if err := checkAction(conf.Action, airPlayRoutingActions); err != nil {
+		return nil, err
+	}
+
+	switch conf.Action {
+	case AirPlayActionRouteToOutputs:
+		err = j.w.br.Controller().AirPlay().RouteToOutputs()
+	case AirPlayActionUnrouteFromOutputs:
+		err = j.w.br.Controller().AirPlay().UnrouteFromOutputs()
+	case AirPlayActionQueryRouting:
+	default:
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownAction, conf.Action)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := &RoutingState{}
+	if state.Routed, err = j.w.br.Controller().AirPlay().Routed(); err != nil {
+		return nil, err
+	}
+	return state.AsJSON()
+}
+
+// AirPlayVolume takes a marshalled AirPlayVolumeJSON and returns the resulting marshalled VolumeState.
+// The volume is always reported between 0 and 1, whichever curve maps it to gain.
+func (j *JsonCTL) AirPlayVolume(jsonData []byte) (resultJson []byte, err error) {
+	conf := AirPlayVolumeJSON{}
+	if err := unmarshalStrict(jsonData, &conf); err != nil {
+		return nil, err
+	}
+	if err := checkAction(conf.Action, airPlayVolumeActions); err != nil {
+		return nil, err
+	}
+	if conf.Action == AirPlayActionSetVolume && (conf.Volume < 0 || conf.Volume > 1) {
+		return nil, fmt.Errorf("%w 'volume': %f is not between 0 and 1", ErrInvalidField, conf.Volume)
+	}
+
+	apc := j.w.br.Controller().AirPlay()
+	switch conf.Action {
+	case AirPlayActionSetVolume:
+		if conf.Curve != "" {
+			if err := apc.SetVolumeCurve(conf.Curve); err != nil {
+				return nil, err
+			}
+		}
+		if err := apc.SetVolume(conf.Volume); err != nil {
+			return nil, err
+		}
+	case AirPlayActionGetVolume:
+	default:
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownAction, conf.Action)
 	}
 
-	return fmt.Errorf("unknown action '%s'", conf.Action)
+	state := &VolumeState{}
+	if state.Volume, state.Curve, err = apc.Volume(); err != nil {
+		return nil, err
+	}
+	return state.AsJSON()
+}
+
+// AirPlayRestart takes a marshalled AirPlayRestartJSON, restarts the AirPlay server with the
+// options applied over its current ones, and returns the new server as AirPlayGetInfo does.
+func (j *JsonCTL) AirPlayRestart(jsonData []byte) (resultJson []byte, err error) {
+	conf := AirPlayRestartJSON{}
+	if err := unmarshalStrict(jsonData, &conf); err != nil {
+		return nil, err
+	}
+	if err := checkAction(conf.Action, []AirPlayAction{AirPlayActionRestart}); err != nil {
+		return nil, err
+	}
+
+	server := j.w.br.Controller().AirPlay().Server()
+	if server == nil {
+		return nil, fmt.Errorf("server %w", ErrNotActive)
+	}
+	options := airPlayInputJSON(server.Config())
+	if len(conf.Options) > 0 {
+		if err := unmarshalStrict(conf.Options, &options); err != nil {
+			return nil, err
+		}
+		// auto switch carries over a restart as it is, and is changed with its own actions
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(conf.Options, &fields); err != nil {
+			return nil, fmt.Errorf("error unmarshalling JSON: %w", err)
+		}
+		for _, field := range []string{"auto_switch", "capture_device_id"} {
+			if _, ok := fields[field]; ok {
+				return nil, fmt.Errorf("%w '%s': not supported on restart", ErrInvalidField, field)
+			}
+		}
+	}
+	serverConf, err := options.config()
+	if err != nil {
+		return nil, err
+	}
+	if err := j.w.br.RestartAirPlayInput(serverConf); err != nil {
+		return nil, err
+	}
+	return j.AirPlayGetInfo()
 }
 
 func (j *JsonCTL) AirPlayGetClients() (resultJson []byte, err error) {
@@ -50,3 +248,12 @@ func (j *JsonCTL) AirPlayGetClients() (resultJson []byte, err error) {
 	}
 	return cList.AsJSON()
 }
+
+// AirPlayGetInfo returns the marshalled AirPlay server, including the RTSP port it listens on
+func (j *JsonCTL) AirPlayGetInfo() (resultJson []byte, err error) {
+	server := j.w.br.Controller().AirPlay().Server()
+	if server == nil {
+		return nil, fmt.Errorf("server %w", ErrNotActive)
+	}
+	return json.Marshal(server)
+}
@@ -2,22 +2,43 @@ package audiobridge
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/LedFx/ledfx/pkg/audio"
 	"github.com/LedFx/ledfx/pkg/audio/audiobridge/assets"
+	"github.com/LedFx/ledfx/pkg/event"
 	log "github.com/LedFx/ledfx/pkg/logger"
 )
 
+// DefaultRampDuration is how long the bridge takes to fade between input sources
+const DefaultRampDuration = 50 * time.Millisecond
+
+// how much longer than the ramp a fade out may take, as it only moves once the input writes
+const fadeOutGrace = 100 * time.Millisecond
+
 // NewBridge initializes a new bridge between a source and destination audio device.
 func NewBridge(bufferCallback func(buf audio.Buffer)) (br *Bridge, err error) {
 	br = &Bridge{
 		bufferCallback: bufferCallback,
 		byteWriter:     audio.NewAsyncMultiWriter(),
+		ramp:           audio.NewRamp(DefaultRampDuration),
+		clip:           audio.NewClipDetector(audio.DefaultClipWindow, audio.DefaultClipThreshold),
 		inputType:      inputType(-1), // -1 signifies undefined
 		done:           make(chan bool),
+		shutdownDone:   make(chan struct{}),
 		outputs:        make([]*OutputInfo, 0),
+		pipeDir:        DefaultPipeDir,
 	}
 
+	// clipping is counted on the raw input, before anything in the chain changes its level
+	br.chain = audio.NewChain(br.byteWriter, br.clip, br.ramp)
+	br.clip.OnClip(func(stats audio.ClipStats) {
+		event.Invoke(event.AudioClipping, map[string]interface{}{
+			"rate":  stats.Rate,
+			"total": stats.Total,
+		})
+	})
+
 	br.info = &Info{
 		br: br,
 	}
@@ -32,8 +53,13 @@ func NewBridge(bufferCallback func(buf audio.Buffer)) (br *Bridge, err error) {
 	return br, nil
 }
 
+// Write decodes p for the callback, which must not keep the buffer after returning
 func (cbw *CallbackWrapper) Write(p []byte) (int, error) {
-	cbw.Callback(audio.BytesToAudioBuffer(p))
+	// sized as BytesToAudioBuffer, one sample per byte with the second half silent
+	buf := audio.Buffers.Get(len(p))
+	defer audio.Buffers.Put(buf)
+	audio.DecodeBytes(buf, p)
+	cbw.Callback(buf)
 	return len(p), nil
 }
 
@@ -52,6 +78,7 @@ func (br *Bridge) Stop() {
 			br.done <- true
 		}()
 	}()
+	br.fadeOut()
 	if br.airplay != nil {
 		log.Logger.WithField("context", "Audio Bridge").Warnf("Stopping AirPlay handler...")
 		br.airplay.Stop()
@@ -61,15 +88,22 @@ func (br *Bridge) Stop() {
 		log.Logger.WithField("context", "Audio Bridge").Warnf("Stopping local audio handler...")
 		br.local.Stop()
 	}
+	br.closePipes()
 }
 
+// closeInput fades out and stops the current input. The next input fades in.
 func (br *Bridge) closeInput() {
+	br.fadeOut()
+	defer br.ramp.FadeIn()
+
 	switch br.inputType {
 	case inputTypeAirPlayServer:
+		br.disableAutoSwitch()
 		if !br.airplay.server.Stopped() {
 			br.airplay.server.Stop()
 		}
 	case inputTypeLocal:
+		br.local.quitMixer()
 		if br.local.capture == nil {
 			return
 		}
@@ -84,6 +118,15 @@ func (br *Bridge) closeInput() {
 	}
 }
 
+// fadeOut fades the outputs to silence, waiting while the current input plays through the ramp.
+// It returns at once when no input is writing.
+func (br *Bridge) fadeOut() {
+	br.ramp.FadeOut()
+	if !br.ramp.Wait(br.ramp.Duration() + fadeOutGrace) {
+		log.Logger.WithField("context", "Audio Bridge").Warnf("Fade out didn't finish within %s", br.ramp.Duration()+fadeOutGrace)
+	}
+}
+
 // Wait waits for the bridge to finish.
 func (br *Bridge) Wait() {
 	<-br.done
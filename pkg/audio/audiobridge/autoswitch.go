@@ -0,0 +1,243 @@
+package audiobridge
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/LedFx/ledfx/pkg/audio/audiobridge/capture"
+	"github.com/LedFx/ledfx/pkg/integrations/airplay2"
+	log "github.com/LedFx/ledfx/pkg/logger"
+)
+
+// Source is an input the auto switch policy can route to the outputs
+type Source string
+
+const (
+	// SourceAuto routes AirPlay while a sender is connected, and local capture otherwise
+	SourceAuto    Source = "auto"
+	SourceAirPlay Source = "airplay"
+	SourceCapture Source = "capture"
+)
+
+// autoSwitch keeps local capture running alongside the AirPlay server, routing
+// whichever should be heard to the outputs. The other is paused or unrouted.
+type autoSwitch struct {
+	// switchMu serialises switches, which fade, so mu is only held to read and change the policy
+	switchMu sync.Mutex
+
+	mu      sync.Mutex
+	pinned  Source           // SourceAuto unless the user has chosen a source
+	senders int              // connected AirPlay senders
+	active  Source           // source currently routed
+	server  *airplay2.Server // server whose senders are counted, nil while it is replaced
+
+	disabled bool // set once the AirPlay input closes, so late sender callbacks are ignored
+	held     bool // set while a test tone holds the outputs, so senders are counted but not switched to
+}
+
+// AutoSwitchState is the auto switch policy as reported by the controller
+type AutoSwitchState struct {
+	Pinned  Source `json:"pinned"`
+	Active  Source `json:"active"`
+	Senders int    `json:"senders"`
+}
+
+// EnableAutoSwitch starts capturing from the local device id alongside the running AirPlay server.
+// Capture is heard until a sender connects, when the outputs switch to AirPlay and capture
+// is paused. Capture resumes once the last sender disconnects.
+func (br *Bridge) EnableAutoSwitch(id string) (err error) {
+	if br.inputType != inputTypeAirPlayServer || br.airplay == nil || br.airplay.server == nil {
+		return fmt.Errorf("server %w", ErrNotActive)
+	}
+	if br.local == nil {
+		br.local = newLocalHandler()
+	}
+	if br.local.capture != nil {
+		br.local.capture.Quit()
+	}
+	if br.local.capture, err = capture.NewHandler(id, br.chain); err != nil {
+		return fmt.Errorf("error initializing new capture handler: %w", err)
+	}
+
+	br.autoSwitchMu.Lock()
+	as, created := br.autoSwitch, br.autoSwitch == nil
+	if created {
+		as = &autoSwitch{pinned: SourceAuto}
+		br.autoSwitch = as
+	}
+	br.autoSwitchMu.Unlock()
+	if created {
+		br.watchSenders(as, br.airplay.server)
+	}
+	br.applyAutoSwitch(as)
+	return nil
+}
+
+// currentAutoSwitch returns the auto switch policy, or nil when it isn't enabled
+func (br *Bridge) currentAutoSwitch() *autoSwitch {
+	br.autoSwitchMu.Lock()
+	defer br.autoSwitchMu.Unlock()
+	return br.autoSwitch
+}
+
+// disableAutoSwitch stops capture started by EnableAutoSwitch, as the AirPlay input is closing
+func (br *Bridge) disableAutoSwitch() {
+	br.autoSwitchMu.Lock()
+	as := br.autoSwitch
+	br.autoSwitch = nil
+	br.autoSwitchMu.Unlock()
+	if as == nil {
+		return
+	}
+	// waits for a switch in progress, so none routes once the input has closed
+	as.switchMu.Lock()
+	as.mu.Lock()
+	as.disabled = true
+	as.mu.Unlock()
+	as.switchMu.Unlock()
+	if br.local != nil && br.local.capture != nil && !br.local.capture.Stopped() {
+		br.local.capture.Quit()
+	}
+}
+
+// watchSenders counts senders on server, switching source as they come and go.
+// A restarted server starts with no senders. The callbacks run on the sender's RTSP
+// session, so switches happen in the background rather than holding it up while fading.
+// Callbacks from a server no longer watched are ignored.
+func (br *Bridge) watchSenders(as *autoSwitch, server *airplay2.Server) {
+	as.mu.Lock()
+	as.senders = 0
+	as.active = "" // a new server routes to the outputs until told otherwise
+	as.server = server
+	as.mu.Unlock()
+	server.OnClientConnect(func(addr string) {
+		as.mu.Lock()
+		if as.server != server {
+			as.mu.Unlock()
+			return
+		}
+		as.senders++
+		as.mu.Unlock()
+		log.Logger.WithField("context", "Auto Switch").Infof("AirPlay sender '%s' connected", addr)
+		go br.applyAutoSwitch(as)
+	})
+	server.OnClientDisconnect(func(addr string) {
+		as.mu.Lock()
+		if as.server != server {
+			as.mu.Unlock()
+			return
+		}
+		if as.senders > 0 {
+			as.senders--
+		}
+		as.mu.Unlock()
+		log.Logger.WithField("context", "Auto Switch").Infof("AirPlay sender '%s' disconnected", addr)
+		go br.applyAutoSwitch(as)
+	})
+}
+
+// unwatchSenders ignores the watched server's senders, as it is about to be replaced
+func (as *autoSwitch) unwatchSenders() {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.server = nil
+}
+
+// applyAutoSwitch routes the source the policy wants heard, fading if it changes.
+// The switch is decided under mu, which is released before fading.
+func (br *Bridge) applyAutoSwitch(as *autoSwitch) {
+	as.switchMu.Lock()
+	defer as.switchMu.Unlock()
+
+	as.mu.Lock()
+	server := as.server
+	if as.disabled || as.held || server == nil {
+		as.mu.Unlock()
+		return
+	}
+	want := as.pinned
+	if want == SourceAuto {
+		want = SourceCapture
+		if as.senders > 0 {
+			want = SourceAirPlay
+		}
+	}
+	prev := as.active
+	as.active = want
+	as.mu.Unlock()
+	if want == prev {
+		return
+	}
+	if prev != "" {
+		br.fadeOut()
+		defer br.ramp.FadeIn()
+	}
+
+	log.Logger.WithField("context", "Auto Switch").Infof("Switching outputs to %s", want)
+	switch want {
+	case SourceAirPlay:
+		if br.local != nil && br.local.capture != nil {
+			br.local.capture.Pause()
+		}
+		server.RouteToOutputs()
+	case SourceCapture:
+		server.UnrouteFromOutputs()
+		if br.local != nil && br.local.capture != nil {
+			br.local.capture.Resume()
+		}
+	}
+}
+
+// hold stops the policy switching sources while a test tone holds the outputs.
+// It waits for a switch in progress.
+func (as *autoSwitch) hold() {
+	as.switchMu.Lock()
+	defer as.switchMu.Unlock()
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.held = true
+}
+
+// releaseAutoSwitch hands the outputs back to the policy once a test tone ends. The tone
+// silenced every source, so the one the policy wants now is routed afresh.
+func (br *Bridge) releaseAutoSwitch(as *autoSwitch) {
+	as.mu.Lock()
+	as.held = false
+	as.active = ""
+	as.mu.Unlock()
+	br.applyAutoSwitch(as)
+}
+
+// PinSource overrides the auto switch policy, keeping source routed whether or not
+// senders are connected. SourceAuto hands control back to the policy.
+func (c *Controller) PinSource(source Source) error {
+	as := c.br.currentAutoSwitch()
+	if as == nil {
+		return fmt.Errorf("auto switch %w", ErrNotActive)
+	}
+	switch source {
+	case SourceAuto, SourceAirPlay, SourceCapture:
+	default:
+		return fmt.Errorf("%w 'source': unknown source '%s'", ErrInvalidField, source)
+	}
+	as.mu.Lock()
+	as.pinned = source
+	as.mu.Unlock()
+	c.br.applyAutoSwitch(as)
+	return nil
+}
+
+// AutoSwitch returns the state of the auto switch policy
+func (c *Controller) AutoSwitch() (AutoSwitchState, error) {
+	as := c.br.currentAutoSwitch()
+	if as == nil {
+		return AutoSwitchState{}, fmt.Errorf("auto switch %w", ErrNotActive)
+	}
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	return AutoSwitchState{
+		Pinned:  as.pinned,
+		Active:  as.active,
+		Senders: as.senders,
+	}, nil
+}
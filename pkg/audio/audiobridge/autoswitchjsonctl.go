@@ -0,0 +1,37 @@
+package audiobridge
+
+import (
+	"encoding/json"
+)
+
+// AutoSwitchCTLJSON pins a source, or hands control back to the policy with SourceAuto.
+// An empty source only queries the state.
+type AutoSwitchCTLJSON struct {
+	Source Source `json:"source,omitempty"`
+}
+
+func (asctl AutoSwitchCTLJSON) AsJSON() ([]byte, error) {
+	return json.Marshal(&asctl)
+}
+
+func (as *AutoSwitchState) AsJSON() ([]byte, error) {
+	return json.Marshal(as)
+}
+
+// AutoSwitch takes a marshalled AutoSwitchCTLJSON and returns the resulting marshalled AutoSwitchState
+func (j *JsonCTL) AutoSwitch(jsonData []byte) (resultJson []byte, err error) {
+	conf := AutoSwitchCTLJSON{}
+	if err := unmarshalStrict(jsonData, &conf); err != nil {
+		return nil, err
+	}
+	if conf.Source != "" {
+		if err := j.w.br.Controller().PinSource(conf.Source); err != nil {
+			return nil, err
+		}
+	}
+	state, err := j.w.br.Controller().AutoSwitch()
+	if err != nil {
+		return nil, err
+	}
+	return state.AsJSON()
+}
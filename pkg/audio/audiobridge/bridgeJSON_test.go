@@ -1,11 +1,20 @@
 package audiobridge
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/LedFx/ledfx/pkg/audio"
+	"github.com/LedFx/ledfx/pkg/audio/audiobridge/synth"
+	"github.com/LedFx/ledfx/pkg/integrations/airplay2"
 	log "github.com/LedFx/ledfx/pkg/logger"
 )
 
@@ -184,3 +193,260 @@ func TestBridgeYouTube2LocalJSON(t *testing.T) {
 
 	br.Wait()
 }
+
+func TestAirPlayCTLValidation(t *testing.T) {
+	j := &JsonCTL{}
+	tests := []struct {
+		body string
+		want error
+		msg  string
+	}{
+		{`{"action": "stop", "force": true}`, ErrInvalidField, `"force"`},
+		{`{"action": 3}`, ErrInvalidField, `'action': expected audiobridge.AirPlayAction, got number`},
+		{`{}`, ErrInvalidField, `'action': missing`},
+		{`{"action": "rewind"}`, ErrUnknownAction, `'rewind' in field 'action', expected one of stop, route`},
+	}
+	for _, tt := range tests {
+		err := j.AirPlaySet([]byte(tt.body))
+		if !errors.Is(err, tt.want) || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("Expected: %v containing %s\r\n Got: %v", tt.want, tt.msg, err)
+		}
+	}
+	if _, err := j.AirPlayVolume([]byte(`{"action": "set_volume", "volume": 2}`)); !errors.Is(err, ErrInvalidField) {
+		t.Errorf("Expected: %v\r\n Got: %v", ErrInvalidField, err)
+	}
+}
+
+func TestAddPipeOutputJSON(t *testing.T) {
+	br, err := NewBridge(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer br.closePipes()
+	dir := t.TempDir()
+	br.SetPipeDir(filepath.Join(dir, "pipes"))
+
+	for _, path := range []string{"", filepath.Join(dir, "abs"), "../escaped", "a/../../escaped"} {
+		body, _ := PipeOutputJSON{Path: path}.AsJSON()
+		if err := br.JSONWrapper().AddPipeOutput(body); !errors.Is(err, ErrInvalidField) {
+			t.Errorf("Expected: %v for path %q\r\n Got: %v", ErrInvalidField, path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected: nothing created outside the pipe directory\r\n Got: %v", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	body, _ := PipeOutputJSON{Path: "audio"}.AsJSON()
+	if err := br.JSONWrapper().AddPipeOutput(body); err != nil {
+		t.Fatalf("Error adding pipe output: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pipes", "audio")); err != nil {
+		t.Errorf("Expected: pipe made in the pipe directory\r\n Got: %v", err)
+	}
+}
+
+func TestAirPlayRestartJSON(t *testing.T) {
+	br, err := NewBridge(func(buf audio.Buffer) {})
+	if err != nil {
+		t.Fatalf("Error initializing new bridge: %v\n", err)
+	}
+	defer br.Stop()
+	if err := br.StartAirPlayInputConfig(airplay2.Config{AdvertisementName: "Before", RTSPPort: 0, MaxClients: 3}); err != nil {
+		t.Fatalf("Error starting AirPlay input: %v\n", err)
+	}
+
+	ctl := br.JSONWrapper().CTL()
+	if _, err := ctl.AirPlayRestart([]byte(`{"action": "restart", "options": {"name": "After", "port": -1}}`)); err != nil {
+		t.Fatalf("Error restarting AirPlay input: %v\n", err)
+	}
+	conf := br.Controller().AirPlay().Server().Config()
+	if conf.AdvertisementName != "After" || conf.MaxClients != 3 {
+		t.Errorf("Expected: name 'After', max clients kept at 3\r\n Got: %+v", conf)
+	}
+	for _, options := range []string{`{"colour": "red"}`, `{"auto_switch": true}`, `{"capture_device_id": "default"}`} {
+		if _, err := ctl.AirPlayRestart([]byte(`{"action": "restart", "options": ` + options + `}`)); !errors.Is(err, ErrInvalidField) {
+			t.Errorf("Expected: %v for %s\r\n Got: %v", ErrInvalidField, options, err)
+		}
+	}
+
+	// a server which fails to start leaves the old one running again
+	port := br.Controller().AirPlay().Server().Port()
+	if _, err := ctl.AirPlayRestart([]byte(`{"action": "restart", "options": {"name": "Broken", "interface": "no-such-interface"}}`)); err == nil {
+		t.Errorf("Expected: error starting on a missing interface")
+	}
+	server := br.Controller().AirPlay().Server()
+	if server.Stopped() || server.Config().AdvertisementName != "After" || server.Port() != port {
+		t.Errorf("Expected: 'After' running again on port %d\r\n Got: %+v, stopped %v", port, server.Config(), server.Stopped())
+	}
+}
+
+func TestBridgeShutdown(t *testing.T) {
+	br, err := NewBridge(func(buf audio.Buffer) {})
+	if err != nil {
+		t.Fatalf("Error initializing new bridge: %v\n", err)
+	}
+	if err := br.StartAirPlayInputConfig(airplay2.Config{AdvertisementName: "Shutdown", RTSPPort: 0}); err != nil {
+		t.Fatalf("Error starting AirPlay input: %v\n", err)
+	}
+
+	// a second call waits on the first rather than stopping everything again
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := br.Controller().Shutdown(ctx); err != nil {
+			t.Errorf("Expected: shutdown %d to finish\r\n Got: %v", i, err)
+		}
+		cancel()
+	}
+	if !br.Controller().AirPlay().Server().Stopped() {
+		t.Errorf("Expected: AirPlay server stopped")
+	}
+	br.Wait()
+}
+
+// the policy is exercised without a capture device, which isn't available everywhere tests run
+// autoSwitchBridge returns a bridge with auto switch watching an unstarted AirPlay server
+func autoSwitchBridge(t *testing.T) (*Bridge, *airplay2.Server) {
+	t.Helper()
+	br, err := NewBridge(func(buf audio.Buffer) {})
+	if err != nil {
+		t.Fatalf("Error initializing new bridge: %v\n", err)
+	}
+	server := airplay2.NewServer(airplay2.Config{}, br.chain, br.byteWriter)
+	br.inputType = inputTypeAirPlayServer
+	br.airplay = &AirPlayHandler{server: server}
+	as := &autoSwitch{pinned: SourceAuto}
+	br.autoSwitch = as
+	br.watchSenders(as, server)
+	return br, server
+}
+
+// waitAutoSwitch waits for the switch sender callbacks run in the background
+func waitAutoSwitch(t *testing.T, br *Bridge, server *airplay2.Server, senders int, routed bool, active Source) {
+	t.Helper()
+	var state AutoSwitchState
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var err error
+		if state, err = br.Controller().AutoSwitch(); err != nil {
+			t.Fatal(err)
+		}
+		if state.Senders == senders && server.Routed() == routed && state.Active == active {
+			return
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Errorf("Expected: routed %v, active %s with %d senders\r\n Got: routed %v, %+v", routed, active, senders, server.Routed(), state)
+}
+
+func TestBridgeAutoSwitch(t *testing.T) {
+	br, err := NewBridge(func(buf audio.Buffer) {})
+	if err != nil {
+		t.Fatalf("Error initializing new bridge: %v\n", err)
+	}
+	if _, err := br.Controller().AutoSwitch(); !errors.Is(err, ErrNotActive) {
+		t.Errorf("Expected: %v\r\n Got: %v", ErrNotActive, err)
+	}
+
+	br, server := autoSwitchBridge(t)
+	br.applyAutoSwitch(br.currentAutoSwitch())
+	waitAutoSwitch(t, br, server, 0, false, SourceCapture)
+	server.SimulateSession("10.0.0.1", true)
+	waitAutoSwitch(t, br, server, 1, true, SourceAirPlay)
+	server.SimulateSession("10.0.0.2", true)
+	server.SimulateSession("10.0.0.1", false)
+	waitAutoSwitch(t, br, server, 1, true, SourceAirPlay)
+	server.SimulateSession("10.0.0.2", false)
+	waitAutoSwitch(t, br, server, 0, false, SourceCapture)
+
+	if err := br.Controller().PinSource(SourceAirPlay); err != nil {
+		t.Fatal(err)
+	}
+	waitAutoSwitch(t, br, server, 0, true, SourceAirPlay)
+	if err := br.Controller().PinSource("bluetooth"); !errors.Is(err, ErrInvalidField) {
+		t.Errorf("Expected: %v\r\n Got: %v", ErrInvalidField, err)
+	}
+	if err := br.Controller().PinSource(SourceAuto); err != nil {
+		t.Fatal(err)
+	}
+	waitAutoSwitch(t, br, server, 0, false, SourceCapture)
+
+	br.disableAutoSwitch()
+	if _, err := br.Controller().AutoSwitch(); !errors.Is(err, ErrNotActive) {
+		t.Errorf("Expected: %v after closing the input\r\n Got: %v", ErrNotActive, err)
+	}
+	// late callbacks from the closed input are ignored
+	server.SimulateSession("10.0.0.1", true)
+	if server.Routed() {
+		t.Errorf("Expected: AirPlay left unrouted after auto switch is disabled")
+	}
+}
+
+func TestTestToneHoldsAutoSwitch(t *testing.T) {
+	br, server := autoSwitchBridge(t)
+	as := br.currentAutoSwitch()
+	server.SimulateSession("10.0.0.1", true)
+	waitAutoSwitch(t, br, server, 1, true, SourceAirPlay)
+
+	conf := synth.Config{Waveform: synth.Sine, Frequency: 440, Amplitude: 0.5}
+	if err := br.Controller().TestTone(conf, MaxTestToneDuration); err != nil {
+		t.Fatalf("Error starting test tone: %v\n", err)
+	}
+	// the sender leaving while the tone plays is counted, but doesn't switch sources over it
+	server.SimulateSession("10.0.0.1", false)
+	br.applyAutoSwitch(as)
+	if state, _ := br.Controller().AutoSwitch(); server.Routed() || state.Active != SourceAirPlay || state.Senders != 0 {
+		t.Errorf("Expected: AirPlay held off the outputs during the tone\r\n Got: routed %v, %+v", server.Routed(), state)
+	}
+
+	// afterwards the policy routes capture, rather than the AirPlay routing the tone replaced
+	br.Controller().StopTestTone()
+	waitAutoSwitch(t, br, server, 0, false, SourceCapture)
+	br.disableAutoSwitch()
+}
+
+func TestBridgeTestTone(t *testing.T) {
+	var mu sync.Mutex
+	var peak int16
+	br, err := NewBridge(func(buf audio.Buffer) {
+		mu.Lock()
+		defer mu.Unlock()
+		if h := buf.HighestValue(); h > peak {
+			peak = h
+		}
+	})
+	if err != nil {
+		t.Fatalf("Error initializing new bridge: %v\n", err)
+	}
+	server := airplay2.NewServer(airplay2.Config{}, br.chain, br.byteWriter)
+	br.airplay = &AirPlayHandler{server: server}
+
+	conf := synth.Config{Waveform: synth.Sine, Frequency: 440, Amplitude: 0.5}
+	if err := br.Controller().TestTone(conf, 200*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := br.Controller().TestTone(conf, time.Second); !errors.Is(err, ErrTestToneActive) {
+		t.Errorf("Expected: %v\r\n Got: %v", ErrTestToneActive, err)
+	}
+	if server.Routed() {
+		t.Error("Expected: AirPlay unrouted while the tone plays")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !server.Routed() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !server.Routed() {
+		t.Error("Expected: AirPlay routed again once the tone ends")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if peak < 8000 {
+		t.Errorf("Expected: the tone to reach the callback\r\n Got: peak %d", peak)
+	}
+}
@@ -2,12 +2,15 @@ package audiobridge
 
 import (
 	"bufio"
+	"bytes"
 	"io"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/LedFx/ledfx/pkg/audio"
+	"github.com/LedFx/ledfx/pkg/audio/audiobridge/synth"
 	log "github.com/LedFx/ledfx/pkg/logger"
 )
 
@@ -181,3 +184,62 @@ func TestBridgeMic2AirPlay(t *testing.T) {
 
 	br.Wait()
 }
+
+func TestStopTestTone(t *testing.T) {
+	br, err := NewBridge(func(buf audio.Buffer) {})
+	if err != nil {
+		t.Fatalf("Error initializing new bridge: %v\n", err)
+	}
+	defer br.Stop()
+
+	conf := synth.Config{Waveform: synth.Sine, Frequency: 440, Amplitude: 0.5}
+	if err := br.Controller().TestTone(conf, MaxTestToneDuration); err != nil {
+		t.Fatalf("Error starting test tone: %v\n", err)
+	}
+	if err := br.Controller().TestTone(conf, time.Second); err == nil {
+		t.Errorf("Expected: error starting a second test tone")
+	}
+	br.Controller().StopTestTone()
+
+	// the tone has finished by the time StopTestTone returns
+	br.toneMu.Lock()
+	active := br.toneStop != nil || br.toneDone != nil
+	br.toneMu.Unlock()
+	if active {
+		t.Errorf("Expected: no test tone after StopTestTone returns")
+	}
+	if err := br.Controller().TestTone(conf, time.Second); err != nil {
+		t.Errorf("Expected: a new test tone to start\r\n Got: %v", err)
+	}
+	br.Controller().StopTestTone()
+}
+
+func TestAddOutputWriterProcessors(t *testing.T) {
+	br, err := NewBridge(func(buf audio.Buffer) {})
+	if err != nil {
+		t.Fatalf("Error initializing new bridge: %v\n", err)
+	}
+	defer br.Stop()
+
+	var raw, gained bytes.Buffer
+	if err := br.AddOutputWriter(&raw, "raw"); err != nil {
+		t.Fatalf("Error adding output writer: %v\n", err)
+	}
+	if err := br.AddOutputWriterProcessors(&gained, "gained", audio.FormatInt16, audio.Gain(2)); err != nil {
+		t.Fatalf("Error adding output writer with processors: %v\n", err)
+	}
+	if err := br.AddOutputWriterProcessors(io.Discard, "bad", audio.Format(-1)); err == nil {
+		t.Errorf("Expected: error adding an output in an unknown format")
+	}
+
+	in := audio.Buffer{100, -200, 300}
+	if _, err := br.byteWriter.Write(in.AsBytes()); err != nil {
+		t.Fatalf("Error writing to outputs: %v\n", err)
+	}
+	if !bytes.Equal(raw.Bytes(), in.AsBytes()) {
+		t.Errorf("Expected: %v\r\n Got: %v", in.AsBytes(), raw.Bytes())
+	}
+	if want := (audio.Buffer{200, -400, 600}).AsBytes(); !bytes.Equal(gained.Bytes(), want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, gained.Bytes())
+	}
+}
@@ -2,21 +2,58 @@ package capture
 
 import (
 	"fmt"
+	"io"
+	"math"
+	"sync"
 
 	"github.com/LedFx/ledfx/pkg/audio"
+	"github.com/LedFx/ledfx/pkg/config"
 	log "github.com/LedFx/ledfx/pkg/logger"
+	"github.com/LedFx/ledfx/pkg/metrics"
 
 	"github.com/LedFx/portaudio"
+
+	"go.uber.org/atomic"
 )
 
 type Handler struct {
 	*portaudio.Stream
-	byteWriter *audio.AsyncMultiWriter
+	byteWriter io.Writer
 	stopped    bool
+	// paused suppresses writes while leaving the stream open
+	paused *atomic.Bool
+	// invert has bit c set to negate channel c of a stereo stream before it is downmixed
+	invert atomic.Uint32
+
+	// mu guards the stream as it is reopened at a new buffer size
+	mu       sync.Mutex
+	params   portaudio.StreamParameters
+	callback func(audio.Buffer, portaudio.StreamCallbackTimeInfo, portaudio.StreamCallbackFlags)
+
+	// tuner, if set by AutoTune, resizes the buffer through retune
+	tuner  atomic.Value
+	retune chan retune
 }
 
-func NewHandler(id string, byteWriter *audio.AsyncMultiWriter) (h *Handler, err error) {
-	audioDevice, err := audio.GetDeviceByID(id)
+// NewHandler opens the capture device with the given ID. An empty ID uses the default input device.
+// Captured audio is written to byteWriter as 16 bit mono, usually the bridge's *audio.Chain.
+func NewHandler(id string, byteWriter io.Writer) (h *Handler, err error) {
+	if err := audio.AcquirePortAudio(); err != nil {
+		return nil, err
+	}
+	// the handler holds PortAudio until Quit, unless it fails to open
+	defer func() {
+		if err != nil {
+			audio.ReleasePortAudio()
+		}
+	}()
+
+	var audioDevice config.AudioDevice
+	if id == "" {
+		audioDevice, err = audio.DefaultInputDevice()
+	} else {
+		audioDevice, err = audio.GetDeviceByID(id)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -26,6 +63,10 @@ func NewHandler(id string, byteWriter *audio.AsyncMultiWriter) (h *Handler, err
 		return nil, fmt.Errorf("error getting PortAudio device info: %w", err)
 	}
 
+	if dev.MaxInputChannels < 1 {
+		return nil, fmt.Errorf("device '%s' has no input channels", audioDevice.Name)
+	}
+
 	p := portaudio.StreamParameters{
 		Input: portaudio.StreamDeviceParameters{
 			Device:   dev,
@@ -39,32 +80,160 @@ func NewHandler(id string, byteWriter *audio.AsyncMultiWriter) (h *Handler, err
 
 	h = &Handler{
 		byteWriter: byteWriter,
+		paused:     atomic.NewBool(false),
 	}
 
 	log.Logger.WithField("context", "Local Capture Init").Debugf("Opening stream...")
-	if h.Stream, err = portaudio.OpenStream(p, h.monoCallback); err != nil {
-		return nil, fmt.Errorf("error opening stream: %w", err)
+	h.callback = h.monoCallback
+	if h.Stream, err = portaudio.OpenStream(p, h.callback); err != nil {
+		// some devices only expose stereo input, so open it as stereo and downmix
+		if dev.MaxInputChannels < 2 {
+			return nil, fmt.Errorf("error opening stream: %w", err)
+		}
+		log.Logger.WithField("context", "Local Capture Init").Infof("Mono capture unsupported (%v), opening stereo and downmixing", err)
+		p.Input.Channels = 2
+		h.callback = h.stereoCallback
+		if h.Stream, err = portaudio.OpenStream(p, h.callback); err != nil {
+			return nil, fmt.Errorf("error opening stereo stream: %w", err)
+		}
+	} else {
+		log.Logger.WithField("context", "Local Capture Init").Infof("Opened mono capture")
 	}
+	h.params = p
 
 	log.Logger.WithField("context", "Local Capture Init").Debugf("Starting stream...")
 	if err = h.Stream.Start(); err != nil {
+		h.Stream.Close()
 		return nil, fmt.Errorf("error starting stream: %w", err)
 	}
 
 	return h, nil
 }
 
-func (h *Handler) monoCallback(in audio.Buffer) {
+func (h *Handler) monoCallback(in audio.Buffer, _ portaudio.StreamCallbackTimeInfo, flags portaudio.StreamCallbackFlags) {
+	overflow := flags&portaudio.InputOverflow != 0
+	if overflow {
+		metrics.CaptureOverflows.Inc()
+	}
+	h.tune(overflow)
+	if h.paused.Load() {
+		return
+	}
 	h.byteWriter.Write(in.AsBytes())
 }
 
+// stereoCallback downmixes interleaved stereo input to mono before writing it
+func (h *Handler) stereoCallback(in audio.Buffer, info portaudio.StreamCallbackTimeInfo, flags portaudio.StreamCallbackFlags) {
+	if invert := h.invert.Load(); invert != 0 {
+		for i, s := range in {
+			if invert&(1<<(i%2)) == 0 {
+				continue
+			}
+			if s == math.MinInt16 {
+				in[i] = math.MaxInt16
+			} else {
+				in[i] = -s
+			}
+		}
+	}
+	h.monoCallback(audio.DownmixStereo(in), info, flags)
+}
+
+// SetInvertPhase negates channel c, 0 for left and 1 for right, or stops negating it.
+// It only applies to devices captured in stereo, where a miswired channel would cancel
+// the other as they are downmixed.
+func (h *Handler) SetInvertPhase(c int, invert bool) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.params.Input.Channels < 2 {
+		return fmt.Errorf("device is captured in mono, so has no channels to invert")
+	}
+	if c < 0 || c >= h.params.Input.Channels {
+		return fmt.Errorf("no channel %d, the device is captured with %d", c, h.params.Input.Channels)
+	}
+	bits := h.invert.Load()
+	if invert {
+		bits |= 1 << c
+	} else {
+		bits &^= 1 << c
+	}
+	h.invert.Store(bits)
+	return nil
+}
+
 func (h *Handler) Quit() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopped {
+		return
+	}
 	h.stopped = true
+	defer audio.ReleasePortAudio()
 	log.Logger.WithField("context", "Capture Handler").Debug("Aborting stream...")
 	h.Stream.Abort()
 	log.Logger.WithField("context", "Capture Handler").Debug("Closing stream...")
 	h.Stream.Close()
 	log.Logger.WithField("context", "Capture Handler").Info("Closed stream")
+	if h.retune != nil {
+		close(h.retune)
+	}
+}
+
+// reopen replaces the stream with one of frames per buffer, keeping the device and channels
+func (h *Handler) reopen(frames int) (err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopped || frames == h.params.FramesPerBuffer {
+		return nil
+	}
+	h.Stream.Abort()
+	h.Stream.Close()
+	h.params.FramesPerBuffer = frames
+	if h.Stream, err = portaudio.OpenStream(h.params, h.callback); err != nil {
+		// the old stream is gone, so the handler can't carry on
+		h.stopReopen()
+		return fmt.Errorf("error reopening stream at %d frames: %w", frames, err)
+	}
+	if err = h.Stream.Start(); err != nil {
+		h.Stream.Close()
+		h.stopReopen()
+		return fmt.Errorf("error starting stream: %w", err)
+	}
+	return nil
+}
+
+// stops a handler whose stream couldn't be reopened, as Quit would. Must be called with mu held.
+func (h *Handler) stopReopen() {
+	h.stopped = true
+	audio.ReleasePortAudio()
+	if h.retune != nil {
+		close(h.retune)
+	}
+}
+
+// Pause stops captured audio being written without closing the device, which is slow to reopen
+func (h *Handler) Pause() {
+	if !h.paused.Swap(true) {
+		log.Logger.WithField("context", "Capture Handler").Info("Paused capture")
+	}
+}
+
+// Resume restarts writing captured audio after Pause
+func (h *Handler) Resume() {
+	if h.paused.Swap(false) {
+		log.Logger.WithField("context", "Capture Handler").Info("Resumed capture")
+	}
+}
+
+// SampleRate returns the rate the device is captured at, its default rate
+func (h *Handler) SampleRate() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.params.SampleRate
+}
+
+func (h *Handler) Paused() bool {
+	return h.paused.Load()
 }
 
 func (h *Handler) Stopped() bool {
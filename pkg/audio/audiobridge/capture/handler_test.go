@@ -0,0 +1,211 @@
+package capture
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/audio"
+
+	"github.com/LedFx/portaudio"
+
+	"go.uber.org/atomic"
+)
+
+// recordingWriter keeps a copy of every write
+type recordingWriter struct {
+	writes [][]byte
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, append([]byte{}, p...))
+	return len(p), nil
+}
+
+func TestMonoCallback(t *testing.T) {
+	w := &recordingWriter{}
+	h := &Handler{byteWriter: w, paused: atomic.NewBool(false)}
+	in := audio.Buffer{0, 1, -1, 32767, -32768, 256}
+
+	h.monoCallback(in, portaudio.StreamCallbackTimeInfo{}, 0)
+	if len(w.writes) != 1 || !bytes.Equal(w.writes[0], in.AsBytes()) {
+		t.Errorf("Expected: %v\r\n Got: %v", [][]byte{in.AsBytes()}, w.writes)
+	}
+
+	h.Pause()
+	h.monoCallback(in, portaudio.StreamCallbackTimeInfo{}, 0)
+	if len(w.writes) != 1 {
+		t.Errorf("Expected: no writes while paused\r\n Got: %d writes", len(w.writes))
+	}
+
+	h.Resume()
+	h.stereoCallback(audio.Buffer{100, 200, -100, -300}, portaudio.StreamCallbackTimeInfo{}, 0)
+	if want := audio.DownmixStereo(audio.Buffer{100, 200, -100, -300}).AsBytes(); len(w.writes) != 2 || !bytes.Equal(w.writes[1], want) {
+		t.Errorf("Expected: downmixed write %v\r\n Got: %v", want, w.writes)
+	}
+}
+
+func TestStereoInvertPhase(t *testing.T) {
+	w := &recordingWriter{}
+	h := &Handler{byteWriter: w, paused: atomic.NewBool(false)}
+	if err := h.SetInvertPhase(0, true); err == nil {
+		t.Errorf("Expected: error inverting a channel of mono capture")
+	}
+	h.params.Input.Channels = 2
+	if err := h.SetInvertPhase(2, true); err == nil {
+		t.Errorf("Expected: error inverting channel 2 of stereo capture")
+	}
+
+	// a miswired right channel cancels the left as they are downmixed, until inverted
+	in := func() audio.Buffer { return audio.Buffer{1000, -1000, -32768, 32767} }
+	h.stereoCallback(in(), portaudio.StreamCallbackTimeInfo{}, 0)
+	if err := h.SetInvertPhase(1, true); err != nil {
+		t.Fatal(err)
+	}
+	h.stereoCallback(in(), portaudio.StreamCallbackTimeInfo{}, 0)
+	if err := h.SetInvertPhase(1, false); err != nil {
+		t.Fatal(err)
+	}
+	h.stereoCallback(in(), portaudio.StreamCallbackTimeInfo{}, 0)
+	want := [][]byte{
+		audio.Buffer{0, 0}.AsBytes(),
+		audio.Buffer{1000, -32767}.AsBytes(),
+		audio.Buffer{0, 0}.AsBytes(),
+	}
+	if len(w.writes) != len(want) {
+		t.Fatalf("Expected: %d writes\r\n Got: %d", len(want), len(w.writes))
+	}
+	for i := range want {
+		if !bytes.Equal(w.writes[i], want[i]) {
+			t.Errorf("Expected: write %d %v\r\n Got: %v", i, want[i], w.writes[i])
+		}
+	}
+}
+
+func TestTuner(t *testing.T) {
+	now := time.Unix(0, 0)
+	tn := newTuner(1024, 256, 4096)
+	tn.now = func() time.Time { return now }
+
+	// runs a window of callbacks, overflowing on the given number of them
+	window := func(overflows int) (frames int) {
+		for i := 0; i < 100; i++ {
+			if i == 99 {
+				now = now.Add(tuneWindow)
+			}
+			if f, _ := tn.observe(i < overflows); f != 0 {
+				frames = f
+			}
+		}
+		return frames
+	}
+
+	if got := window(5); got != 2048 {
+		t.Errorf("Expected: grow to 2048\r\n Got: %d", got)
+	}
+	if got := window(50); got != 0 {
+		t.Errorf("Expected: the window after a change is ignored\r\n Got: %d", got)
+	}
+	if got := window(50); got != 4096 {
+		t.Errorf("Expected: grow to the max of 4096\r\n Got: %d", got)
+	}
+	window(0)
+	if got := window(50); got != 0 {
+		t.Errorf("Expected: no growth past the max\r\n Got: %d", got)
+	}
+
+	// two grows need four times the usual stable stretch before shrinking
+	for i := 0; i < stableWindows<<2-1; i++ {
+		if got := window(0); got != 0 {
+			t.Fatalf("Expected: no shrink after %d stable windows\r\n Got: %d", i+1, got)
+		}
+	}
+	if got := window(0); got != 2048 {
+		t.Errorf("Expected: shrink to 2048\r\n Got: %d", got)
+	}
+}
+
+func TestMixer(t *testing.T) {
+	w := &recordingWriter{}
+	m := newMixer(w, MixAverage, 4)
+	now := time.Unix(0, 0)
+	m.now = func() time.Time { return now }
+	a, b := m.addSource("a", 0), m.addSource("b", 0)
+	a.resampler = audio.NewResampler(44100, 44100)
+	b.resampler = audio.NewResampler(22050, 44100)
+
+	// nothing is mixed until both sources have a frame
+	m.push(b, audio.Buffer{300})
+	m.push(a, audio.Buffer{100, 100, 100, 100, 100, 100})
+	if len(w.writes) != 0 {
+		t.Fatalf("Expected: no mix while waiting on a live source\r\n Got: %d writes", len(w.writes))
+	}
+	m.push(b, audio.Buffer{300, 300, 300})
+	if len(w.writes) != 1 || !bytes.Equal(w.writes[0], audio.Buffer{200, 200, 200, 200}.AsBytes()) {
+		t.Errorf("Expected: the average of both sources\r\n Got: %v", w.writes)
+	}
+
+	// a source which stops writing is left out once stale
+	if err := m.SetGain(0, 6.0206); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(mixerStaleAfter)
+	m.push(a, audio.Buffer{100, 100})
+	if len(w.writes) != 2 || !bytes.Equal(w.writes[1], audio.Buffer{200, 200, 200, 200}.AsBytes()) {
+		t.Errorf("Expected: source a alone at double gain\r\n Got: %v", w.writes)
+	}
+	if err := m.SetGain(2, 0); err == nil {
+		t.Error("Expected: error setting the gain of a missing source")
+	}
+
+	// an inverted source cancels an identical one
+	w = &recordingWriter{}
+	m = newMixer(w, MixSum, 2)
+	a, b = m.addSource("a", 0), m.addSource("b", 0)
+	a.resampler, b.resampler = audio.NewResampler(44100, 44100), audio.NewResampler(44100, 44100)
+	if err := m.SetInvertPhase(1, true); err != nil {
+		t.Fatal(err)
+	}
+	a.last = time.Now() // live, so b waits for it
+	m.push(b, audio.Buffer{-32768, 1000})
+	m.push(a, audio.Buffer{-32768, 1000})
+	if len(w.writes) != 1 || !bytes.Equal(w.writes[0], audio.Buffer{0, 0}.AsBytes()) {
+		t.Errorf("Expected: silence from opposed sources\r\n Got: %v", w.writes)
+	}
+}
+
+// writerFunc adapts a function to an io.Writer
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestMixerWritesUnlocked(t *testing.T) {
+	var m *Mixer
+	writes := 0
+	m = newMixer(writerFunc(func(p []byte) (int, error) {
+		// the mixer's lock is free while a frame is written, so this doesn't deadlock
+		if err := m.SetGain(0, -6); err != nil {
+			t.Error(err)
+		}
+		writes++
+		return len(p), nil
+	}), MixSum, 2)
+	a := m.addSource("a", 0)
+	a.resampler = audio.NewResampler(44100, 44100)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.push(a, audio.Buffer{1, 2, 3, 4})
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected: push to return\r\n Got: deadlocked writing the mix")
+	}
+	if writes != 2 {
+		t.Errorf("Expected: 2 frames written\r\n Got: %d", writes)
+	}
+}
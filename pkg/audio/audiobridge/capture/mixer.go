@@ -0,0 +1,259 @@
+package capture
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/audio"
+	log "github.com/LedFx/ledfx/pkg/logger"
+)
+
+const (
+	// a source which hasn't written for this long is left out of the mix rather than waited on
+	mixerStaleAfter = 200 * time.Millisecond
+	// sources may get this many mix frames ahead of the others before their oldest audio is dropped
+	mixerMaxQueued = 4
+)
+
+// MixMode is how a Mixer combines its sources
+type MixMode int
+
+const (
+	// MixAverage divides the sum by the number of sources mixed, so the level matches one source
+	MixAverage MixMode = iota
+	// MixSum adds the sources, clipping at full scale
+	MixSum
+)
+
+// MixerSource is a capture device mixed by a Mixer
+type MixerSource struct {
+	DeviceID string `json:"device_id"`
+	// Gain in dB applied to this source before mixing
+	GainDB float64 `json:"gain_db,omitempty"`
+	// InvertPhase negates the source, for a miswired mic or one cancelling another
+	InvertPhase bool `json:"invert_phase,omitempty"`
+}
+
+// Mixer captures from several devices at once and mixes them into one mono stream at
+// audio.SampleRate. Each source is resampled from its device's rate and queued, and a
+// frame is mixed once every live source has one ready.
+type Mixer struct {
+	mu      sync.Mutex
+	writeMu sync.Mutex // taken before mu is released, so frames are written in order without holding mu
+	out     io.Writer
+	mode    MixMode
+	frame   int // samples per mixed frame
+	sources []*mixerSource
+	mix     []float64
+	buf     audio.Buffer
+
+	handlers []*Handler
+	now      func() time.Time
+}
+
+type mixerSource struct {
+	name      string
+	gain      float64 // linear
+	invert    bool
+	resampler *audio.Resampler
+	queue     audio.Buffer // resampled samples waiting to be mixed
+	last      time.Time    // when the source last wrote
+}
+
+// NewMixer opens a capture handler for each source, writing the mix to out
+func NewMixer(sources []MixerSource, mode MixMode, out io.Writer) (m *Mixer, err error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("a mixer needs at least one source")
+	}
+	m = newMixer(out, mode, int(audio.BufferSize))
+	defer func() {
+		if err != nil {
+			m.Quit()
+		}
+	}()
+	for i, src := range sources {
+		s := m.addSource(src.DeviceID, src.GainDB)
+		s.invert = src.InvertPhase
+		h, err := NewHandler(src.DeviceID, &mixerInput{m: m, s: s})
+		if err != nil {
+			return nil, fmt.Errorf("error opening source %d: %w", i, err)
+		}
+		m.mu.Lock()
+		m.handlers = append(m.handlers, h)
+		s.resampler = audio.NewResampler(h.SampleRate(), float64(audio.SampleRate))
+		m.mu.Unlock()
+		log.Logger.WithField("context", "Capture Mixer").Infof("Mixing source %d at %.0f Hz", i, h.SampleRate())
+	}
+	return m, nil
+}
+
+func newMixer(out io.Writer, mode MixMode, frame int) *Mixer {
+	return &Mixer{
+		out:   out,
+		mode:  mode,
+		frame: frame,
+		mix:   make([]float64, frame),
+		buf:   make(audio.Buffer, frame),
+		now:   time.Now,
+	}
+}
+
+// adds a source without a resampler, which drops audio until one is set
+func (m *Mixer) addSource(name string, gainDB float64) *mixerSource {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := &mixerSource{name: name, gain: math.Pow(10, gainDB/20)}
+	m.sources = append(m.sources, s)
+	return s
+}
+
+// SetGain changes the gain of source i, in dB
+func (m *Mixer) SetGain(i int, gainDB float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i < 0 || i >= len(m.sources) {
+		return fmt.Errorf("no source %d, the mixer has %d", i, len(m.sources))
+	}
+	m.sources[i].gain = math.Pow(10, gainDB/20)
+	return nil
+}
+
+// SetInvertPhase negates source i, or stops negating it. It takes effect from the next frame mixed.
+func (m *Mixer) SetInvertPhase(i int, invert bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i < 0 || i >= len(m.sources) {
+		return fmt.Errorf("no source %d, the mixer has %d", i, len(m.sources))
+	}
+	m.sources[i].invert = invert
+	return nil
+}
+
+// Pause stops every source writing without closing the devices
+func (m *Mixer) Pause() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, h := range m.handlers {
+		h.Pause()
+	}
+}
+
+// Resume restarts every source after Pause
+func (m *Mixer) Resume() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, h := range m.handlers {
+		h.Resume()
+	}
+}
+
+// Paused reports whether no open source is writing, after Pause
+func (m *Mixer) Paused() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, h := range m.handlers {
+		if !h.Stopped() && !h.Paused() {
+			return false
+		}
+	}
+	return true
+}
+
+// Quit closes every source
+func (m *Mixer) Quit() {
+	m.mu.Lock()
+	handlers := m.handlers
+	m.handlers = nil
+	m.mu.Unlock()
+	for _, h := range handlers {
+		h.Quit()
+	}
+}
+
+// mixerInput receives a source's captured audio
+type mixerInput struct {
+	m *Mixer
+	s *mixerSource
+}
+
+func (in *mixerInput) Write(p []byte) (int, error) {
+	in.m.push(in.s, audio.BytesToAudioBuffer(p)[:len(p)/2])
+	return len(p), nil
+}
+
+// queues a source's samples, then mixes every frame now ready and writes them out
+func (m *Mixer) push(s *mixerSource, b audio.Buffer) {
+	m.mu.Lock()
+	if s.resampler == nil {
+		m.mu.Unlock()
+		return
+	}
+	s.last = m.now()
+	s.queue = append(s.queue, s.resampler.Process(b)...)
+	if over := len(s.queue) - mixerMaxQueued*m.frame; over > 0 {
+		log.Logger.WithField("context", "Capture Mixer").Debugf("Source '%s' running ahead, dropping %d samples", s.name, over)
+		s.queue = append(s.queue[:0], s.queue[over:]...)
+	}
+
+	var frames [][]byte
+	for m.ready() {
+		frames = append(frames, m.mixFrame())
+	}
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	m.mu.Unlock()
+	for _, frame := range frames {
+		if _, err := m.out.Write(frame); err != nil {
+			log.Logger.WithField("context", "Capture Mixer").Errorf("Error writing mix: %v", err)
+		}
+	}
+}
+
+// ready reports whether a frame can be mixed: every source is either ready or stale, and one is ready
+func (m *Mixer) ready() bool {
+	now := m.now()
+	ready := false
+	for _, s := range m.sources {
+		switch {
+		case len(s.queue) >= m.frame:
+			ready = true
+		case now.Sub(s.last) < mixerStaleAfter:
+			return false
+		}
+	}
+	return ready
+}
+
+// mixes a frame from the sources' queues, returning it encoded for writing
+func (m *Mixer) mixFrame() []byte {
+	for i := range m.mix {
+		m.mix[i] = 0
+	}
+	mixed := 0
+	for _, s := range m.sources {
+		if len(s.queue) < m.frame {
+			continue
+		}
+		// negating in float64, as -32768 has no int16 opposite
+		gain := s.gain
+		if s.invert {
+			gain = -gain
+		}
+		for i, v := range s.queue[:m.frame] {
+			m.mix[i] += float64(v) * gain
+		}
+		s.queue = append(s.queue[:0], s.queue[m.frame:]...)
+		mixed++
+	}
+	scale := 1.0
+	if m.mode == MixAverage {
+		scale = 1 / float64(mixed)
+	}
+	for i, v := range m.mix {
+		m.buf[i] = audio.Clip16(v * scale)
+	}
+	return m.buf.AsBytes()
+}
@@ -0,0 +1,167 @@
+package capture
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/LedFx/ledfx/pkg/logger"
+)
+
+const (
+	// overflow rates are measured over windows of this length
+	tuneWindow = 2 * time.Second
+	// the buffer grows when more callbacks than this overflow in a window
+	growRate = 0.01
+	// and shrinks after this many windows in a row without overflows
+	stableWindows = 15
+	// each grow doubles the stable windows needed to shrink again, up to this many times,
+	// so a size on the edge of overflowing doesn't flip back and forth
+	maxBackoff = 4
+)
+
+// tuner watches stream callbacks for overflows, deciding when the buffer should change size
+type tuner struct {
+	min, max int
+	frames   int // current frames per buffer
+
+	now         func() time.Time
+	windowStart time.Time
+	callbacks   int
+	overflows   int
+
+	stable  int // windows in a row without overflows
+	backoff int // grows so far, capped at maxBackoff
+	settle  bool
+}
+
+func newTuner(frames, min, max int) *tuner {
+	return &tuner{
+		min:    min,
+		max:    max,
+		frames: frames,
+		now:    time.Now,
+	}
+}
+
+// observe counts a callback, returning the new buffer size and the overflow rate which
+// triggered it once a window ends with a change due, otherwise 0
+func (t *tuner) observe(overflow bool) (frames int, rate float64) {
+	now := t.now()
+	if t.windowStart.IsZero() {
+		t.windowStart = now
+	}
+	t.callbacks++
+	if overflow {
+		t.overflows++
+	}
+	if now.Sub(t.windowStart) < tuneWindow {
+		return 0, 0
+	}
+
+	rate = float64(t.overflows) / float64(t.callbacks)
+	t.windowStart, t.callbacks, t.overflows = now, 0, 0
+	if t.settle {
+		// reopening the stream glitches, so the window after a change isn't judged
+		t.settle = false
+		return 0, 0
+	}
+
+	switch {
+	case rate > growRate && t.frames < t.max:
+		frames = t.frames * 2
+		if frames > t.max {
+			frames = t.max
+		}
+		if t.backoff < maxBackoff {
+			t.backoff++
+		}
+	case rate > 0:
+		t.stable = 0
+		return 0, 0
+	default:
+		t.stable++
+		if t.stable < stableWindows<<t.backoff || t.frames <= t.min {
+			return 0, 0
+		}
+		frames = t.frames / 2
+		if frames < t.min {
+			frames = t.min
+		}
+	}
+	t.frames, t.stable, t.settle = frames, 0, true
+	return frames, rate
+}
+
+// AutoTune grows the capture buffer when overflows are frequent and shrinks it when capture
+// is stable, between min and max frames. Each change reopens the stream.
+func (h *Handler) AutoTune(min, max int) error {
+	if min < 1 || max < min {
+		return fmt.Errorf("invalid buffer bounds [%d, %d]", min, max)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	frames := h.params.FramesPerBuffer
+	if frames < min {
+		frames = min
+	} else if frames > max {
+		frames = max
+	}
+	if h.stopped {
+		return fmt.Errorf("capture has stopped")
+	}
+	if h.retune == nil {
+		h.retune = make(chan retune, 1)
+		go h.retuneLoop()
+	}
+	h.tuner.Store(newTuner(frames, min, max))
+	if frames != h.params.FramesPerBuffer {
+		h.requestRetune(retune{frames: frames})
+	}
+	return nil
+}
+
+// retune is a buffer size for retuneLoop to reopen the stream at
+type retune struct {
+	frames int
+	tuned  bool // set when the tuner asked for it, having seen the overflow rate
+	rate   float64
+}
+
+// tune is called from the stream callback, handing size changes off to retuneLoop.
+// It doesn't log, as logging could block the callback.
+func (h *Handler) tune(overflow bool) {
+	t, ok := h.tuner.Load().(*tuner)
+	if !ok {
+		return
+	}
+	if frames, rate := t.observe(overflow); frames != 0 {
+		h.requestRetune(retune{frames: frames, tuned: true, rate: rate})
+	}
+}
+
+// queues a resize without blocking, replacing one not yet applied
+func (h *Handler) requestRetune(r retune) {
+	for {
+		select {
+		case h.retune <- r:
+			return
+		default:
+		}
+		select {
+		case <-h.retune:
+		default:
+		}
+	}
+}
+
+// reopens the stream at each new size until the handler quits
+func (h *Handler) retuneLoop() {
+	for r := range h.retune {
+		if r.tuned {
+			log.Logger.WithField("context", "Capture Handler").Infof("Overflow rate %.2f%%, resizing buffer to %d frames", r.rate*100, r.frames)
+		}
+		if err := h.reopen(r.frames); err != nil {
+			log.Logger.WithField("context", "Capture Handler").Errorf("Error resizing buffer: %v", err)
+		}
+	}
+}
@@ -9,10 +9,15 @@ type CaptureAction int
 
 const (
 	CaptureActionStop CaptureAction = iota
+	// CaptureActionInvertPhase sets whether a mixed input's source, or a stereo device's channel, is negated
+	CaptureActionInvertPhase
 )
 
 type CaptureCTLJSON struct {
 	Action CaptureAction `json:"action"`
+	// Source and Invert are only used by CaptureActionInvertPhase. Source is the channel for a single device
+	Source int  `json:"source,omitempty"`
+	Invert bool `json:"invert,omitempty"`
 }
 
 func (capctl CaptureCTLJSON) AsJSON() ([]byte, error) {
@@ -28,7 +33,9 @@ func (j *JsonCTL) Capture(jsonData []byte) (err error) {
 	switch conf.Action {
 	case CaptureActionStop:
 		return j.w.br.Controller().Local().QuitCapture()
+	case CaptureActionInvertPhase:
+		return j.w.br.Controller().Local().SetInvertPhase(conf.Source, conf.Invert)
 	}
 
-	return fmt.Errorf("unknown action '%d'", conf.Action)
+	return fmt.Errorf("%w '%d'", ErrUnknownAction, conf.Action)
 }
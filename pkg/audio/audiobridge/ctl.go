@@ -1,10 +1,11 @@
 package audiobridge
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/LedFx/ledfx/pkg/audio"
 	"github.com/LedFx/ledfx/pkg/audio/audiobridge/youtube"
 	"github.com/LedFx/ledfx/pkg/integrations/airplay2"
 )
@@ -26,6 +27,23 @@ type Controller struct {
 func (c *Controller) InputType() string {
 	return c.br.inputType.String()
 }
+
+// SetRampDuration sets how long switching input sources takes to fade out and back in
+func (c *Controller) SetRampDuration(d time.Duration) {
+	c.br.ramp.SetDuration(d)
+}
+
+// WatchDevices reports local audio devices being plugged in and removed until ctx is done,
+// so device lists can be kept up to date without a restart
+func (c *Controller) WatchDevices(ctx context.Context) <-chan audio.DeviceChange {
+	return audio.WatchDevices(ctx)
+}
+
+// Clipping returns how much of the input hits full scale. A steady rate means the input gain is too high.
+func (c *Controller) Clipping() audio.ClipStats {
+	return c.br.clip.Stats()
+}
+
 func (c *Controller) Outputs() []OutputInfo {
 	outputs := make([]OutputInfo, len(c.br.outputs))
 	for i := range c.br.outputs {
@@ -49,7 +67,7 @@ func (ytc *YoutubeController) CheckErr() error {
 			return nil
 		}
 	}
-	return fmt.Errorf("YouTube handler is not active")
+	return fmt.Errorf("YouTube handler %w", ErrNotActive)
 }
 
 func (ytc *YoutubeController) NowPlaying() (info youtube.TrackInfo, err error) {
@@ -58,7 +76,7 @@ func (ytc *YoutubeController) NowPlaying() (info youtube.TrackInfo, err error) {
 			return ytc.handler.handler.Player().NowPlaying(), nil
 		}
 	}
-	return info, fmt.Errorf("YouTube handler is not active")
+	return info, fmt.Errorf("YouTube handler %w", ErrNotActive)
 }
 func (ytc *YoutubeController) QueuedTracks() ([]youtube.TrackInfo, error) {
 	if ytc.handler != nil {
@@ -66,7 +84,7 @@ func (ytc *YoutubeController) QueuedTracks() ([]youtube.TrackInfo, error) {
 			return ytc.handler.handler.Player().QueuedTracks(), nil
 		}
 	}
-	return nil, fmt.Errorf("YouTube handler is not active")
+	return nil, fmt.Errorf("YouTube handler %w", ErrNotActive)
 }
 
 func (ytc *YoutubeController) TimeElapsed() (time.Duration, error) {
@@ -75,7 +93,7 @@ func (ytc *YoutubeController) TimeElapsed() (time.Duration, error) {
 			return ytc.handler.handler.Player().TimeElapsed(), nil
 		}
 	}
-	return -1, errors.New("YouTube handler is not active")
+	return -1, fmt.Errorf("YouTube handler %w", ErrNotActive)
 }
 
 func (ytc *YoutubeController) IsPaused() (bool, error) {
@@ -84,7 +102,7 @@ func (ytc *YoutubeController) IsPaused() (bool, error) {
 			return ytc.handler.handler.Player().IsPaused(), nil
 		}
 	}
-	return false, fmt.Errorf("YouTube handler is not active")
+	return false, fmt.Errorf("YouTube handler %w", ErrNotActive)
 }
 func (ytc *YoutubeController) TrackIndex() (int, error) {
 	if ytc.handler != nil {
@@ -92,7 +110,7 @@ func (ytc *YoutubeController) TrackIndex() (int, error) {
 			return ytc.handler.handler.Player().TrackIndex(), nil
 		}
 	}
-	return -1, fmt.Errorf("YouTube handler is not active")
+	return -1, fmt.Errorf("YouTube handler %w", ErrNotActive)
 }
 func (ytc *YoutubeController) IsPlaying() (bool, error) {
 	if ytc.handler != nil {
@@ -100,7 +118,7 @@ func (ytc *YoutubeController) IsPlaying() (bool, error) {
 			return ytc.handler.handler.Player().IsPlaying(), nil
 		}
 	}
-	return false, fmt.Errorf("YouTube handler is not active")
+	return false, fmt.Errorf("YouTube handler %w", ErrNotActive)
 }
 
 // --- END YOUTUBE CTL ---
@@ -119,14 +137,14 @@ func (lc *LocalController) Stop() error {
 		lc.handler.Stop()
 		return nil
 	}
-	return fmt.Errorf("local handler is not active")
+	return fmt.Errorf("local handler %w", ErrNotActive)
 }
 func (lc *LocalController) QuitPlayback() error {
 	if lc.handler != nil {
 		lc.handler.playback.Quit()
 		return nil
 	}
-	return fmt.Errorf("local playback is not active")
+	return fmt.Errorf("local playback %w", ErrNotActive)
 }
 func (lc *LocalController) QuitCapture() error {
 	if lc.handler != nil {
@@ -134,14 +152,31 @@ func (lc *LocalController) QuitCapture() error {
 			lc.handler.capture.Quit()
 			return nil
 		}
+		if lc.handler.mixer != nil {
+			lc.handler.quitMixer()
+			return nil
+		}
 	}
-	return fmt.Errorf("local capture is not active")
+	return fmt.Errorf("local capture %w", ErrNotActive)
 }
+
+// SetInvertPhase negates, or stops negating, source i of a mixed capture input,
+// or channel i (0 left, 1 right) of a single device captured in stereo
+func (lc *LocalController) SetInvertPhase(i int, invert bool) error {
+	if lc.handler != nil && lc.handler.mixer != nil {
+		return lc.handler.mixer.SetInvertPhase(i, invert)
+	}
+	if lc.handler != nil && lc.handler.capture != nil {
+		return lc.handler.capture.SetInvertPhase(i, invert)
+	}
+	return fmt.Errorf("local capture %w", ErrNotActive)
+}
+
 func (lc *LocalController) PlaybackIdentifier() (string, error) {
 	if lc.handler != nil {
 		return lc.handler.playback.Identifier(), nil
 	}
-	return "", fmt.Errorf("local playback is not active")
+	return "", fmt.Errorf("local playback %w", ErrNotActive)
 }
 
 // --- END LOCAL CTL ---
@@ -162,11 +197,89 @@ func (apc *AirPlayController) StopServer() error {
 			return nil
 		}
 	}
-	return fmt.Errorf("server is not active")
+	return fmt.Errorf("server %w", ErrNotActive)
+}
+func (apc *AirPlayController) ReannounceService() error {
+	if apc.handler != nil {
+		if apc.handler.server != nil {
+			return apc.handler.server.ReannounceService()
+		}
+	}
+	return fmt.Errorf("server %w", ErrNotActive)
+}
+
+// Remote sends a DACP transport command to the sender of the active AirPlay stream
+func (apc *AirPlayController) Remote(action AirPlayAction) error {
+	if apc.handler == nil || apc.handler.server == nil {
+		return fmt.Errorf("server %w", ErrNotActive)
+	}
+	switch action {
+	case AirPlayActionPlay:
+		return apc.handler.server.Play()
+	case AirPlayActionPause:
+		return apc.handler.server.Pause()
+	case AirPlayActionNext:
+		return apc.handler.server.Next()
+	case AirPlayActionPrevious:
+		return apc.handler.server.Previous()
+	}
+	return fmt.Errorf("%w '%s'", ErrUnknownAction, action)
+}
+func (apc *AirPlayController) RouteToOutputs() error {
+	if apc.handler != nil {
+		if apc.handler.server != nil {
+			apc.handler.server.RouteToOutputs()
+			return nil
+		}
+	}
+	return fmt.Errorf("server %w", ErrNotActive)
+}
+func (apc *AirPlayController) UnrouteFromOutputs() error {
+	if apc.handler != nil {
+		if apc.handler.server != nil {
+			apc.handler.server.UnrouteFromOutputs()
+			return nil
+		}
+	}
+	return fmt.Errorf("server %w", ErrNotActive)
+}
+func (apc *AirPlayController) Routed() (bool, error) {
+	if apc.handler != nil {
+		if apc.handler.server != nil {
+			return apc.handler.server.Routed(), nil
+		}
+	}
+	return false, fmt.Errorf("server %w", ErrNotActive)
+}
+
+// SetVolume sets the AirPlay playback volume, between 0 and 1
+func (apc *AirPlayController) SetVolume(volume float64) error {
+	if apc.handler != nil {
+		if apc.handler.server != nil {
+			return apc.handler.server.SetVolume(volume)
+		}
+	}
+	return fmt.Errorf("server %w", ErrNotActive)
+}
+func (apc *AirPlayController) SetVolumeCurve(curve airplay2.VolumeCurve) error {
+	if apc.handler != nil {
+		if apc.handler.server != nil {
+			return apc.handler.server.SetVolumeCurve(curve)
+		}
+	}
+	return fmt.Errorf("server %w", ErrNotActive)
+}
+func (apc *AirPlayController) Volume() (float64, airplay2.VolumeCurve, error) {
+	if apc.handler != nil {
+		if apc.handler.server != nil {
+			return apc.handler.server.Volume(), apc.handler.server.VolumeCurve(), nil
+		}
+	}
+	return 0, "", fmt.Errorf("server %w", ErrNotActive)
 }
 func (apc *AirPlayController) Clients() []*airplay2.Client {
 	if apc.handler != nil {
-		return apc.handler.clients
+		return apc.handler.clientList()
 	}
 	return nil
 }
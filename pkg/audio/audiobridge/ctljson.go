@@ -1,6 +1,12 @@
 package audiobridge
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/LedFx/ledfx/pkg/audio/audiobridge/youtube"
 )
 
@@ -23,3 +29,23 @@ func (w *BridgeJSONWrapper) CTL() *JsonCTL {
 		return w.CTL()
 	}
 }
+
+// unmarshalStrict unmarshals jsonData into v, rejecting unknown fields.
+// Field problems wrap ErrInvalidField and name the field.
+func unmarshalStrict(jsonData []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(jsonData))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil {
+		return nil
+	}
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("%w '%s': expected %s, got %s", ErrInvalidField, typeErr.Field, typeErr.Type, typeErr.Value)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return fmt.Errorf("%w %s: not supported", ErrInvalidField, strings.TrimPrefix(err.Error(), "json: unknown field "))
+	default:
+		return fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+}
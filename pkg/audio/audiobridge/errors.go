@@ -0,0 +1,20 @@
+package audiobridge
+
+import "errors"
+
+var (
+	// ErrNotActive is wrapped by controller errors when the targeted handler hasn't been started.
+	ErrNotActive = errors.New("is not active")
+
+	// ErrUnknownAction is wrapped by JsonCTL errors when the requested action isn't recognised.
+	ErrUnknownAction = errors.New("unknown action")
+
+	// ErrInvalidField is wrapped by JsonCTL errors when a field is unknown or has the wrong type.
+	ErrInvalidField = errors.New("invalid field")
+
+	// ErrInvalidLogLevel is wrapped by JsonCTL errors when a log level can't be parsed.
+	ErrInvalidLogLevel = errors.New("invalid log level")
+
+	// ErrTestToneActive is returned when a test tone is requested while one is already playing.
+	ErrTestToneActive = errors.New("a test tone is already playing")
+)
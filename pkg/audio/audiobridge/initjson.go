@@ -3,6 +3,10 @@ package audiobridge
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/LedFx/ledfx/pkg/audio"
+	"github.com/LedFx/ledfx/pkg/audio/audiobridge/capture"
+	"github.com/LedFx/ledfx/pkg/integrations/airplay2"
 )
 
 type Wrapper interface {
@@ -12,8 +16,22 @@ type Wrapper interface {
 
 // AirPlayInputJSON configures an AirPlay input (server)
 type AirPlayInputJSON struct {
-	Name string `json:"name"`
-	Port int    `json:"port"`
+	Name               string `json:"name"`
+	Port               int    `json:"port"` // RTSP port. 0 is 7000, negative picks any free port
+	IPv4Only           bool   `json:"ipv4_only,omitempty"`
+	Interface          string `json:"interface,omitempty"`
+	BindIP             string `json:"bind_ip,omitempty"`
+	DisableConcealment bool   `json:"disable_concealment,omitempty"`
+	VerifyFormat       bool   `json:"verify_format,omitempty"`
+	JitterDepth        int    `json:"jitter_depth,omitempty"`
+	ReceiveBuffer      int    `json:"receive_buffer_ms,omitempty"`
+	MaxClients         int    `json:"max_clients,omitempty"`
+	StatePath          string `json:"state_path,omitempty"`
+	VolumeCurve        string `json:"volume_curve,omitempty"`
+
+	// AutoSwitch captures from CaptureDeviceID while no sender is connected, see Bridge.EnableAutoSwitch
+	AutoSwitch      bool   `json:"auto_switch,omitempty"`
+	CaptureDeviceID string `json:"capture_device_id,omitempty"`
 }
 
 func (a AirPlayInputJSON) AsJSON() ([]byte, error) {
@@ -33,6 +51,15 @@ func (a AirPlayOutputJSON) AsJSON() ([]byte, error) {
 // LocalInputJSON configures a local input (capture)
 type LocalInputJSON struct {
 	DeviceID string `json:"device_id,omitempty"`
+	// MinFrames and MaxFrames, if MaxFrames is set, let the buffer size adapt to overflows.
+	// MinFrames defaults to audio.MinBufferSize.
+	MinFrames int `json:"min_frames,omitempty"`
+	MaxFrames int `json:"max_frames,omitempty"`
+
+	// Sources, if set, mixes several devices into one input in place of DeviceID.
+	// Sum adds them rather than averaging.
+	Sources []capture.MixerSource `json:"sources,omitempty"`
+	Sum     bool                  `json:"sum,omitempty"`
 }
 
 func (l LocalInputJSON) AsJSON() ([]byte, error) {
@@ -47,6 +74,15 @@ func (l LocalOutputJSON) AsJSON() ([]byte, error) {
 	return json.Marshal(&l)
 }
 
+// PipeOutputJSON configures a named pipe output. Path is relative to the bridge's pipe directory.
+type PipeOutputJSON struct {
+	Path string `json:"path"`
+}
+
+func (p PipeOutputJSON) AsJSON() ([]byte, error) {
+	return json.Marshal(&p)
+}
+
 type YouTubeInputJSON struct {
 }
 
@@ -72,20 +108,77 @@ func (w *BridgeJSONWrapper) StartAirPlayInput(jsonData []byte) (err error) {
 		return fmt.Errorf("error unmarshalling JSON: %w", err)
 	}
 
-	if conf.Name == "" {
-		conf.Name = "LedFX"
+	serverConf, err := conf.config()
+	if err != nil {
+		return err
 	}
-	if conf.Port == 0 {
-		conf.Port = 7000
-	}
-
-	if err := w.br.StartAirPlayInput(conf.Name, conf.Port); err != nil {
+	if err := w.br.StartAirPlayInputConfig(serverConf); err != nil {
 		return fmt.Errorf("error starting AirPlay Server: %w", err)
 	}
+	if conf.AutoSwitch {
+		if err := w.br.EnableAutoSwitch(conf.CaptureDeviceID); err != nil {
+			return fmt.Errorf("error enabling auto switch: %w", err)
+		}
+	}
 
 	return nil
 }
 
+// config converts to server options, filling in the default name and port
+func (a AirPlayInputJSON) config() (airplay2.Config, error) {
+	if a.Name == "" {
+		a.Name = "LedFX"
+	}
+	switch {
+	case a.Port == 0:
+		a.Port = 7000
+	case a.Port < 0:
+		a.Port = 0
+	}
+
+	curve, err := airplay2.ParseVolumeCurve(a.VolumeCurve)
+	if err != nil {
+		return airplay2.Config{}, err
+	}
+
+	return airplay2.Config{
+		AdvertisementName:  a.Name,
+		RTSPPort:           a.Port,
+		IPv4Only:           a.IPv4Only,
+		Interface:          a.Interface,
+		BindIP:             a.BindIP,
+		DisableConcealment: a.DisableConcealment,
+		VerifyFormat:       a.VerifyFormat,
+		JitterDepth:        a.JitterDepth,
+		ReceiveBuffer:      a.ReceiveBuffer,
+		MaxClients:         a.MaxClients,
+		StatePath:          a.StatePath,
+		VolumeCurve:        curve,
+	}, nil
+}
+
+// airPlayInputJSON is the inverse of AirPlayInputJSON.config
+func airPlayInputJSON(conf airplay2.Config) AirPlayInputJSON {
+	port := conf.RTSPPort
+	if port == 0 {
+		port = -1
+	}
+	return AirPlayInputJSON{
+		Name:               conf.AdvertisementName,
+		Port:               port,
+		IPv4Only:           conf.IPv4Only,
+		Interface:          conf.Interface,
+		BindIP:             conf.BindIP,
+		DisableConcealment: conf.DisableConcealment,
+		VerifyFormat:       conf.VerifyFormat,
+		JitterDepth:        conf.JitterDepth,
+		ReceiveBuffer:      conf.ReceiveBuffer,
+		MaxClients:         conf.MaxClients,
+		StatePath:          conf.StatePath,
+		VolumeCurve:        string(conf.VolumeCurve),
+	}
+}
+
 // AddAirPlayOutput takes a marshalled AirPlayOutputJSON
 func (w *BridgeJSONWrapper) AddAirPlayOutput(jsonData []byte) (err error) {
 	conf := AirPlayOutputJSON{}
@@ -104,9 +197,27 @@ func (w *BridgeJSONWrapper) StartLocalInput(jsonData []byte) (err error) {
 	if err := json.Unmarshal(jsonData, &conf); err != nil {
 		return fmt.Errorf("error unmarshalling JSON: %w", err)
 	}
+	if len(conf.Sources) > 0 {
+		mode := capture.MixAverage
+		if conf.Sum {
+			mode = capture.MixSum
+		}
+		if err := w.br.StartMixedInput(conf.Sources, mode); err != nil {
+			return fmt.Errorf("error starting mixed capture: %w", err)
+		}
+		return nil
+	}
 	if err := w.br.StartLocalInput(conf.DeviceID); err != nil {
 		return fmt.Errorf("error starting local capture: %w", err)
 	}
+	if conf.MaxFrames > 0 {
+		if conf.MinFrames == 0 {
+			conf.MinFrames = audio.MinBufferSize
+		}
+		if err := w.br.local.capture.AutoTune(conf.MinFrames, conf.MaxFrames); err != nil {
+			return fmt.Errorf("error enabling buffer tuning: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -122,6 +233,21 @@ func (w *BridgeJSONWrapper) AddLocalOutput(jsonData []byte) (err error) {
 	return nil
 }
 
+// AddPipeOutput takes a marshalled PipeOutputJSON
+func (w *BridgeJSONWrapper) AddPipeOutput(jsonData []byte) (err error) {
+	conf := PipeOutputJSON{}
+	if err := json.Unmarshal(jsonData, &conf); err != nil {
+		return fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+	if conf.Path == "" {
+		return fmt.Errorf("path %w", ErrInvalidField)
+	}
+	if err := w.br.AddPipeOutput(conf.Path); err != nil {
+		return fmt.Errorf("error starting pipe output: %w", err)
+	}
+	return nil
+}
+
 // StartYouTubeInput takes a marshalled YouTubeInputJSON
 func (w *BridgeJSONWrapper) StartYouTubeInput(jsonData []byte) (err error) {
 	conf := YouTubeInputJSON{}
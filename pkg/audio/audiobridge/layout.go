@@ -2,8 +2,10 @@ package audiobridge
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/LedFx/ledfx/pkg/audio"
+	"github.com/LedFx/ledfx/pkg/audio/audiobridge/pipe"
 )
 
 // Bridge can wire up an audio source to multiple destinations
@@ -13,20 +15,39 @@ type Bridge struct {
 
 	bufferCallback func(buf audio.Buffer)
 	byteWriter     *audio.AsyncMultiWriter
+	chain          *audio.Chain        // inputs write here, on the way to byteWriter
+	ramp           *audio.Ramp         // fades between input sources
+	clip           *audio.ClipDetector // counts clipped input samples
 
 	airplay *AirPlayHandler
 	local   *LocalHandler
 	youtube *YoutubeHandler
 
+	// autoSwitch is set while local capture runs alongside the AirPlay server.
+	// autoSwitchMu guards the pointer, as it is cleared while controller calls read it.
+	autoSwitchMu sync.Mutex
+	autoSwitch   *autoSwitch
+
+	// toneStop is set while a test tone holds the outputs, closed to end it early.
+	// toneDone is closed once the tone has quit and the live input is restored.
+	toneMu   sync.Mutex
+	toneStop chan struct{}
+	toneDone chan struct{}
+
 	ctl *Controller
 
 	done chan bool
 
+	shutdownOnce sync.Once
+	shutdownDone chan struct{}
+
 	jsonWrapper *BridgeJSONWrapper
 
 	info *Info
 
 	outputs []*OutputInfo
+	pipes   []*pipe.Writer
+	pipeDir string
 }
 
 // inputType indicates the audio source a bridge will use
@@ -79,6 +100,7 @@ const (
 	outputTypeLocal     OutputType = "local"
 	outputTypeGeneric   OutputType = "generic"
 	outputTypeBluetooth OutputType = "bluetooth"
+	outputTypePipe      OutputType = "pipe"
 )
 
 type OutputInfo struct {
@@ -103,6 +125,9 @@ type LocalOutputInfo struct {
 type GenericOutputInfo struct {
 	Identifier string `json:"identifier"`
 }
+type PipeOutputInfo struct {
+	Path string `json:"path"`
+}
 type BluetoothOutputInfo struct {
 	// TODO
 }
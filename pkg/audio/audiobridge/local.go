@@ -12,6 +12,7 @@ import (
 type LocalHandler struct {
 	playback playback.Handler
 	capture  *capture.Handler
+	mixer    *capture.Mixer // set instead of capture when several devices are mixed
 }
 
 func newLocalHandler() *LocalHandler {
@@ -32,9 +33,10 @@ func (br *Bridge) StartLocalInput(id string) (err error) {
 	if br.local.capture != nil {
 		br.local.capture.Quit()
 	}
+	br.local.quitMixer()
 
 	log.Logger.WithField("context", "Local Capture Init").Infof("Initializing new capture handler...")
-	if br.local.capture, err = capture.NewHandler(id, br.byteWriter); err != nil {
+	if br.local.capture, err = capture.NewHandler(id, br.chain); err != nil {
 		return fmt.Errorf("error initializing new capture handler: %w", err)
 	}
 	config.SetLocalInput(id)
@@ -42,6 +44,31 @@ func (br *Bridge) StartLocalInput(id string) (err error) {
 	return nil
 }
 
+// StartMixedInput captures from several local devices at once, mixing them into one input
+func (br *Bridge) StartMixedInput(sources []capture.MixerSource, mode capture.MixMode) (err error) {
+	if br.inputType != -1 {
+		br.closeInput()
+	}
+
+	br.inputType = inputTypeLocal
+
+	if br.local == nil {
+		br.local = newLocalHandler()
+	}
+
+	if br.local.capture != nil {
+		br.local.capture.Quit()
+		br.local.capture = nil
+	}
+	br.local.quitMixer()
+
+	log.Logger.WithField("context", "Local Capture Init").Infof("Initializing mixer for %d devices...", len(sources))
+	if br.local.mixer, err = capture.NewMixer(sources, mode, br.chain); err != nil {
+		return fmt.Errorf("error initializing new capture mixer: %w", err)
+	}
+	return nil
+}
+
 func (br *Bridge) AddLocalOutput() (err error) {
 	if br.local == nil {
 		br.local = newLocalHandler()
@@ -75,8 +102,19 @@ func (lh *LocalHandler) Stop() {
 		log.Logger.WithField("context", "Local Audio UnixHandler").Warnf("Stopping capture handler...")
 		lh.capture.Quit()
 	}
+	if lh.mixer != nil {
+		log.Logger.WithField("context", "Local Audio UnixHandler").Warnf("Stopping capture mixer...")
+		lh.quitMixer()
+	}
 	if lh.playback != nil {
 		log.Logger.WithField("context", "Local Audio UnixHandler").Warnf("Stopping playback handler...")
 		lh.playback.Quit()
 	}
 }
+
+func (lh *LocalHandler) quitMixer() {
+	if lh.mixer != nil {
+		lh.mixer.Quit()
+		lh.mixer = nil
+	}
+}
@@ -0,0 +1,80 @@
+package audiobridge
+
+import (
+	"encoding/json"
+	"fmt"
+
+	log "github.com/LedFx/ledfx/pkg/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+type LogAction string
+
+const (
+	LogActionSetLevel LogAction = "set_level"
+	LogActionGetLevel LogAction = "get_level"
+	LogActionRecent   LogAction = "recent"
+)
+
+type LogCTLJSON struct {
+	Action LogAction `json:"action"`
+	// Level is a logrus level name, such as "debug" or "info". Only used by set_level.
+	Level string `json:"level,omitempty"`
+	// Lines limits how many lines recent returns, 0 for every line kept
+	Lines int `json:"lines,omitempty"`
+}
+
+func (lctl LogCTLJSON) AsJSON() ([]byte, error) {
+	return json.Marshal(&lctl)
+}
+
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+func (ll *LogLevel) AsJSON() ([]byte, error) {
+	return json.Marshal(ll)
+}
+
+// RecentLogs is what the recent action returns, oldest line first
+type RecentLogs struct {
+	Lines []log.Line `json:"lines"`
+}
+
+func (rl *RecentLogs) AsJSON() ([]byte, error) {
+	return json.Marshal(rl)
+}
+
+// Log takes a marshalled LogCTLJSON and returns the shared logger's level as a marshalled LogLevel.
+// Setting the level takes effect immediately, without a restart. The recent action instead returns
+// the latest log lines as a marshalled RecentLogs, so a bug report doesn't need access to the host.
+func (j *JsonCTL) Log(jsonData []byte) (resultJson []byte, err error) {
+	conf := LogCTLJSON{}
+	if err := json.Unmarshal(jsonData, &conf); err != nil {
+		return nil, fmt.Errorf("error unmarshalling JSON: %w", err)
+	}
+
+	switch conf.Action {
+	case LogActionSetLevel:
+		level, err := logrus.ParseLevel(conf.Level)
+		if err != nil {
+			return nil, fmt.Errorf("%w '%s'", ErrInvalidLogLevel, conf.Level)
+		}
+		log.Logger.SetLevel(level)
+		log.Logger.WithField("context", "Log CTL").Infof("Log level set to %s", level)
+	case LogActionGetLevel:
+	case LogActionRecent:
+		rl := &RecentLogs{
+			Lines: log.Ring.Recent(conf.Lines),
+		}
+		return rl.AsJSON()
+	default:
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownAction, conf.Action)
+	}
+
+	ll := &LogLevel{
+		Level: log.Logger.GetLevel().String(),
+	}
+	return ll.AsJSON()
+}
@@ -0,0 +1,77 @@
+package audiobridge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LedFx/ledfx/pkg/audio/audiobridge/pipe"
+	"github.com/LedFx/ledfx/pkg/constants"
+	log "github.com/LedFx/ledfx/pkg/logger"
+)
+
+// DefaultPipeDir is where named pipe outputs are made unless SetPipeDir changes it
+var DefaultPipeDir = filepath.Join(constants.GetOsConfigDir(), "pipes")
+
+// SetPipeDir sets the directory named pipe outputs are confined to
+func (br *Bridge) SetPipeDir(dir string) {
+	br.pipeDir = dir
+}
+
+// AddPipeOutput writes the raw 16 bit PCM from the current input to the named pipe at name,
+// creating it if needed. name is relative to the pipe directory, and can't leave it.
+// Audio is dropped while no reader has the pipe open.
+func (br *Bridge) AddPipeOutput(name string) error {
+	path, err := br.pipePath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(br.pipeDir, 0o755); err != nil {
+		return fmt.Errorf("error creating pipe directory: %w", err)
+	}
+	w, err := pipe.New(path)
+	if err != nil {
+		return fmt.Errorf("error opening named pipe: %w", err)
+	}
+	if err := br.AddOutputWriter(w, pipeWriterID(path)); err != nil {
+		w.Close()
+		return fmt.Errorf("error adding pipe writer: %w", err)
+	}
+	br.pipes = append(br.pipes, w)
+	br.outputs = append(br.outputs, &OutputInfo{
+		Type: outputTypePipe,
+		Info: &PipeOutputInfo{
+			Path: path,
+		},
+	})
+	log.Logger.WithField("context", "Pipe Output").Infof("Writing audio to '%s'", path)
+	return nil
+}
+
+// resolves name within the pipe directory, rejecting absolute paths and any with a ".." element
+func (br *Bridge) pipePath(name string) (string, error) {
+	if name == "" || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("%w 'path': '%s' must be relative to the pipe directory", ErrInvalidField, name)
+	}
+	for _, elem := range strings.FieldsFunc(filepath.ToSlash(name), func(r rune) bool { return r == '/' }) {
+		if elem == ".." {
+			return "", fmt.Errorf("%w 'path': '%s' can't leave the pipe directory", ErrInvalidField, name)
+		}
+	}
+	return filepath.Join(br.pipeDir, name), nil
+}
+
+func pipeWriterID(path string) string {
+	return "pipe:" + path
+}
+
+// closes every pipe output, removing the ones AddPipeOutput created
+func (br *Bridge) closePipes() {
+	for _, w := range br.pipes {
+		if err := w.Close(); err != nil {
+			log.Logger.WithField("context", "Pipe Output").Warnf("Error closing pipe: %v", err)
+		}
+	}
+	br.pipes = nil
+}
@@ -0,0 +1,44 @@
+// Package pipe writes decoded audio to a named pipe, so external tools can read the
+// bridge's output without the pipeline ever waiting on them.
+package pipe
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+var (
+	ErrNotPipe     = errors.New("exists and is not a named pipe")
+	ErrUnsupported = errors.New("named pipes are not supported on this platform")
+)
+
+// how often opening the pipe is retried while no reader is attached
+const reopenInterval = 500 * time.Millisecond
+
+// Writer is an io.Writer for a named pipe. Audio written while no reader is attached,
+// or while the reader is too far behind to keep up, is dropped.
+type Writer struct {
+	path    string
+	created bool // the pipe was made by New, so Close removes it
+
+	mu       sync.Mutex
+	fd       int    // -1 while no reader is attached
+	pending  []byte // the rest of a partly written buffer, sent first so the reader stays sample aligned
+	lastOpen time.Time
+	closed   bool
+
+	dropped atomic.Uint64
+}
+
+// Path returns the path of the named pipe
+func (w *Writer) Path() string {
+	return w.path
+}
+
+// Dropped returns the number of writes discarded because no reader was keeping up
+func (w *Writer) Dropped() uint64 {
+	return w.dropped.Load()
+}
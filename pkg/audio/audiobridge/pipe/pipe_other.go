@@ -0,0 +1,118 @@
+//go:build !windows
+
+package pipe
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+	"time"
+
+	log "github.com/LedFx/ledfx/pkg/logger"
+)
+
+// New returns a Writer for the named pipe at path, creating the pipe if it doesn't exist.
+// A reader can attach and detach at any time.
+func New(path string) (*Writer, error) {
+	w := &Writer{path: path, fd: -1}
+	fi, err := os.Stat(path)
+	switch {
+	case err == nil:
+		if fi.Mode()&fs.ModeNamedPipe == 0 {
+			return nil, fmt.Errorf("'%s' %w", path, ErrNotPipe)
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		if err := syscall.Mkfifo(path, 0o644); err != nil {
+			return nil, fmt.Errorf("error creating named pipe '%s': %w", path, err)
+		}
+		w.created = true
+	default:
+		return nil, fmt.Errorf("error checking '%s': %w", path, err)
+	}
+	return w, nil
+}
+
+// Write passes p to the reader without blocking. It always reports p as written, so a
+// missing or slow reader never holds up or errors the other outputs. Once closed, it returns os.ErrClosed.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.fd < 0 && !w.open() {
+		w.dropped.Inc()
+		return len(p), nil
+	}
+	if len(w.pending) > 0 {
+		if w.pending = w.write(w.pending); len(w.pending) > 0 {
+			w.dropped.Inc()
+			return len(p), nil
+		}
+	}
+	if rest := w.write(p); len(rest) > 0 {
+		if len(rest) == len(p) {
+			w.dropped.Inc()
+		} else {
+			w.pending = append(w.pending[:0], rest...)
+		}
+	}
+	return len(p), nil
+}
+
+// writes as much of p as the pipe takes, returning what's left
+func (w *Writer) write(p []byte) []byte {
+	n, err := syscall.Write(w.fd, p)
+	if n < 0 {
+		n = 0
+	}
+	if errors.Is(err, syscall.EPIPE) {
+		log.Logger.WithField("context", "Pipe Output").Infof("Reader detached from '%s'", w.path)
+		w.closeFd()
+		return nil
+	}
+	return p[n:]
+}
+
+// tries to open the pipe, which only succeeds once a reader has it open. Must be called with mu held.
+func (w *Writer) open() bool {
+	if time.Since(w.lastOpen) < reopenInterval {
+		return false
+	}
+	w.lastOpen = time.Now()
+	fd, err := syscall.Open(w.path, syscall.O_WRONLY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		if !errors.Is(err, syscall.ENXIO) {
+			log.Logger.WithField("context", "Pipe Output").Warnf("Error opening '%s': %v", w.path, err)
+		}
+		return false
+	}
+	log.Logger.WithField("context", "Pipe Output").Infof("Reader attached to '%s'", w.path)
+	w.fd = fd
+	return true
+}
+
+func (w *Writer) closeFd() {
+	if w.fd >= 0 {
+		syscall.Close(w.fd)
+		w.fd = -1
+	}
+	w.pending = w.pending[:0]
+}
+
+// Close detaches from the pipe, removing it if it was created by New
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	w.closeFd()
+	if w.created {
+		w.created = false
+		if err := os.Remove(w.path); err != nil {
+			return fmt.Errorf("error removing named pipe '%s': %w", w.path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+//go:build !windows
+
+package pipe
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestPipeWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audio")
+	w, err := New(path)
+	if err != nil {
+		t.Fatalf("Error creating pipe: %v", err)
+	}
+
+	// nobody is reading, so the write is dropped rather than blocking
+	if n, err := w.Write([]byte{1, 2}); n != 2 || err != nil || w.Dropped() != 1 {
+		t.Errorf("Expected: write dropped without a reader\r\n Got: %d %v, %d dropped", n, err, w.Dropped())
+	}
+
+	r, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatalf("Error opening reader: %v", err)
+	}
+	defer r.Close()
+	w.lastOpen = w.lastOpen.Add(-reopenInterval) // skip the retry delay
+	want := []byte{3, 4, 5, 6}
+	w.Write(want)
+	got := make([]byte, 8)
+	n, _ := r.Read(got)
+	if !bytes.Equal(got[:n], want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got[:n])
+	}
+
+	if err := w.Close(); err != nil {
+		t.Errorf("Error closing pipe: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected: pipe removed on close\r\n Got: %v", err)
+	}
+	if _, err := w.Write(want); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Expected: %v writing after close\r\n Got: %v", os.ErrClosed, err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected: pipe not reopened after close\r\n Got: %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(file, nil, 0o644)
+	if _, err := New(file); !errors.Is(err, ErrNotPipe) {
+		t.Errorf("Expected: %v\r\n Got: %v", ErrNotPipe, err)
+	}
+}
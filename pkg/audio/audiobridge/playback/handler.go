@@ -6,6 +6,7 @@ import (
 
 	"github.com/LedFx/ledfx/pkg/audio"
 	log "github.com/LedFx/ledfx/pkg/logger"
+	"github.com/LedFx/ledfx/pkg/metrics"
 	"github.com/LedFx/ledfx/pkg/util"
 
 	"github.com/LedFx/portaudio"
@@ -17,8 +18,20 @@ type WindowsHandler struct {
 	outDev     *portaudio.DeviceInfo
 	buf        audio.Buffer
 	done       bool
+
+	// audio waiting for a full stream buffer, after drift correction
+	pending  audio.Buffer
+	drift    *audio.DriftCorrector
+	maxAvail int // largest AvailableToWrite seen, ie. the stream's buffer with nothing queued
 }
 
+// The source and the output device run on different clocks. Drift correction keeps
+// the frames queued for the device within driftTolerance of driftTarget.
+const (
+	driftTarget    = 704
+	driftTolerance = 352
+)
+
 func (wh *WindowsHandler) Device() string {
 	return wh.outDev.Name
 }
@@ -40,6 +53,17 @@ func NewHandler() (h *WindowsHandler, err error) {
 		identifier: util.RandString(8),
 		buf:        make([]int16, 1408/2),
 	}
+	h.pending = make(audio.Buffer, 0, 2*len(h.buf))
+
+	if err := audio.AcquirePortAudio(); err != nil {
+		return nil, err
+	}
+	// the handler holds PortAudio until Quit, unless it fails to open
+	defer func() {
+		if err != nil {
+			audio.ReleasePortAudio()
+		}
+	}()
 
 	// todo choose an output device using identifier like input device
 	if h.outDev, err = portaudio.DefaultOutputDevice(); err != nil {
@@ -52,6 +76,7 @@ func NewHandler() (h *WindowsHandler, err error) {
 	// Ensure format compatibility with the data sent over Player.Write()
 	h.outDev.DefaultSampleRate = 44100
 	h.outDev.MaxOutputChannels = 2
+	h.drift = audio.NewDriftCorrector(h.outDev.MaxOutputChannels, h.outDev.DefaultSampleRate, driftTarget, driftTolerance)
 
 	if h.stream, err = portaudio.OpenDefaultStream(
 		0,
@@ -64,6 +89,7 @@ func NewHandler() (h *WindowsHandler, err error) {
 	}
 	log.Logger.WithField("context", "Local Capture Init").Debug("Starting stream...")
 	if err = h.stream.Start(); err != nil {
+		h.stream.Close()
 		return nil, fmt.Errorf("error starting stream: %w", err)
 	}
 	return h, nil
@@ -73,16 +99,45 @@ func (wh *WindowsHandler) Write(p []byte) (n int, err error) {
 	if wh.done {
 		return 0, io.EOF
 	}
-	copy(wh.buf, audio.BytesToAudioBuffer(p))
-	_ = wh.stream.Write()
+	// pending takes a copy, so the decoded samples go straight back to the pool
+	in := audio.Buffers.FromBytes(p)
+	wh.pending = append(wh.pending, wh.drift.Correct(in, wh.queued())...)
+	audio.Buffers.Put(in)
+	metrics.PlaybackDriftPPM.Set(wh.drift.DriftPPM())
+
+	for len(wh.pending) >= len(wh.buf) {
+		copy(wh.buf, wh.pending)
+		_ = wh.stream.Write()
+		wh.pending = append(wh.pending[:0], wh.pending[len(wh.buf):]...)
+	}
 	return len(p), nil
 }
 
+// queued returns the frames written but not yet played
+func (wh *WindowsHandler) queued() int {
+	queued := len(wh.pending) / wh.outDev.MaxOutputChannels
+	avail, err := wh.stream.AvailableToWrite()
+	if err != nil {
+		return queued
+	}
+	if avail > wh.maxAvail {
+		wh.maxAvail = avail
+	}
+	return queued + wh.maxAvail - avail
+}
+
+// DriftPPM returns how far the source's clock is from the output's nominal rate, in parts per million
+func (wh *WindowsHandler) DriftPPM() float64 {
+	return wh.drift.DriftPPM()
+}
+
 func (wh *WindowsHandler) Quit() {
 	if wh.stream != nil {
 		wh.stream.Abort()
+		wh.stream.Close()
 		wh.stream = nil
 		wh.done = true
+		audio.ReleasePortAudio()
 	}
 }
 
@@ -9,6 +9,7 @@ type Handler interface {
 	SampleRate() int
 	CurrentBufferSize() int
 	NumChannels() int8
+	DriftPPM() float64
 
 	Quit()
 }
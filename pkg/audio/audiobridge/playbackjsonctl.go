@@ -30,5 +30,5 @@ func (j *JsonCTL) Playback(jsonData []byte) (err error) {
 		return j.w.br.Controller().Local().QuitPlayback()
 	}
 
-	return fmt.Errorf("unknown action '%d'", conf.Action)
+	return fmt.Errorf("%w '%d'", ErrUnknownAction, conf.Action)
 }
@@ -0,0 +1,47 @@
+package audiobridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime/pprof"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/audio"
+)
+
+// ProfileReport describes where the bridge spent its time while profiling.
+// Stages are the bridge's writers; "CallbackWrapper" is audio analysis.
+type ProfileReport struct {
+	Duration time.Duration        `json:"duration_ns"`
+	Input    string               `json:"input"`
+	Writes   int                  `json:"writes"`
+	Stages   []audio.StageProfile `json:"stages"`
+	// CPUProfile is a pprof CPU profile of the whole process, with samples labelled by stage
+	CPUProfile []byte `json:"cpu_profile,omitempty"`
+}
+
+func (pr *ProfileReport) AsJSON() ([]byte, error) {
+	return json.Marshal(pr)
+}
+
+// Profile runs the current pipeline for d, measuring each output stage.
+// It blocks for d and fails if another CPU profile is already running.
+func (c *Controller) Profile(d time.Duration) (*ProfileReport, error) {
+	var cpu bytes.Buffer
+	if err := pprof.StartCPUProfile(&cpu); err != nil {
+		return nil, fmt.Errorf("error starting CPU profile: %w", err)
+	}
+	c.br.byteWriter.StartProfile()
+	time.Sleep(d)
+	writes, stages := c.br.byteWriter.StopProfile()
+	pprof.StopCPUProfile()
+
+	return &ProfileReport{
+		Duration:   d,
+		Input:      c.InputType(),
+		Writes:     writes,
+		Stages:     stages,
+		CPUProfile: cpu.Bytes(),
+	}, nil
+}
@@ -0,0 +1,73 @@
+package audiobridge
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/LedFx/ledfx/pkg/logger"
+)
+
+// Shutdown stops the input, flushes and closes every output and waits for the bridge's
+// goroutines to finish. It returns once that is done, or with an error when ctx is done first.
+// Shutdown may be called more than once and from a signal handler; later calls wait on the
+// same shutdown rather than starting another.
+func (c *Controller) Shutdown(ctx context.Context) error {
+	c.br.shutdownOnce.Do(func() {
+		go c.br.shutdown()
+	})
+	select {
+	case <-c.br.shutdownDone:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("error shutting down audio bridge: %w", ctx.Err())
+	}
+}
+
+func (br *Bridge) shutdown() {
+	defer close(br.shutdownDone)
+	defer func() {
+		go func() {
+			br.done <- true
+		}()
+	}()
+	// before anything stops, so sender callbacks from the stopping server don't switch
+	// sources, or fade back in, on a bridge being torn down
+	br.disableAutoSwitch()
+	br.ctl.StopTestTone()
+	br.fadeOut()
+
+	// inputs first, so nothing new reaches the outputs
+	if br.youtube != nil && br.youtube.handler != nil && !br.youtube.handler.Stopped() {
+		log.Logger.WithField("context", "Audio Bridge").Warnf("Stopping YouTube input...")
+		br.youtube.handler.Quit()
+	}
+	if br.local != nil && br.local.capture != nil && !br.local.capture.Stopped() {
+		log.Logger.WithField("context", "Audio Bridge").Warnf("Stopping capture input...")
+		br.local.capture.Quit()
+	}
+	if br.local != nil && br.local.mixer != nil {
+		log.Logger.WithField("context", "Audio Bridge").Warnf("Stopping mixed capture input...")
+		br.local.quitMixer()
+	}
+	if br.airplay != nil && br.airplay.server != nil && !br.airplay.server.Stopped() {
+		log.Logger.WithField("context", "Audio Bridge").Warnf("Stopping AirPlay server...")
+		br.airplay.server.Stop()
+		if !br.airplay.server.WaitTimeout(airPlayRestartTimeout) {
+			log.Logger.WithField("context", "Audio Bridge").Warnf("AirPlay server didn't stop within %s", airPlayRestartTimeout)
+		}
+	}
+
+	// waits for in-flight writes to finish before the outputs are closed
+	br.byteWriter.RemoveAll()
+
+	if br.airplay != nil {
+		log.Logger.WithField("context", "Audio Bridge").Warnf("Closing AirPlay outputs...")
+		br.airplay.Stop()
+	}
+	if br.local != nil && br.local.playback != nil {
+		log.Logger.WithField("context", "Audio Bridge").Warnf("Closing local output...")
+		br.local.playback.Quit()
+	}
+	br.closePipes()
+	log.Logger.WithField("context", "Audio Bridge").Infof("Audio bridge shut down")
+}
@@ -0,0 +1,150 @@
+package synth
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/audio"
+	log "github.com/LedFx/ledfx/pkg/logger"
+)
+
+type Waveform string
+
+const (
+	Sine   Waveform = "sine"
+	Square Waveform = "square"
+	Noise  Waveform = "noise"
+)
+
+type Config struct {
+	Waveform  Waveform `json:"waveform"`
+	Frequency float64  `json:"frequency"` // Hz, ignored for noise
+	Amplitude float64  `json:"amplitude"` // 0-1
+	Seed      int64    `json:"seed"`      // noise seed, so runs are reproducible
+}
+
+// Handler generates a deterministic mono stream and writes it to byteWriter in
+// audio.BufferSize frames at audio.SampleRate, like a capture device would.
+type Handler struct {
+	byteWriter io.Writer
+
+	mu        sync.Mutex
+	waveform  Waveform
+	frequency float64
+	amplitude float64
+	phase     float64 // 0-1, carried across buffers so the tone is continuous
+	rng       *rand.Rand
+
+	quit    chan struct{}
+	stopped bool
+}
+
+// New returns a generator which is not yet writing anywhere. Call Next to pull buffers directly.
+func New(conf Config) (h *Handler, err error) {
+	switch conf.Waveform {
+	case Sine, Square, Noise:
+	default:
+		return nil, fmt.Errorf("unknown waveform '%s'", conf.Waveform)
+	}
+	h = &Handler{
+		waveform: conf.Waveform,
+		rng:      rand.New(rand.NewSource(conf.Seed)),
+		quit:     make(chan struct{}),
+	}
+	if conf.Waveform != Noise {
+		if err = h.SetFrequency(conf.Frequency); err != nil {
+			return nil, err
+		}
+	}
+	if err = h.SetAmplitude(conf.Amplitude); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// NewHandler starts writing the generated stream to byteWriter in real time
+func NewHandler(conf Config, byteWriter io.Writer) (h *Handler, err error) {
+	if h, err = New(conf); err != nil {
+		return nil, err
+	}
+	h.byteWriter = byteWriter
+	log.Logger.WithField("context", "Synth Handler").Infof("Generating %s stream", conf.Waveform)
+	go h.loop()
+	return h, nil
+}
+
+func (h *Handler) loop() {
+	ticker := time.NewTicker(time.Duration(audio.BufferSize) * time.Second / time.Duration(audio.SampleRate))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.quit:
+			return
+		case <-ticker.C:
+			h.byteWriter.Write(h.Next().AsBytes())
+		}
+	}
+}
+
+// Next generates the following audio.BufferSize samples of the stream
+func (h *Handler) Next() audio.Buffer {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	buf := make(audio.Buffer, audio.BufferSize)
+	step := h.frequency / float64(audio.SampleRate)
+	scale := h.amplitude * math.MaxInt16
+	for i := range buf {
+		var v float64
+		switch h.waveform {
+		case Sine:
+			v = math.Sin(2 * math.Pi * h.phase)
+		case Square:
+			v = 1
+			if h.phase >= 0.5 {
+				v = -1
+			}
+		case Noise:
+			v = h.rng.Float64()*2 - 1
+		}
+		buf[i] = int16(v * scale)
+		h.phase = math.Mod(h.phase+step, 1)
+	}
+	return buf
+}
+
+func (h *Handler) SetFrequency(hz float64) error {
+	if hz <= 0 || hz > float64(audio.SampleRate)/2 {
+		return fmt.Errorf("frequency %f Hz must be between 0 and %d Hz", hz, audio.SampleRate/2)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.frequency = hz
+	return nil
+}
+
+func (h *Handler) SetAmplitude(amplitude float64) error {
+	if amplitude < 0 || amplitude > 1 {
+		return fmt.Errorf("amplitude %f must be between 0 and 1", amplitude)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.amplitude = amplitude
+	return nil
+}
+
+func (h *Handler) Quit() {
+	if h.stopped {
+		return
+	}
+	h.stopped = true
+	close(h.quit)
+	log.Logger.WithField("context", "Synth Handler").Info("Stopped synth stream")
+}
+
+func (h *Handler) Stopped() bool {
+	return h.stopped
+}
@@ -0,0 +1,56 @@
+package synth
+
+import (
+	"testing"
+)
+
+func TestSine(t *testing.T) {
+	// 441 Hz at 44.1 kHz is exactly 100 samples per period
+	h, err := New(Config{Waveform: Sine, Frequency: 441, Amplitude: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := h.Next()
+	if buf[0] != 0 || buf[25] != 16383 || buf[75] != -16383 {
+		t.Errorf("Expected: 0, 16383, -16383\r\n Got: %d, %d, %d", buf[0], buf[25], buf[75])
+	}
+	// the phase carries across buffers
+	next := h.Next()
+	if next[0] != buf[len(buf)%100] {
+		t.Errorf("Expected: %d\r\n Got: %d", buf[len(buf)%100], next[0])
+	}
+}
+
+func TestSquare(t *testing.T) {
+	h, err := New(Config{Waveform: Square, Frequency: 441, Amplitude: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := h.Next()
+	if buf[10] != 32767 || buf[60] != -32767 {
+		t.Errorf("Expected: 32767, -32767\r\n Got: %d, %d", buf[10], buf[60])
+	}
+}
+
+func TestNoiseSeeded(t *testing.T) {
+	a, _ := New(Config{Waveform: Noise, Amplitude: 1, Seed: 7})
+	b, _ := New(Config{Waveform: Noise, Amplitude: 1, Seed: 7})
+	bufA, bufB := a.Next(), b.Next()
+	for i := range bufA {
+		if bufA[i] != bufB[i] {
+			t.Fatalf("Expected: identical streams for the same seed\r\n Got: %d != %d at %d", bufA[i], bufB[i], i)
+		}
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	if _, err := New(Config{Waveform: "triangle", Frequency: 440, Amplitude: 1}); err == nil {
+		t.Error("Expected: error for unknown waveform")
+	}
+	if _, err := New(Config{Waveform: Sine, Frequency: 30000, Amplitude: 1}); err == nil {
+		t.Error("Expected: error for frequency above nyquist")
+	}
+	if _, err := New(Config{Waveform: Sine, Frequency: 440, Amplitude: 2}); err == nil {
+		t.Error("Expected: error for amplitude above 1")
+	}
+}
@@ -0,0 +1,144 @@
+package audiobridge
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/audio/audiobridge/synth"
+	log "github.com/LedFx/ledfx/pkg/logger"
+)
+
+// MaxTestToneDuration bounds how long a test tone can hold the outputs
+const MaxTestToneDuration = 30 * time.Second
+
+// TestTone plays a synthetic tone to the outputs for d in place of the live input, then
+// reverts to the live input. It returns once the tone has started. The tone drives audio
+// outputs and analysis alike, so it checks effect and LED output wiring as well as routing.
+func (c *Controller) TestTone(conf synth.Config, d time.Duration) error {
+	if d <= 0 || d > MaxTestToneDuration {
+		return fmt.Errorf("%w 'duration': %s must be between 0 and %s", ErrInvalidField, d, MaxTestToneDuration)
+	}
+	if _, err := synth.New(conf); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidField, err)
+	}
+
+	br := c.br
+	br.toneMu.Lock()
+	defer br.toneMu.Unlock()
+	if br.toneStop != nil {
+		return ErrTestToneActive
+	}
+
+	br.fadeOut()
+	restore := br.holdInput()
+	tone, err := synth.NewHandler(conf, br.chain)
+	if err != nil {
+		restore()
+		br.ramp.FadeIn()
+		return err
+	}
+	br.ramp.FadeIn()
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	br.toneStop, br.toneDone = stop, done
+	log.Logger.WithField("context", "Test Tone").Infof("Playing %s test tone for %s", conf.Waveform, d)
+	go func() {
+		defer close(done)
+		select {
+		case <-time.After(d):
+		case <-stop:
+		}
+		br.fadeOut()
+		tone.Quit()
+		restore()
+		br.ramp.FadeIn()
+
+		br.toneMu.Lock()
+		br.toneStop, br.toneDone = nil, nil
+		br.toneMu.Unlock()
+		log.Logger.WithField("context", "Test Tone").Info("Test tone finished, reverted to the live input")
+	}()
+	return nil
+}
+
+// StopTestTone ends a test tone early, returning once the live input is restored.
+// It does nothing if none is playing.
+func (c *Controller) StopTestTone() {
+	c.br.toneMu.Lock()
+	stop, done := c.br.toneStop, c.br.toneDone
+	if stop == nil {
+		c.br.toneMu.Unlock()
+		return
+	}
+	select {
+	case <-stop:
+		// already stopping
+	default:
+		close(stop)
+	}
+	// the tone's goroutine takes toneMu as it finishes
+	c.br.toneMu.Unlock()
+	<-done
+}
+
+// holdInput silences every live source without closing it, returning a func which resumes
+// those it silenced. Sources already paused or unrouted are left as they are, as are those
+// replaced or stopped while held. With auto switch active the policy is held too, and on
+// restore routes whichever of AirPlay and capture it wants by then.
+func (br *Bridge) holdInput() (restore func()) {
+	as := br.currentAutoSwitch()
+	if as != nil {
+		as.hold()
+	}
+	var resume []func()
+	if br.airplay != nil && br.airplay.server != nil && !br.airplay.server.Stopped() && br.airplay.server.Routed() {
+		server := br.airplay.server
+		server.UnrouteFromOutputs()
+		if as == nil {
+			resume = append(resume, func() {
+				if br.airplay != nil && br.airplay.server == server && !server.Stopped() {
+					server.RouteToOutputs()
+				}
+			})
+		}
+	}
+	if local := br.local; local != nil {
+		if handler := local.capture; handler != nil && !handler.Stopped() && !handler.Paused() {
+			handler.Pause()
+			if as == nil {
+				resume = append(resume, func() {
+					if local.capture == handler && !handler.Stopped() {
+						handler.Resume()
+					}
+				})
+			}
+		}
+		if mixer := local.mixer; mixer != nil && !mixer.Paused() {
+			mixer.Pause()
+			resume = append(resume, func() {
+				if local.mixer == mixer {
+					mixer.Resume()
+				}
+			})
+		}
+	}
+	if yt := br.youtube; yt != nil && yt.handler != nil && !yt.handler.Stopped() {
+		handler := yt.handler
+		if player := handler.Player(); player.IsPlaying() && !player.IsPaused() {
+			player.Pause()
+			resume = append(resume, func() {
+				if yt.handler == handler && !handler.Stopped() {
+					player.Unpause()
+				}
+			})
+		}
+	}
+	return func() {
+		for _, fn := range resume {
+			fn()
+		}
+		if as != nil {
+			br.releaseAutoSwitch(as)
+		}
+	}
+}
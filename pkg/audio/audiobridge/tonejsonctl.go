@@ -0,0 +1,63 @@
+package audiobridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/audio/audiobridge/synth"
+)
+
+type TestToneAction string
+
+const (
+	TestToneActionPlay TestToneAction = "play"
+	TestToneActionStop TestToneAction = "stop"
+)
+
+// TestToneCTLJSON plays a test tone. Unset fields default to a 440 Hz sine at half scale for 5 seconds.
+type TestToneCTLJSON struct {
+	Action     TestToneAction `json:"action"`
+	Waveform   synth.Waveform `json:"waveform,omitempty"`
+	Frequency  float64        `json:"frequency,omitempty"`
+	Amplitude  float64        `json:"amplitude,omitempty"`
+	DurationMs int            `json:"duration_ms,omitempty"`
+}
+
+func (ttctl TestToneCTLJSON) AsJSON() ([]byte, error) {
+	return json.Marshal(&ttctl)
+}
+
+// TestTone takes a marshalled TestToneCTLJSON
+func (j *JsonCTL) TestTone(jsonData []byte) (err error) {
+	conf := TestToneCTLJSON{}
+	if err := unmarshalStrict(jsonData, &conf); err != nil {
+		return err
+	}
+	switch conf.Action {
+	case TestToneActionPlay:
+	case TestToneActionStop:
+		j.w.br.Controller().StopTestTone()
+		return nil
+	default:
+		return fmt.Errorf("%w '%s'", ErrUnknownAction, conf.Action)
+	}
+
+	if conf.Waveform == "" {
+		conf.Waveform = synth.Sine
+	}
+	if conf.Frequency == 0 {
+		conf.Frequency = 440
+	}
+	if conf.Amplitude == 0 {
+		conf.Amplitude = 0.5
+	}
+	if conf.DurationMs == 0 {
+		conf.DurationMs = 5000
+	}
+	return j.w.br.Controller().TestTone(synth.Config{
+		Waveform:  conf.Waveform,
+		Frequency: conf.Frequency,
+		Amplitude: conf.Amplitude,
+	}, time.Duration(conf.DurationMs)*time.Millisecond)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/LedFx/ledfx/pkg/audio"
 	"github.com/LedFx/ledfx/pkg/audio/audiobridge/playback"
 	"github.com/LedFx/ledfx/pkg/integrations/airplay2"
 )
@@ -57,3 +58,9 @@ func (br *Bridge) wireLocalOutput(handler playback.Handler) error {
 func (br *Bridge) AddOutputWriter(wr io.Writer, name string) error {
 	return br.byteWriter.AddWriter(wr, name)
 }
+
+// AddOutputWriterProcessors adds an output which receives the audio in format after its own processors.
+// The other outputs get the audio untouched.
+func (br *Bridge) AddOutputWriterProcessors(wr io.Writer, name string, format audio.Format, processors ...audio.Processor) error {
+	return br.byteWriter.AddWriterProcessors(wr, name, format, processors...)
+}
@@ -17,7 +17,7 @@ func (br *Bridge) StartYoutubeInput() error {
 
 	if br.youtube == nil {
 		br.youtube = &YoutubeHandler{
-			handler: youtube.NewHandler(br.byteWriter),
+			handler: youtube.NewHandler(br.chain),
 		}
 	}
 	return nil
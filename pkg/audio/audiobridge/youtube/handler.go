@@ -12,7 +12,6 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/LedFx/ledfx/pkg/audio"
 	log "github.com/LedFx/ledfx/pkg/logger"
 	"github.com/LedFx/ledfx/pkg/util"
 
@@ -25,7 +24,7 @@ import (
 
 type Handler struct {
 	cl         *yt.Client
-	byteWriter *audio.AsyncMultiWriter
+	byteWriter io.Writer
 	p          *Player
 
 	stopped bool
@@ -47,7 +46,7 @@ func (h *Handler) Player() *Player {
 	return h.p
 }
 
-func NewHandler(byteWriter *audio.AsyncMultiWriter) *Handler {
+func NewHandler(byteWriter io.Writer) *Handler {
 	h := &Handler{
 		cl: &yt.Client{
 			Debug:      false,
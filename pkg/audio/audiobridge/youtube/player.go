@@ -10,7 +10,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/LedFx/ledfx/pkg/audio"
 	log "github.com/LedFx/ledfx/pkg/logger"
 	"github.com/LedFx/ledfx/pkg/tickpool"
 
@@ -43,7 +42,7 @@ type Player struct {
 	playByIndexDone chan struct{}
 
 	in  *FileBuffer
-	out *audio.AsyncMultiWriter
+	out io.Writer
 
 	elapsed *atomic.Duration
 	ticker  *time.Ticker
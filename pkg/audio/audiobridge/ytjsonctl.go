@@ -2,7 +2,6 @@ package audiobridge
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 
 	"github.com/LedFx/ledfx/pkg/audio/audiobridge/youtube"
@@ -70,7 +69,7 @@ func (j *JsonCTL) YouTubeSet(jsonData []byte) (respBytes []byte, err error) {
 	case j.w.br.youtube == nil:
 		fallthrough
 	case j.w.br.youtube.handler == nil:
-		return nil, errors.New("YouTube handler is nil")
+		return nil, fmt.Errorf("YouTube handler %w", ErrNotActive)
 	default:
 		j.curYouTubePlayer = j.w.br.youtube.handler.Player()
 	}
@@ -115,7 +114,7 @@ func (j *JsonCTL) YouTubeSet(jsonData []byte) (respBytes []byte, err error) {
 			return nil, fmt.Errorf("error playing track by name: %w", err)
 		}
 	default:
-		return nil, fmt.Errorf("unknown action '%s'", conf.Action)
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownAction, conf.Action)
 	}
 	return nil, nil
 }
@@ -0,0 +1,195 @@
+package audio
+
+import (
+	"io"
+	"math"
+	"sync"
+)
+
+// Processor transforms a buffer of audio. It may modify in place and return in,
+// but must not keep in after returning, as it may be pooled.
+type Processor interface {
+	Process(in Buffer) Buffer
+}
+
+// ProcessorFunc adapts a function to a Processor
+type ProcessorFunc func(in Buffer) Buffer
+
+func (f ProcessorFunc) Process(in Buffer) Buffer {
+	return f(in)
+}
+
+// bypasser is implemented by processors which can report that they currently leave audio untouched
+type bypasser interface {
+	Bypass() bool
+}
+
+// process runs 16 bit PCM through the processors, returning p itself if they would all leave it untouched
+func process(p []byte, processors []Processor) []byte {
+	bypass := true
+	for _, proc := range processors {
+		if b, ok := proc.(bypasser); !ok || !b.Bypass() {
+			bypass = false
+			break
+		}
+	}
+	if bypass {
+		return p
+	}
+	// the bytes are handed to writers which may keep them, so only the samples are pooled
+	pooled := Buffers.FromBytes(p)
+	defer Buffers.Put(pooled)
+	buf := pooled
+	for _, proc := range processors {
+		buf = proc.Process(buf)
+	}
+	return buf.AsBytes()
+}
+
+// Chain runs audio through an ordered list of processors and writes the result to an AsyncMultiWriter.
+// It is an io.Writer, so it can sit between a source and the outputs.
+type Chain struct {
+	mu         sync.RWMutex
+	processors []Processor
+	out        *AsyncMultiWriter
+}
+
+func NewChain(out *AsyncMultiWriter, processors ...Processor) *Chain {
+	return &Chain{
+		processors: processors,
+		out:        out,
+	}
+}
+
+// SetProcessors replaces the processors. It is safe to call while audio is being written.
+func (c *Chain) SetProcessors(processors ...Processor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.processors = processors
+}
+
+// Append adds a processor to the end of the chain
+func (c *Chain) Append(p Processor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.processors = append(c.processors, p)
+}
+
+// Processors returns a copy of the current processors
+func (c *Chain) Processors() []Processor {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Processor(nil), c.processors...)
+}
+
+// Write processes 16 bit PCM and writes it to the outputs
+func (c *Chain) Write(p []byte) (int, error) {
+	c.mu.RLock()
+	out := process(p, c.processors)
+	c.mu.RUnlock()
+	if _, err := c.out.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// AddWriterProcessors adds a writer whose branch runs through processors, leaving the audio every
+// other writer receives untouched. The processors see 16 bit PCM, converted to format afterwards.
+func (bw *AsyncMultiWriter) AddWriterProcessors(writer io.Writer, name string, format Format, processors ...Processor) error {
+	if !format.valid() {
+		return ErrUnknownFormat
+	}
+	return bw.AddWriter(&branch{
+		out:        writer,
+		format:     format,
+		processors: processors,
+	}, name)
+}
+
+// branch is a writer with its own processors, for one output of an AsyncMultiWriter
+type branch struct {
+	out        io.Writer
+	format     Format
+	processors []Processor
+}
+
+func (b *branch) Write(p []byte) (int, error) {
+	// process copies before transforming, so the shared write isn't modified
+	out := process(p, b.processors)
+	if b.format != FormatInt16 {
+		out = convert(out, b.format)
+	}
+	if _, err := b.out.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Gain scales every sample, clipping at the int16 limits
+type Gain float64
+
+func (g Gain) Process(in Buffer) Buffer {
+	for i, s := range in {
+		in[i] = Clip16(float64(s) * float64(g))
+	}
+	return in
+}
+
+// Biquad is a second order IIR filter in direct form I, with coefficients normalised so a0 is 1
+type Biquad struct {
+	B0, B1, B2, A1, A2 float64
+
+	x1, x2, y1, y2 float64
+}
+
+// NewLowPass returns a Butterworth low pass biquad with the given cutoff in Hz
+func NewLowPass(cutoff float64) *Biquad {
+	w := 2 * math.Pi * cutoff / float64(SampleRate)
+	alpha := math.Sin(w) / math.Sqrt2
+	cos := math.Cos(w)
+	a0 := 1 + alpha
+	return &Biquad{
+		B0: (1 - cos) / 2 / a0,
+		B1: (1 - cos) / a0,
+		B2: (1 - cos) / 2 / a0,
+		A1: -2 * cos / a0,
+		A2: (1 - alpha) / a0,
+	}
+}
+
+// NewHighPass returns a Butterworth high pass biquad with the given cutoff in Hz
+func NewHighPass(cutoff float64) *Biquad {
+	w := 2 * math.Pi * cutoff / float64(SampleRate)
+	alpha := math.Sin(w) / math.Sqrt2
+	cos := math.Cos(w)
+	a0 := 1 + alpha
+	return &Biquad{
+		B0: (1 + cos) / 2 / a0,
+		B1: -(1 + cos) / a0,
+		B2: (1 + cos) / 2 / a0,
+		A1: -2 * cos / a0,
+		A2: (1 - alpha) / a0,
+	}
+}
+
+func (f *Biquad) Process(in Buffer) Buffer {
+	for i, s := range in {
+		x := float64(s)
+		y := f.B0*x + f.B1*f.x1 + f.B2*f.x2 - f.A1*f.y1 - f.A2*f.y2
+		f.x2, f.x1 = f.x1, x
+		f.y2, f.y1 = f.y1, y
+		in[i] = Clip16(y)
+	}
+	return in
+}
+
+// Clip16 rounds v to the nearest int16, clipping at the limits
+func Clip16(v float64) int16 {
+	switch {
+	case v > math.MaxInt16:
+		return math.MaxInt16
+	case v < math.MinInt16:
+		return math.MinInt16
+	}
+	return int16(math.Round(v))
+}
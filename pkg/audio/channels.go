@@ -0,0 +1,106 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+)
+
+// Interleave combines planar channels into one interleaved buffer. Every channel must be the same length.
+func Interleave(channels ...Buffer) (Buffer, error) {
+	return InterleaveInto(nil, channels...)
+}
+
+// InterleaveInto is Interleave writing into dst, which is reused if it has the capacity
+func InterleaveInto(dst Buffer, channels ...Buffer) (Buffer, error) {
+	if len(channels) == 0 {
+		return dst[:0], nil
+	}
+	frames := len(channels[0])
+	for i, ch := range channels {
+		if len(ch) != frames {
+			return nil, fmt.Errorf("channel %d has %d samples, expected %d", i, len(ch), frames)
+		}
+	}
+	n := len(channels)
+	dst = resize(dst, frames*n)
+	for c, ch := range channels {
+		for i, s := range ch {
+			dst[i*n+c] = s
+		}
+	}
+	return dst, nil
+}
+
+// Deinterleave splits an interleaved buffer into n planar channels
+func Deinterleave(b Buffer, n int) ([]Buffer, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("channel count %d must be at least 1", n)
+	}
+	dst := make([]Buffer, n)
+	if err := DeinterleaveInto(dst, b); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// DeinterleaveInto splits b into len(dst) planar channels, reusing each dst buffer if it has the capacity
+func DeinterleaveInto(dst []Buffer, b Buffer) error {
+	n := len(dst)
+	if n < 1 {
+		return fmt.Errorf("channel count %d must be at least 1", n)
+	}
+	if len(b)%n != 0 {
+		return fmt.Errorf("buffer of %d samples doesn't divide into %d channels", len(b), n)
+	}
+	frames := len(b) / n
+	for c := range dst {
+		dst[c] = resize(dst[c], frames)
+		for i := range dst[c] {
+			dst[c][i] = b[i*n+c]
+		}
+	}
+	return nil
+}
+
+// returns b with length n, only allocating if its capacity is too small
+func resize(b Buffer, n int) Buffer {
+	if cap(b) < n {
+		return make(Buffer, n)
+	}
+	return b[:n]
+}
+
+// RMS returns the root mean square of the buffer, normalised to 0-1.
+// Interleaved channels are mixed together, see RMSChannels to meter them separately.
+func RMS(b Buffer) float64 {
+	if len(b) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, s := range b {
+		sum += float64(s) * float64(s)
+	}
+	return math.Min(math.Sqrt(sum/float64(len(b)))/float64(rawMax), 1)
+}
+
+// RMSChannels returns the RMS of each channel of an interleaved buffer, normalised to 0-1, in channel order
+func RMSChannels(b Buffer, n int) ([]float64, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("channel count %d must be at least 1", n)
+	}
+	if len(b)%n != 0 {
+		return nil, fmt.Errorf("buffer of %d samples doesn't divide into %d channels", len(b), n)
+	}
+	out := make([]float64, n)
+	frames := len(b) / n
+	if frames == 0 {
+		return out, nil
+	}
+	for i, s := range b {
+		out[i%n] += float64(s) * float64(s)
+	}
+	for c := range out {
+		out[c] = math.Min(math.Sqrt(out[c]/float64(frames))/float64(rawMax), 1)
+	}
+	return out, nil
+}
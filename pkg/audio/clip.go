@@ -0,0 +1,102 @@
+package audio
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultClipWindow is how often the clip rate is worked out
+	DefaultClipWindow = time.Second
+	// DefaultClipThreshold is the fraction of clipped samples in a window that counts as clipping.
+	// At 44.1kHz it is 44 samples a second, more than the odd isolated peak.
+	DefaultClipThreshold = 0.001
+)
+
+// ClipStats is a snapshot of a ClipDetector
+type ClipStats struct {
+	Total uint64  `json:"total"` // clipped samples since the detector was created
+	Rate  float64 `json:"rate"`  // fraction of samples clipped in the last complete window
+}
+
+// ClipDetector counts samples hitting full scale, which means the input gain is too high.
+// The clip rate is measured over windows of time, and a callback runs for each window
+// where it exceeds a threshold.
+type ClipDetector struct {
+	mu        sync.Mutex
+	window    time.Duration
+	threshold float64
+	onClip    func(stats ClipStats)
+
+	total      uint64
+	rate       float64
+	winStart   time.Time
+	winSamples int
+	winClipped int
+
+	now func() time.Time
+}
+
+// NewClipDetector measures the clip rate every window, counting a rate above threshold as clipping
+func NewClipDetector(window time.Duration, threshold float64) *ClipDetector {
+	c := &ClipDetector{
+		window:    window,
+		threshold: threshold,
+		now:       time.Now,
+	}
+	c.winStart = c.now()
+	return c
+}
+
+// OnClip sets a callback run, outside the detector's lock, after each window clipping above the threshold
+func (c *ClipDetector) OnClip(fn func(stats ClipStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onClip = fn
+}
+
+// Update counts the samples in b at ±32767 or beyond, returning how many there were
+func (c *ClipDetector) Update(b *Buffer) (clippedSamples int) {
+	for _, s := range *b {
+		if s >= math.MaxInt16 || s <= -math.MaxInt16 {
+			clippedSamples++
+		}
+	}
+
+	c.mu.Lock()
+	c.total += uint64(clippedSamples)
+	c.winSamples += len(*b)
+	c.winClipped += clippedSamples
+	now := c.now()
+	if now.Sub(c.winStart) < c.window {
+		c.mu.Unlock()
+		return clippedSamples
+	}
+	if c.winSamples > 0 {
+		c.rate = float64(c.winClipped) / float64(c.winSamples)
+	}
+	c.winStart, c.winSamples, c.winClipped = now, 0, 0
+	stats := ClipStats{Total: c.total, Rate: c.rate}
+	onClip := c.onClip
+	c.mu.Unlock()
+
+	if onClip != nil && stats.Rate > c.threshold {
+		onClip(stats)
+	}
+	return clippedSamples
+}
+
+// Process counts clipped samples as a Processor, leaving the audio untouched.
+// Placed first in a Chain it sees the input before any gain is applied.
+func (c *ClipDetector) Process(in Buffer) Buffer {
+	c.Update(&in)
+	return in
+}
+
+// Stats returns the total clipped samples and the latest clip rate
+func (c *ClipDetector) Stats() ClipStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return ClipStats{Total: c.total, Rate: c.rate}
+}
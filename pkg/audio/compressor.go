@@ -0,0 +1,133 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// CompressorParams configures a Compressor. Levels are in dB relative to full scale.
+type CompressorParams struct {
+	Threshold float64       // level above which gain is reduced, such as -12
+	Ratio     float64       // input to output ratio above the threshold. math.Inf(1) hard limits.
+	Attack    time.Duration // time for the envelope to rise to a louder level
+	Release   time.Duration // time for the envelope to fall back after a transient
+	Makeup    float64       // gain applied after compression, in dB
+
+	// SoftClip rounds over output pushed past full scale with a tanh curve, as SoftClip does,
+	// instead of clipping it. Drive is extra gain into that curve, in dB, and only applies with SoftClip.
+	SoftClip bool
+	Drive    float64
+}
+
+// Compressor is a feed-forward dynamic range compressor. An envelope follows the level of
+// the input, and gain is reduced wherever the envelope is above the threshold. The envelope
+// carries across buffers, and parameters can be changed while audio is flowing.
+type Compressor struct {
+	mu       sync.Mutex
+	params   CompressorParams
+	attack   float64 // per sample envelope coefficients
+	release  float64
+	makeup   float64 // linear makeup gain, including drive when soft clipping
+	slope    float64 // fraction of the overshoot removed, 1-1/ratio
+	env      float64 // envelope, linear 0-1
+	lastGain float64 // gain reduction applied to the last sample, in dB
+}
+
+func NewCompressor(params CompressorParams) (*Compressor, error) {
+	c := &Compressor{}
+	if err := c.SetParams(params); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewLimiter returns a compressor with an infinite ratio and instant attack,
+// so the output never goes above threshold
+func NewLimiter(threshold float64, release time.Duration) *Compressor {
+	c, _ := NewCompressor(CompressorParams{
+		Threshold: threshold,
+		Ratio:     math.Inf(1),
+		Release:   release,
+	})
+	return c
+}
+
+// SetParams changes the parameters. The envelope is kept, so there's no jump in gain.
+func (c *Compressor) SetParams(p CompressorParams) error {
+	if p.Ratio < 1 || math.IsNaN(p.Ratio) {
+		return fmt.Errorf("compressor ratio %f must be at least 1", p.Ratio)
+	}
+	if p.Attack < 0 || p.Release < 0 {
+		return fmt.Errorf("compressor attack and release must not be negative")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.params = p
+	c.attack = envelopeCoef(p.Attack)
+	c.release = envelopeCoef(p.Release)
+	c.makeup = dbToLinear(p.Makeup)
+	if p.SoftClip {
+		c.makeup *= dbToLinear(p.Drive)
+	}
+	c.slope = 1 - 1/p.Ratio
+	return nil
+}
+
+func (c *Compressor) Params() CompressorParams {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.params
+}
+
+// GainReduction returns the gain reduction applied to the last sample, in dB. It is 0 or negative.
+func (c *Compressor) GainReduction() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastGain
+}
+
+func (c *Compressor) Process(in Buffer) Buffer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, s := range in {
+		level := math.Abs(float64(s)) / float64(rawMax)
+		coef := c.release
+		if level > c.env {
+			coef = c.attack
+		}
+		c.env = coef*c.env + (1-coef)*level
+
+		gain := 0.0
+		if c.env > 0 {
+			if over := linearToDB(c.env) - c.params.Threshold; over > 0 {
+				gain = -over * c.slope
+			}
+		}
+		c.lastGain = gain
+		out := float64(s) * dbToLinear(gain) * c.makeup
+		if c.params.SoftClip {
+			in[i] = softClip(out)
+		} else {
+			in[i] = Clip16(out)
+		}
+	}
+	return in
+}
+
+// envelopeCoef is the one pole coefficient reaching about 63% of a step in d. 0 is instant.
+func envelopeCoef(d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return math.Exp(-1 / (d.Seconds() * float64(SampleRate)))
+}
+
+func dbToLinear(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+func linearToDB(v float64) float64 {
+	return 20 * math.Log10(v)
+}
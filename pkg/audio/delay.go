@@ -0,0 +1,123 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// MaxDelay is the longest delay a DelayLine can be set to, in milliseconds
+const MaxDelay = 10000
+
+// DelayLine delays audio by a set time, so one output can be held back to line up with
+// another which buffers more, such as LEDs driven alongside a Bluetooth speaker.
+// Delays which aren't a whole number of samples are linearly interpolated.
+// The delay can be changed while audio is flowing; the output jumps to the new position.
+type DelayLine struct {
+	mu         sync.Mutex
+	sampleRate float64
+	channels   int
+	ms         float64
+	frames     float64 // delay in frames, ms converted at sampleRate
+	ring       Buffer  // interleaved frames of history, the newest at pos
+	pos        int     // frame written most recently
+}
+
+// NewDelayLine returns a mono delay line of ms milliseconds at sampleRate
+func NewDelayLine(ms, sampleRate float64) (*DelayLine, error) {
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("sample rate %f must be positive", sampleRate)
+	}
+	d := &DelayLine{
+		sampleRate: sampleRate,
+		channels:   1,
+	}
+	if err := d.SetDelay(ms); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// SetDelay changes the delay, between 0 and MaxDelay milliseconds
+func (d *DelayLine) SetDelay(ms float64) error {
+	if ms < 0 || ms > MaxDelay || math.IsNaN(ms) {
+		return fmt.Errorf("delay %fms must be between 0 and %dms", ms, MaxDelay)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.ms == 0 {
+		// history isn't kept while bypassed, so don't play back what's left from before
+		d.ring = nil
+	}
+	d.ms = ms
+	d.frames = ms * d.sampleRate / 1000
+	d.grow()
+	return nil
+}
+
+// Delay returns the delay in milliseconds
+func (d *DelayLine) Delay() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.ms
+}
+
+// SetChannels sets the number of interleaved channels in the buffers processed. The history is cleared.
+func (d *DelayLine) SetChannels(n int) error {
+	if n < 1 {
+		return fmt.Errorf("channel count %d must be at least 1", n)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.channels = n
+	d.ring = nil
+	d.grow()
+	return nil
+}
+
+// Bypass reports whether the delay is 0, leaving audio untouched
+func (d *DelayLine) Bypass() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.ms == 0
+}
+
+// Process replaces each sample with the one from the delay ago, starting from silence
+func (d *DelayLine) Process(in Buffer) Buffer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	whole := int(d.frames)
+	frac := d.frames - float64(whole)
+	size := len(d.ring) / d.channels
+	for i := 0; i+d.channels <= len(in); i += d.channels {
+		d.pos = (d.pos + 1) % size
+		copy(d.ring[d.pos*d.channels:], in[i:i+d.channels])
+		a := (d.pos - whole + size) % size
+		b := (a - 1 + size) % size
+		for c := 0; c < d.channels; c++ {
+			x0 := float64(d.ring[a*d.channels+c])
+			x1 := float64(d.ring[b*d.channels+c])
+			in[i+c] = Clip16(x0 + (x1-x0)*frac)
+		}
+	}
+	return in
+}
+
+// grow makes sure the ring holds enough history for the delay, keeping the frames already in it
+func (d *DelayLine) grow() {
+	// the current frame and the two either side of the delay
+	size := int(d.frames) + 2
+	old := len(d.ring) / d.channels
+	if size <= old {
+		return
+	}
+	ring := make(Buffer, size*d.channels)
+	// oldest first, so the newest frame ends up at the end of the new ring
+	for i := 0; i < old; i++ {
+		src := (d.pos + 1 + i) % old
+		dst := size - old + i
+		copy(ring[dst*d.channels:(dst+1)*d.channels], d.ring[src*d.channels:(src+1)*d.channels])
+	}
+	d.ring = ring
+	d.pos = size - 1
+}
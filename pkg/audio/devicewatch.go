@@ -0,0 +1,155 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/config"
+	log "github.com/LedFx/ledfx/pkg/logger"
+
+	"github.com/LedFx/portaudio"
+)
+
+type DeviceChangeType string
+
+const (
+	DeviceAdded   DeviceChangeType = "added"
+	DeviceRemoved DeviceChangeType = "removed"
+)
+
+// DeviceChange is an audio device appearing or disappearing
+type DeviceChange struct {
+	Type   DeviceChangeType   `json:"type"`
+	Device config.AudioDevice `json:"device"`
+}
+
+// DeviceWatchInterval is how often WatchDevices polls the device list
+var DeviceWatchInterval = 2 * time.Second
+
+// RefreshDevices returns the current device list. PortAudio only enumerates devices when it
+// is initialised, so when no stream holds it, it is terminated and initialised again first.
+// Terminating would close any open stream, so while one is open the list is the one PortAudio
+// last enumerated, and it is refreshed once every stream has closed.
+func RefreshDevices() ([]config.AudioDevice, error) {
+	paMu.Lock()
+	defer paMu.Unlock()
+	if paRefs > 0 {
+		return GetAudioDevices()
+	}
+	if err := portaudio.Terminate(); err != nil {
+		return nil, fmt.Errorf("error terminating PortAudio: %w", err)
+	}
+	if err := portaudio.Initialize(); err != nil {
+		return nil, fmt.Errorf("error initializing PortAudio: %w", err)
+	}
+	clearSampleRates()
+	return GetAudioDevices()
+}
+
+// one poller serves every WatchDevices caller. It runs while anyone is watching.
+var watcher struct {
+	mu     sync.Mutex
+	subs   map[chan DeviceChange]bool
+	cancel context.CancelFunc
+}
+
+// WatchDevices reports audio devices being added and removed until ctx is done, then closes the channel.
+// Devices present when it starts are not reported. Changes are dropped for a watcher which falls
+// more than a few behind, rather than holding up the others.
+func WatchDevices(ctx context.Context) <-chan DeviceChange {
+	ch := make(chan DeviceChange, 16)
+	watcher.mu.Lock()
+	if watcher.subs == nil {
+		watcher.subs = map[chan DeviceChange]bool{}
+	}
+	watcher.subs[ch] = true
+	if watcher.cancel == nil {
+		var pollCtx context.Context
+		pollCtx, watcher.cancel = context.WithCancel(context.Background())
+		go pollDevices(pollCtx)
+	}
+	watcher.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		watcher.mu.Lock()
+		defer watcher.mu.Unlock()
+		delete(watcher.subs, ch)
+		close(ch)
+		if len(watcher.subs) == 0 {
+			watcher.cancel()
+			watcher.cancel = nil
+		}
+	}()
+	return ch
+}
+
+// pollDevices lists devices every DeviceWatchInterval until ctx is done, sending changes to every watcher
+func pollDevices(ctx context.Context) {
+	known, err := GetAudioDevices()
+	if err != nil {
+		log.Logger.WithField("context", "Device Watcher").Warnf("Error listing audio devices: %v", err)
+	}
+	ticker := time.NewTicker(DeviceWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		devices, err := RefreshDevices()
+		if err != nil {
+			log.Logger.WithField("context", "Device Watcher").Warnf("Error refreshing audio devices: %v", err)
+			continue
+		}
+		changes := diffDevices(known, devices)
+		known = devices
+		if len(changes) == 0 {
+			continue
+		}
+		forgetSampleRates(changes)
+		watcher.mu.Lock()
+		if ctx.Err() != nil {
+			// the last watcher left, and a new poller may already be serving its replacements
+			watcher.mu.Unlock()
+			return
+		}
+		for _, change := range changes {
+			log.Logger.WithField("context", "Device Watcher").Infof("Audio device %s: '%s'", change.Type, change.Device.Name)
+			for ch := range watcher.subs {
+				select {
+				case ch <- change:
+				default:
+					log.Logger.WithField("context", "Device Watcher").Warn("Dropped device change for a slow watcher")
+				}
+			}
+		}
+		watcher.mu.Unlock()
+	}
+}
+
+// diffDevices returns the devices removed from before, then those added in after, matched by ID
+func diffDevices(before, after []config.AudioDevice) (changes []DeviceChange) {
+	ids := func(devices []config.AudioDevice) map[string]bool {
+		m := make(map[string]bool, len(devices))
+		for _, d := range devices {
+			m[d.Id] = true
+		}
+		return m
+	}
+	was, is := ids(before), ids(after)
+	for _, d := range before {
+		if !is[d.Id] {
+			changes = append(changes, DeviceChange{Type: DeviceRemoved, Device: d})
+		}
+	}
+	for _, d := range after {
+		if !was[d.Id] {
+			changes = append(changes, DeviceChange{Type: DeviceAdded, Device: d})
+		}
+	}
+	return changes
+}
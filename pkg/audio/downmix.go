@@ -0,0 +1,82 @@
+package audio
+
+import "math"
+
+// DownmixMode selects how DownmixStereo combines the two channels. The modes differ in how
+// level carries over to mono, which matters to effects keyed off level:
+//
+//	                     correlated   uncorrelated   hard-panned
+//	DownmixAverage         0dB           -3dB           -6dB
+//	DownmixPanLaw         +3dB            0dB           -3dB
+//	DownmixPeak            0dB        up to +3dB         0dB
+//
+// Correlated is the same signal in both channels, such as a centred vocal.
+type DownmixMode int
+
+const (
+	// DownmixAverage is (L+R)/2. It never clips, but quietens anything not centred.
+	DownmixAverage DownmixMode = iota
+	// DownmixPanLaw is (L+R)/√2, the -3dB pan law. It keeps the energy of uncorrelated
+	// channels, but boosts centred content by 3dB, so loud centred audio is clamped.
+	DownmixPanLaw
+	// DownmixPeak is L+R limited to the louder channel's level. Hard-panned and centred content
+	// both keep their peak level, and the result never exceeds either input.
+	DownmixPeak
+)
+
+func (m DownmixMode) String() string {
+	switch m {
+	case DownmixAverage:
+		return "average"
+	case DownmixPanLaw:
+		return "pan_law"
+	case DownmixPeak:
+		return "peak"
+	}
+	return "unknown"
+}
+
+// DownmixStereo combines each pair of interleaved stereo samples into one mono sample.
+// The mode defaults to DownmixAverage.
+func DownmixStereo(b Buffer, mode ...DownmixMode) Buffer {
+	m := DownmixAverage
+	if len(mode) > 0 {
+		m = mode[0]
+	}
+	mono := make(Buffer, len(b)/2)
+	for i := range mono {
+		l, r := int32(b[2*i]), int32(b[2*i+1])
+		switch m {
+		case DownmixPanLaw:
+			mono[i] = Clip16(float64(l+r) / math.Sqrt2)
+		case DownmixPeak:
+			mono[i] = int16(peakSum(l, r))
+		default:
+			mono[i] = int16((l + r) / 2)
+		}
+	}
+	return mono
+}
+
+// returns l+r with its magnitude limited to that of the louder of l and r
+func peakSum(l, r int32) int32 {
+	peak := abs32(l)
+	if abs32(r) > peak {
+		peak = abs32(r)
+	}
+	sum := l + r
+	switch {
+	case sum > peak:
+		return peak
+	case sum < -peak:
+		return -peak
+	}
+	return sum
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,101 @@
+package audio
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+const (
+	// smoothing of the queued level, so single late writes don't trigger corrections
+	driftFillAlpha = 0.05
+	// drift is measured over windows of this length
+	driftWindow = 10 * time.Second
+)
+
+// DriftCorrector keeps the latency between a source and an output bounded when their clocks differ.
+// Each buffer is passed through Correct with the number of frames queued at the output. While the
+// smoothed queue is above target+tolerance one frame is dropped per buffer, and below target-tolerance
+// the last frame is duplicated.
+type DriftCorrector struct {
+	mu        sync.Mutex
+	channels  int
+	rate      float64
+	target    float64
+	tolerance float64
+
+	fill        float64 // smoothed frames queued
+	windowStart time.Time
+	frames      int64 // frames received this window
+	corrections int64
+	ppm         *atomic.Float64
+
+	now func() time.Time
+}
+
+// NewDriftCorrector creates a corrector for interleaved audio with the given channel count and
+// nominal sample rate, holding the output queue within tolerance frames of target
+func NewDriftCorrector(channels int, rate float64, target, tolerance int) *DriftCorrector {
+	return &DriftCorrector{
+		channels:  channels,
+		rate:      rate,
+		target:    float64(target),
+		tolerance: float64(tolerance),
+		fill:      float64(target),
+		ppm:       atomic.NewFloat64(0),
+		now:       time.Now,
+	}
+}
+
+// Correct returns buf with at most one frame dropped or duplicated. queued is the number of frames
+// waiting at the output before buf is written.
+func (d *DriftCorrector) Correct(buf Buffer, queued int) Buffer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.measure(len(buf) / d.channels)
+
+	d.fill += driftFillAlpha * (float64(queued) - d.fill)
+	if len(buf) < 2*d.channels {
+		return buf
+	}
+	switch {
+	case d.fill > d.target+d.tolerance:
+		d.corrections++
+		return buf[:len(buf)-d.channels]
+	case d.fill < d.target-d.tolerance:
+		d.corrections++
+		return append(buf, buf[len(buf)-d.channels:]...)
+	}
+	return buf
+}
+
+// measures how fast frames arrive against the nominal rate on the system clock
+func (d *DriftCorrector) measure(frames int) {
+	now := d.now()
+	if d.windowStart.IsZero() {
+		d.windowStart = now
+		return
+	}
+	d.frames += int64(frames)
+	elapsed := now.Sub(d.windowStart)
+	if elapsed < driftWindow {
+		return
+	}
+	d.ppm.Store((float64(d.frames)/(elapsed.Seconds()*d.rate) - 1) * 1e6)
+	d.windowStart = now
+	d.frames = 0
+}
+
+// DriftPPM returns how much faster (positive) or slower the source ran than the nominal rate,
+// in parts per million, over the last complete measurement window
+func (d *DriftCorrector) DriftPPM() float64 {
+	return d.ppm.Load()
+}
+
+// Corrections returns the number of frames dropped or duplicated
+func (d *DriftCorrector) Corrections() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.corrections
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/LedFx/ledfx/pkg/config"
@@ -30,16 +31,11 @@ func createId(hostapi string, device string, chanIn, chanOut int) string {
 }
 
 func GetPaDeviceInfo(ad config.AudioDevice) (d *portaudio.DeviceInfo, err error) {
-	hs, err := portaudio.HostApis()
-	if err != nil {
+	if _, err = portaudio.HostApis(); err != nil {
 		return
 	}
-	for _, h := range hs {
-		for _, d := range h.Devices {
-			if ad.Id == createId(h.Name, d.Name, d.MaxInputChannels, d.MaxOutputChannels) {
-				return d, nil
-			}
-		}
+	if d = findPaDevice(ad); d != nil {
+		return d, nil
 	}
 	logger.Logger.Warn("Saved audio input device cannot be found. Reverting to default device.")
 	d, err = portaudio.DefaultInputDevice()
@@ -49,6 +45,22 @@ func GetPaDeviceInfo(ad config.AudioDevice) (d *portaudio.DeviceInfo, err error)
 	return d, err
 }
 
+// findPaDevice returns the PortAudio device with ad's id, or nil if there isn't one
+func findPaDevice(ad config.AudioDevice) *portaudio.DeviceInfo {
+	hs, err := portaudio.HostApis()
+	if err != nil {
+		return nil
+	}
+	for _, h := range hs {
+		for _, d := range h.Devices {
+			if ad.Id == createId(h.Name, d.Name, d.MaxInputChannels, d.MaxOutputChannels) {
+				return d
+			}
+		}
+	}
+	return nil
+}
+
 func GetAudioDevices() (infos []config.AudioDevice, err error) {
 	hs, err := portaudio.HostApis()
 	if err != nil {
@@ -99,6 +111,27 @@ func LogAudioDevices() {
 	fmt.Println()
 }
 
+// DefaultInputDevice returns the system's default input device
+func DefaultInputDevice() (config.AudioDevice, error) {
+	d, err := portaudio.DefaultInputDevice()
+	if err != nil {
+		return config.AudioDevice{}, fmt.Errorf("error getting default input device: %w", err)
+	}
+	var hostApi string
+	if d.HostApi != nil {
+		hostApi = d.HostApi.Name
+	}
+	return config.AudioDevice{
+		Id:          createId(hostApi, d.Name, d.MaxInputChannels, d.MaxOutputChannels),
+		HostApi:     hostApi,
+		SampleRate:  d.DefaultSampleRate,
+		Name:        d.Name,
+		ChannelsIn:  d.MaxInputChannels,
+		ChannelsOut: d.MaxOutputChannels,
+		IsDefault:   true,
+	}, nil
+}
+
 func GetDeviceByID(id string) (config.AudioDevice, error) {
 	devices, err := GetAudioDevices()
 	if err != nil {
@@ -112,3 +145,33 @@ func GetDeviceByID(id string) (config.AudioDevice, error) {
 
 	return config.AudioDevice{}, errors.New("could not find saved audio device")
 }
+
+// GetDeviceByName finds the device whose name contains substr, ignoring case.
+// Names are stable across reboots where IDs may not be, so config files stay portable.
+func GetDeviceByName(substr string) (config.AudioDevice, error) {
+	devices, err := GetAudioDevices()
+	if err != nil {
+		return config.AudioDevice{}, err
+	}
+	return matchDeviceName(devices, substr)
+}
+
+func matchDeviceName(devices []config.AudioDevice, substr string) (config.AudioDevice, error) {
+	var matches []config.AudioDevice
+	for _, device := range devices {
+		if strings.Contains(strings.ToLower(device.Name), strings.ToLower(substr)) {
+			matches = append(matches, device)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return config.AudioDevice{}, fmt.Errorf("%w matching '%s'", ErrDeviceNotFound, substr)
+	case 1:
+		return matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = fmt.Sprintf("'%s' (%s)", m.Name, m.HostApi)
+	}
+	return config.AudioDevice{}, fmt.Errorf("%w '%s': %s", ErrAmbiguousDevice, substr, strings.Join(names, ", "))
+}
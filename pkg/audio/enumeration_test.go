@@ -0,0 +1,97 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/LedFx/ledfx/pkg/config"
+)
+
+func TestMatchDeviceName(t *testing.T) {
+	devices := []config.AudioDevice{
+		{Id: "1", Name: "USB Microphone", HostApi: "ALSA"},
+		{Id: "2", Name: "Built-in Microphone", HostApi: "ALSA"},
+		{Id: "3", Name: "Speakers", HostApi: "ALSA"},
+	}
+	if d, err := matchDeviceName(devices, "usb mic"); err != nil || d.Id != "1" {
+		t.Errorf("Expected: device 1\r\n Got: %+v, %v", d, err)
+	}
+	if _, err := matchDeviceName(devices, "headset"); !errors.Is(err, ErrDeviceNotFound) {
+		t.Errorf("Expected: %v\r\n Got: %v", ErrDeviceNotFound, err)
+	}
+	_, err := matchDeviceName(devices, "MICROPHONE")
+	if !errors.Is(err, ErrAmbiguousDevice) || !strings.Contains(err.Error(), "Built-in Microphone") {
+		t.Errorf("Expected: %v listing the matches\r\n Got: %v", ErrAmbiguousDevice, err)
+	}
+}
+
+func TestDiffDevices(t *testing.T) {
+	mic := config.AudioDevice{Id: "1", Name: "Built-in Microphone"}
+	usb := config.AudioDevice{Id: "2", Name: "USB Interface"}
+	speakers := config.AudioDevice{Id: "3", Name: "Speakers"}
+
+	got := diffDevices([]config.AudioDevice{mic, speakers}, []config.AudioDevice{usb, mic})
+	want := []DeviceChange{
+		{Type: DeviceRemoved, Device: speakers},
+		{Type: DeviceAdded, Device: usb},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected: %+v\r\n Got: %+v", want, got)
+	}
+	if got := diffDevices([]config.AudioDevice{mic}, []config.AudioDevice{mic}); len(got) != 0 {
+		t.Errorf("Expected: no changes\r\n Got: %+v", got)
+	}
+}
+
+func TestForgetSampleRates(t *testing.T) {
+	sampleRatesMu.Lock()
+	sampleRatesCache["1"] = []float64{44100}
+	sampleRatesCache["2"] = []float64{48000}
+	sampleRatesMu.Unlock()
+	defer clearSampleRates()
+
+	forgetSampleRates([]DeviceChange{{Type: DeviceRemoved, Device: config.AudioDevice{Id: "2"}}})
+	sampleRatesMu.Lock()
+	_, kept := sampleRatesCache["1"]
+	_, forgotten := sampleRatesCache["2"]
+	sampleRatesMu.Unlock()
+	if !kept || forgotten {
+		t.Errorf("Expected: only the removed device's rates forgotten\r\n Got: kept %v, forgotten %v", kept, !forgotten)
+	}
+}
+
+func TestWatchDevicesShared(t *testing.T) {
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	ch1, ch2 := WatchDevices(ctx1), WatchDevices(ctx2)
+
+	watcher.mu.Lock()
+	subs, polling := len(watcher.subs), watcher.cancel != nil
+	watcher.mu.Unlock()
+	if subs != 2 || !polling {
+		t.Errorf("Expected: 2 watchers sharing a poller\r\n Got: %d watchers, polling %v", subs, polling)
+	}
+
+	cancel1()
+	for range ch1 {
+	}
+	watcher.mu.Lock()
+	polling = watcher.cancel != nil
+	watcher.mu.Unlock()
+	if !polling {
+		t.Errorf("Expected: the poller to keep running for the other watcher")
+	}
+
+	cancel2()
+	for range ch2 {
+	}
+	watcher.mu.Lock()
+	polling = watcher.cancel != nil
+	watcher.mu.Unlock()
+	if polling {
+		t.Errorf("Expected: the poller to stop with the last watcher")
+	}
+}
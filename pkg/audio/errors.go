@@ -5,4 +5,7 @@ import "errors"
 var (
 	ErrNameCannotBeOmitted = errors.New("name must not be omitted")
 	ErrWriterNotFound      = errors.New("writer was not found in the index map")
+	ErrUnknownFormat       = errors.New("unknown audio format")
+	ErrDeviceNotFound      = errors.New("no audio device found")
+	ErrAmbiguousDevice     = errors.New("more than one audio device matches")
 )
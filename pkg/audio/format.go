@@ -0,0 +1,63 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Format is the sample encoding a writer added to an AsyncMultiWriter receives
+type Format int
+
+const (
+	// FormatInt16 is 16 bit little endian PCM, as written to the AsyncMultiWriter
+	FormatInt16 Format = iota
+	// FormatFloat32 is little endian float32 samples on the int16 scale
+	FormatFloat32
+	// FormatNormalized is little endian float32 samples scaled to -1 to 1
+	FormatNormalized
+
+	numFormats
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatInt16:
+		return "int16"
+	case FormatFloat32:
+		return "float32"
+	case FormatNormalized:
+		return "normalized"
+	}
+	return "unknown"
+}
+
+func (f Format) valid() bool {
+	return f >= 0 && f < numFormats
+}
+
+// conversions holds p converted to each format, computed at most once per write
+type conversions [numFormats][]byte
+
+func (c *conversions) get(p []byte, f Format) []byte {
+	if f == FormatInt16 {
+		return p
+	}
+	if c[f] == nil {
+		c[f] = convert(p, f)
+	}
+	return c[f]
+}
+
+// converts 16 bit PCM to a float format
+func convert(p []byte, f Format) []byte {
+	scale := float32(1)
+	if f == FormatNormalized {
+		scale = 1 / float32(math.MaxInt16+1)
+	}
+	out := make([]byte, len(p)/2*4)
+	for i := 0; i+1 < len(p); i += 2 {
+		s := float32(int16(binary.LittleEndian.Uint16(p[i:])))
+		binary.LittleEndian.PutUint32(out[i*2:], math.Float32bits(s*scale))
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+package audio
+
+import (
+	"math"
+	"sync"
+)
+
+// intensity of the melbank used for metering
+const levelsIntensity float64 = 0.7
+
+// Levels is a snapshot of the most recently analysed audio buffer, for metering in the UI
+type Levels struct {
+	RMS   float64   `json:"rms"`   // 0-1
+	Peak  float64   `json:"peak"`  // 0-1
+	Bands []float64 `json:"bands"` // melbank band values
+}
+
+type levelsState struct {
+	mu     sync.Mutex
+	levels Levels
+}
+
+// EnableLevels turns metering on or off. While it is off the analyzer does no extra work.
+func (a *analyzer) EnableLevels(enabled bool) {
+	a.metering.Store(enabled)
+}
+
+// Levels returns a copy of the latest levels snapshot
+func (a *analyzer) Levels() Levels {
+	a.levels.mu.Lock()
+	defer a.levels.mu.Unlock()
+	l := a.levels.levels
+	l.Bands = make([]float64, len(a.levels.levels.Bands))
+	copy(l.Bands, a.levels.levels.Bands)
+	return l
+}
+
+func (a *analyzer) updateLevels(rms, peak float64, bands []float64) {
+	a.levels.mu.Lock()
+	defer a.levels.mu.Unlock()
+	a.levels.levels.RMS = rms
+	a.levels.levels.Peak = peak
+	if len(a.levels.levels.Bands) != len(bands) {
+		a.levels.levels.Bands = make([]float64, len(bands))
+	}
+	copy(a.levels.levels.Bands, bands)
+}
+
+// peak returns the largest absolute int16-scaled sample, normalised to 0-1
+func peak(data []float32) float64 {
+	var max float64
+	for _, x := range data {
+		if v := math.Abs(float64(x)); v > max {
+			max = v
+		}
+	}
+	return math.Min(max/float64(rawMax), 1)
+}
@@ -0,0 +1,78 @@
+package audio
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// PeakMeter is a VU style meter: the level jumps up to each peak and falls at a constant rate,
+// and a peak-hold marker stays at the highest recent peak for a hold time before falling too.
+// Decay is based on elapsed time, so it doesn't depend on how often Update is called.
+type PeakMeter struct {
+	mu        sync.Mutex
+	decay     float64 // level per second
+	hold      time.Duration
+	current   float64
+	peak      float64
+	peakSince time.Time
+	last      time.Time
+
+	now func() time.Time
+}
+
+// NewPeakMeter creates a meter falling by decay (full scale is 1) per second, holding peaks for hold
+func NewPeakMeter(decay float64, hold time.Duration) *PeakMeter {
+	return &PeakMeter{
+		decay: decay,
+		hold:  hold,
+		now:   time.Now,
+	}
+}
+
+// Update feeds a buffer of audio to the meter
+func (m *PeakMeter) Update(b Buffer) {
+	var max float64
+	for _, s := range b {
+		if v := math.Abs(float64(s)); v > max {
+			max = v
+		}
+	}
+	level := math.Min(max/float64(rawMax), 1)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.fall(now)
+	if level > m.current {
+		m.current = level
+	}
+	if level >= m.peak {
+		m.peak = level
+		m.peakSince = now
+	}
+}
+
+// Levels returns the current level and the peak-hold level, from 0 to 1
+func (m *PeakMeter) Levels() (current, peakHold float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fall(m.now())
+	return m.current, m.peak
+}
+
+// decays the levels to now
+func (m *PeakMeter) fall(now time.Time) {
+	if m.last.IsZero() {
+		m.last = now
+		return
+	}
+	dt := now.Sub(m.last).Seconds()
+	m.last = now
+	m.current = math.Max(m.current-m.decay*dt, 0)
+	if held := now.Sub(m.peakSince); held > m.hold {
+		// only fall for the time since the hold expired
+		fallFor := math.Min(dt, (held - m.hold).Seconds())
+		m.peak = math.Max(m.peak-m.decay*fallFor, m.current)
+	}
+}
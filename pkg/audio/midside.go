@@ -0,0 +1,46 @@
+package audio
+
+// EncodeMS splits interleaved stereo into mid, the average of left and right, and side,
+// half their difference. A trailing odd sample is ignored. Halving drops the lowest bit,
+// so a round trip through DecodeMS can be off by one.
+func EncodeMS(interleaved Buffer) (mid, side Buffer) {
+	frames := len(interleaved) / 2
+	mid, side = make(Buffer, frames), make(Buffer, frames)
+	for i := 0; i < frames; i++ {
+		l, r := int32(interleaved[2*i]), int32(interleaved[2*i+1])
+		mid[i] = int16((l + r) / 2)
+		side[i] = int16((l - r) / 2)
+	}
+	return mid, side
+}
+
+// DecodeMS recombines mid and side into interleaved stereo, left = mid+side and right = mid-side.
+// If the buffers differ in length the extra samples of the longer one are ignored.
+func DecodeMS(mid, side Buffer) Buffer {
+	frames := len(mid)
+	if len(side) < frames {
+		frames = len(side)
+	}
+	out := make(Buffer, 2*frames)
+	for i := 0; i < frames; i++ {
+		m, s := float64(mid[i]), float64(side[i])
+		out[2*i] = Clip16(m + s)
+		out[2*i+1] = Clip16(m - s)
+	}
+	return out
+}
+
+// Width returns a Processor which scales the side channel of interleaved stereo by factor.
+// 0 collapses to mono, 1 leaves the audio untouched and above 1 exaggerates the difference
+// between the channels, clipping where it goes past full scale.
+func Width(factor float64) Processor {
+	return ProcessorFunc(func(in Buffer) Buffer {
+		for i := 0; i+1 < len(in); i += 2 {
+			l, r := float64(in[i]), float64(in[i+1])
+			m, s := (l+r)/2, (l-r)/2*factor
+			in[i] = Clip16(m + s)
+			in[i+1] = Clip16(m - s)
+		}
+		return in
+	})
+}
@@ -0,0 +1,117 @@
+package audio
+
+import (
+	"math"
+	"strconv"
+)
+
+// DefaultPitchThreshold is the YIN threshold below which a period is accepted as the pitch
+const DefaultPitchThreshold = 0.15
+
+var noteNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// PitchDetector estimates the fundamental frequency of mono audio with the YIN algorithm.
+//
+// YIN compares the buffer with itself shifted by each candidate period, so a buffer needs
+// at least two periods of the lowest frequency searched for: 2*SampleRate/MinFreq samples.
+// At 44.1kHz that is 1764 samples for 50Hz, or 1103 for the bottom of a guitar's range.
+// Shorter buffers still work, but the lowest frequencies are not detected.
+type PitchDetector struct {
+	MinFreq   float64 // lowest frequency searched for, in Hz
+	MaxFreq   float64 // highest frequency searched for, in Hz
+	Threshold float64 // YIN threshold, lower is stricter
+
+	diff []float64 // difference function, reused between buffers
+}
+
+func NewPitchDetector(minFreq, maxFreq float64) *PitchDetector {
+	return &PitchDetector{
+		MinFreq:   minFreq,
+		MaxFreq:   maxFreq,
+		Threshold: DefaultPitchThreshold,
+	}
+}
+
+// MinBufferSize is the number of samples needed to detect down to MinFreq
+func (p *PitchDetector) MinBufferSize() int {
+	return int(math.Ceil(2 * float64(SampleRate) / p.MinFreq))
+}
+
+// Detect returns the fundamental frequency in Hz and a confidence from 0 to 1.
+// Unvoiced audio, such as silence or noise, returns a confidence of 0.
+func (p *PitchDetector) Detect(b Buffer) (freq, confidence float64) {
+	tauMin := int(float64(SampleRate) / p.MaxFreq)
+	if tauMin < 2 {
+		tauMin = 2
+	}
+	tauMax := int(float64(SampleRate) / p.MinFreq)
+	if tauMax > len(b)/2 {
+		tauMax = len(b) / 2
+	}
+	if tauMax <= tauMin {
+		return 0, 0
+	}
+	window := len(b) - tauMax
+
+	if cap(p.diff) < tauMax+1 {
+		p.diff = make([]float64, tauMax+1)
+	}
+	d := p.diff[:tauMax+1]
+
+	// difference function, then its cumulative mean normalised form
+	d[0] = 1
+	var running float64
+	for tau := 1; tau <= tauMax; tau++ {
+		var sum float64
+		for i := 0; i < window; i++ {
+			delta := float64(b[i]) - float64(b[i+tau])
+			sum += delta * delta
+		}
+		running += sum
+		if running == 0 {
+			d[tau] = 1
+		} else {
+			d[tau] = sum * float64(tau) / running
+		}
+	}
+
+	// the first dip below the threshold, followed down to its minimum
+	tau := -1
+	for t := tauMin; t <= tauMax; t++ {
+		if d[t] < p.Threshold {
+			for t+1 <= tauMax && d[t+1] < d[t] {
+				t++
+			}
+			tau = t
+			break
+		}
+	}
+	if tau < 0 {
+		return 0, 0
+	}
+
+	// parabolic interpolation between neighbouring periods
+	period := float64(tau)
+	if tau > 1 && tau < tauMax {
+		a, c, e := d[tau-1], d[tau], d[tau+1]
+		if den := a - 2*c + e; den != 0 {
+			period += (a - e) / (2 * den)
+		}
+	}
+	return float64(SampleRate) / period, math.Max(0, math.Min(1, 1-d[tau]))
+}
+
+// NearestNote returns the name of the equal tempered note closest to freq, such as "A4",
+// and how far freq is from it in cents. A4 is 440Hz.
+func NearestNote(freq float64) (name string, cents float64) {
+	if freq <= 0 || math.IsNaN(freq) || math.IsInf(freq, 0) {
+		return "", 0
+	}
+	// MIDI note numbers, where A4 is 69
+	semis := 69 + 12*math.Log2(freq/440)
+	note := int(math.Round(semis))
+	cents = (semis - float64(note)) * 100
+	octave := int(math.Floor(float64(note)/12)) - 1
+	idx := (note%12 + 12) % 12
+	return noteNames[idx] + strconv.Itoa(octave), cents
+}
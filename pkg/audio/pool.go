@@ -0,0 +1,62 @@
+package audio
+
+import "sync"
+
+// BufferPool recycles Buffers to take allocations off the audio hot paths.
+//
+// A Buffer from Get belongs to the caller until it is handed back with Put. After Put
+// it must not be read, written or kept anywhere, since the next Get may return it to
+// someone else. Anything which has to outlive the call, such as bytes passed to
+// writers which queue them, must be copied out first. Buffers passed to a callback
+// may only be pooled if the callback is known not to keep them.
+type BufferPool struct {
+	buffers sync.Pool // *Buffer holding a buffer ready for reuse
+	headers sync.Pool // empty *Buffer, so Put doesn't allocate one
+}
+
+// Buffers is the pool shared by the audio pipeline
+var Buffers = NewBufferPool()
+
+func NewBufferPool() *BufferPool {
+	return &BufferPool{}
+}
+
+// Get returns a zeroed Buffer of n samples
+func (bp *BufferPool) Get(n int) Buffer {
+	var b Buffer
+	if h, ok := bp.buffers.Get().(*Buffer); ok {
+		b = *h
+		*h = nil
+		bp.headers.Put(h)
+	}
+	if cap(b) < n {
+		// too small to reuse, leave it to the GC
+		return make(Buffer, n)
+	}
+	b = b[:n]
+	for i := range b {
+		b[i] = 0
+	}
+	return b
+}
+
+// Put returns b to the pool. The caller must not use b afterwards.
+func (bp *BufferPool) Put(b Buffer) {
+	if cap(b) == 0 {
+		return
+	}
+	h, ok := bp.headers.Get().(*Buffer)
+	if !ok {
+		h = new(Buffer)
+	}
+	*h = b[:0]
+	bp.buffers.Put(h)
+}
+
+// FromBytes returns a pooled Buffer holding 16 bit PCM decoded from p, as BytesToAudioBuffer
+// but without the unused second half
+func (bp *BufferPool) FromBytes(p []byte) Buffer {
+	b := bp.Get(len(p) / 2)
+	DecodeBytes(b, p)
+	return b
+}
@@ -0,0 +1,45 @@
+package audio
+
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/LedFx/ledfx/pkg/logger"
+
+	"github.com/LedFx/portaudio"
+)
+
+// Streams hold a reference to PortAudio while open. It is initialised by the first
+// reference and terminated when the last is released.
+var (
+	paMu   sync.Mutex
+	paRefs int
+)
+
+// AcquirePortAudio takes a reference to PortAudio, to be held while a stream is open
+func AcquirePortAudio() error {
+	paMu.Lock()
+	defer paMu.Unlock()
+	if paRefs == 0 {
+		if err := portaudio.Initialize(); err != nil {
+			return fmt.Errorf("error initializing PortAudio, is an audio backend available?: %w", err)
+		}
+	}
+	paRefs++
+	return nil
+}
+
+// ReleasePortAudio releases a reference taken by AcquirePortAudio
+func ReleasePortAudio() {
+	paMu.Lock()
+	defer paMu.Unlock()
+	if paRefs == 0 {
+		return
+	}
+	paRefs--
+	if paRefs == 0 {
+		if err := portaudio.Terminate(); err != nil {
+			log.Logger.WithField("context", "PortAudio").Warnf("Error terminating PortAudio: %v", err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package audio
+
+import (
+	"context"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// StageProfile is the cost of one writer of an AsyncMultiWriter while profiling
+type StageProfile struct {
+	Name       string        `json:"name"`
+	Calls      int           `json:"calls"`
+	Time       time.Duration `json:"time_ns"`
+	Allocs     uint64        `json:"allocs"`
+	AllocBytes uint64        `json:"alloc_bytes"`
+}
+
+// how often allocation counters are read while profiling. Reading them stops the world,
+// so it isn't done around every write.
+const memSampleInterval = 250 * time.Millisecond
+
+// writeProfiler accumulates per writer costs
+type writeProfiler struct {
+	mu     sync.Mutex
+	stages map[string]*StageProfile
+	order  []string
+	writes int
+
+	// time each stage has run since the allocation counters were last read, and their values then
+	window              map[string]time.Duration
+	mallocs, allocBytes uint64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newWriteProfiler() *writeProfiler {
+	wp := &writeProfiler{
+		stages: make(map[string]*StageProfile),
+		window: make(map[string]time.Duration),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	wp.mallocs, wp.allocBytes = readAllocs()
+	go wp.sampleAllocs()
+	return wp
+}
+
+// measure runs fn, recording its time against name
+func (wp *writeProfiler) measure(name string, fn func()) {
+	start := time.Now()
+	pprof.Do(context.Background(), pprof.Labels("stage", name), func(context.Context) {
+		fn()
+	})
+	elapsed := time.Since(start)
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	st, ok := wp.stages[name]
+	if !ok {
+		st = &StageProfile{Name: name}
+		wp.stages[name] = st
+		wp.order = append(wp.order, name)
+	}
+	st.Calls++
+	st.Time += elapsed
+	wp.window[name] += elapsed
+}
+
+// sampleAllocs reads the allocation counters every memSampleInterval, and once more when stopped
+func (wp *writeProfiler) sampleAllocs() {
+	defer close(wp.done)
+	ticker := time.NewTicker(memSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-wp.stop:
+			wp.attributeAllocs()
+			return
+		case <-ticker.C:
+			wp.attributeAllocs()
+		}
+	}
+}
+
+// attributeAllocs shares what was allocated since the last read between the stages, by the time
+// each ran in between. Counts are process wide, so this is an estimate which includes anything
+// else allocating meanwhile.
+func (wp *writeProfiler) attributeAllocs() {
+	mallocs, allocBytes := readAllocs()
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	var total time.Duration
+	for _, d := range wp.window {
+		total += d
+	}
+	for name, d := range wp.window {
+		share := 1 / float64(len(wp.window))
+		if total > 0 {
+			share = float64(d) / float64(total)
+		}
+		st := wp.stages[name]
+		st.Allocs += uint64(float64(mallocs-wp.mallocs) * share)
+		st.AllocBytes += uint64(float64(allocBytes-wp.allocBytes) * share)
+		delete(wp.window, name)
+	}
+	wp.mallocs, wp.allocBytes = mallocs, allocBytes
+}
+
+// close stops sampling, attributing what was allocated since the last sample
+func (wp *writeProfiler) close() {
+	close(wp.stop)
+	<-wp.done
+}
+
+func readAllocs() (mallocs, allocBytes uint64) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.Mallocs, m.TotalAlloc
+}
+
+// StartProfile starts recording the time and allocations of each writer.
+// Writes are made sequentially while profiling so stages can be measured separately.
+func (bw *AsyncMultiWriter) StartProfile() {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	if bw.profiler != nil {
+		bw.profiler.close()
+	}
+	bw.profiler = newWriteProfiler()
+	bw.writeFn = bw.writeSeq
+}
+
+// StopProfile stops profiling, returning the number of writes and the cost of each writer in the order first written
+func (bw *AsyncMultiWriter) StopProfile() (writes int, stages []StageProfile) {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	wp := bw.profiler
+	bw.profiler = nil
+	bw.checkAsyncThreshold()
+	if wp == nil {
+		return 0, nil
+	}
+	wp.close()
+	stages = make([]StageProfile, len(wp.order))
+	for i, name := range wp.order {
+		stages[i] = *wp.stages[name]
+	}
+	return wp.writes, stages
+}
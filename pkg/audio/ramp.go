@@ -0,0 +1,156 @@
+package audio
+
+import (
+	"sync"
+	"time"
+)
+
+// a ramp which hasn't processed a buffer for this long has nothing writing through it
+const rampIdleAfter = 100 * time.Millisecond
+
+// Ramp fades audio in and out so a source starting or stopping doesn't click.
+// Fades are timed by the clock rather than by samples, so the same ramp works for any
+// channel count. A fade starts with the first buffer processed after it is requested.
+type Ramp struct {
+	mu        sync.Mutex
+	duration  time.Duration
+	from, to  float64       // gain at the start and end of the current fade
+	start     time.Time     // zero until the first buffer of the fade
+	last      float64       // gain at the end of the previous buffer
+	processed time.Time     // when the last buffer was processed
+	done      chan struct{} // closed once the current fade reaches its gain
+}
+
+// NewRamp returns a ramp which fades in from silence over d, starting with the first buffer
+func NewRamp(d time.Duration) *Ramp {
+	return &Ramp{
+		duration: d,
+		from:     0,
+		to:       1,
+		done:     make(chan struct{}),
+	}
+}
+
+// SetDuration changes the fade time. A fade in progress keeps going at the new rate.
+func (r *Ramp) SetDuration(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.duration = d
+}
+
+func (r *Ramp) Duration() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.duration
+}
+
+// FadeIn ramps the gain from its current value up to 1
+func (r *Ramp) FadeIn() {
+	r.fadeTo(1)
+}
+
+// FadeOut ramps the gain from its current value down to 0
+func (r *Ramp) FadeOut() {
+	r.fadeTo(0)
+}
+
+func (r *Ramp) fadeTo(gain float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.from, r.to = r.last, gain
+	r.start = time.Time{}
+	r.done = make(chan struct{})
+	if r.last == gain {
+		close(r.done)
+	}
+}
+
+// Wait waits for the current fade to reach its gain, returning false if it hasn't after timeout.
+// Fades only move as audio is processed, so it returns at once when nothing is being written.
+func (r *Ramp) Wait(timeout time.Duration) bool {
+	r.mu.Lock()
+	done := r.done
+	r.mu.Unlock()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	poll := time.NewTicker(rampIdleAfter / 2)
+	defer poll.Stop()
+	for !r.idle() {
+		select {
+		case <-done:
+			return true
+		case <-deadline.C:
+			return false
+		case <-poll.C:
+		}
+	}
+	return true
+}
+
+// idle reports whether nothing has been processed lately
+func (r *Ramp) idle() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return time.Since(r.processed) > rampIdleAfter
+}
+
+// Gain returns the gain applied at the end of the last processed buffer
+func (r *Ramp) Gain() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// Bypass reports whether the ramp is fully open, leaving audio untouched
+func (r *Ramp) Bypass() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last == 1 && r.to == 1
+}
+
+// Process scales the buffer, moving linearly from the previous buffer's gain to the gain now
+func (r *Ramp) Process(in Buffer) Buffer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.processed = now
+	if r.start.IsZero() {
+		r.start = now
+	}
+	g0, g1 := r.last, r.gainAt(now)
+	r.last = g1
+	if g1 == r.to {
+		select {
+		case <-r.done:
+		default:
+			close(r.done)
+		}
+	}
+
+	switch {
+	case g0 == 1 && g1 == 1:
+		return in
+	case g0 == 0 && g1 == 0:
+		for i := range in {
+			in[i] = 0
+		}
+		return in
+	}
+	n := float64(len(in))
+	for i, s := range in {
+		in[i] = Clip16(float64(s) * (g0 + (g1-g0)*float64(i)/n))
+	}
+	return in
+}
+
+// must be called with mu held
+func (r *Ramp) gainAt(t time.Time) float64 {
+	if r.duration <= 0 {
+		return r.to
+	}
+	p := float64(t.Sub(r.start)) / float64(r.duration)
+	if p >= 1 {
+		return r.to
+	}
+	return r.from + (r.to-r.from)*p
+}
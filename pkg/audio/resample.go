@@ -0,0 +1,43 @@
+package audio
+
+// Resampler converts a mono stream between sample rates by linear interpolation.
+// It keeps the position between buffers, so a stream can be fed through in pieces of any size.
+// Linear interpolation is cheap but doesn't filter, so downsampling aliases a little.
+type Resampler struct {
+	step   float64 // input samples advanced per output sample
+	pos    float64 // position of the next output sample, where 0 is prev
+	prev   int16   // last sample of the previous buffer
+	primed bool
+}
+
+// NewResampler creates a resampler from one rate to another, in Hz
+func NewResampler(from, to float64) *Resampler {
+	return &Resampler{step: from / to}
+}
+
+// Process returns in at the new rate. in is returned unchanged if the rates match.
+func (r *Resampler) Process(in Buffer) Buffer {
+	if r.step == 1 || len(in) == 0 {
+		return in
+	}
+	if !r.primed {
+		// the stream starts at in[0], there's nothing before it to interpolate from
+		r.pos, r.primed = 1, true
+	}
+	// sample k is prev for k == 0, else in[k-1]
+	at := func(k int) float64 {
+		if k == 0 {
+			return float64(r.prev)
+		}
+		return float64(in[k-1])
+	}
+	out := make(Buffer, 0, int(float64(len(in))/r.step)+1)
+	for ; int(r.pos) < len(in); r.pos += r.step {
+		k := int(r.pos)
+		frac := r.pos - float64(k)
+		out = append(out, Clip16(at(k)+(at(k+1)-at(k))*frac))
+	}
+	r.pos -= float64(len(in))
+	r.prev = in[len(in)-1]
+	return out
+}
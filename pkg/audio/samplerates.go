@@ -0,0 +1,66 @@
+package audio
+
+import (
+	"sync"
+
+	"github.com/LedFx/ledfx/pkg/config"
+
+	"github.com/LedFx/portaudio"
+)
+
+// StandardSampleRates are the rates SupportedSampleRates probes for
+var StandardSampleRates = []float64{8000, 11025, 16000, 22050, 32000, 44100, 48000, 88200, 96000, 176400, 192000}
+
+var (
+	sampleRatesMu    sync.Mutex
+	sampleRatesCache = map[string][]float64{} // keyed by device id
+)
+
+// SupportedSampleRates returns which of StandardSampleRates the device can capture mono 16 bit audio at.
+// Probing is slow on some host APIs, so results are cached per device until RefreshDevices
+// reinitialises PortAudio, or the device watcher sees the device added or removed.
+// Devices which can't be found or have no inputs support none.
+func SupportedSampleRates(dev config.AudioDevice) []float64 {
+	sampleRatesMu.Lock()
+	defer sampleRatesMu.Unlock()
+	if rates, ok := sampleRatesCache[dev.Id]; ok {
+		return rates
+	}
+
+	rates := make([]float64, 0)
+	if d := findPaDevice(dev); d != nil && d.MaxInputChannels > 0 {
+		p := portaudio.StreamParameters{
+			Input: portaudio.StreamDeviceParameters{
+				Device:   d,
+				Channels: 1,
+				Latency:  d.DefaultLowInputLatency,
+			},
+			FramesPerBuffer: portaudio.FramesPerBufferUnspecified,
+		}
+		for _, rate := range StandardSampleRates {
+			p.SampleRate = rate
+			if portaudio.IsFormatSupported(p, func(in []int16) {}) == nil {
+				rates = append(rates, rate)
+			}
+		}
+	}
+	sampleRatesCache[dev.Id] = rates
+	return rates
+}
+
+// forgets probed sample rates, as device ids can be reused by different hardware
+func clearSampleRates() {
+	sampleRatesMu.Lock()
+	defer sampleRatesMu.Unlock()
+	sampleRatesCache = map[string][]float64{}
+}
+
+// forgets the probed sample rates of devices which were added or removed. RefreshDevices
+// can't clear the cache while a stream is open, so this keeps it from going stale meanwhile.
+func forgetSampleRates(changes []DeviceChange) {
+	sampleRatesMu.Lock()
+	defer sampleRatesMu.Unlock()
+	for _, change := range changes {
+		delete(sampleRatesCache, change.Device.Id)
+	}
+}
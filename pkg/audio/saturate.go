@@ -0,0 +1,31 @@
+package audio
+
+import "math"
+
+// softKnee is the fraction of full scale above which soft clipping bends the signal
+const softKnee = 0.5
+
+// SoftClip is a gain which saturates instead of clipping, so overdriven audio rounds
+// over towards full scale rather than squaring off. Drive is the gain in dB pushed
+// into the curve. Audio below half scale after the gain is unaffected.
+type SoftClip struct {
+	Drive float64
+}
+
+func (s SoftClip) Process(in Buffer) Buffer {
+	g := dbToLinear(s.Drive)
+	for i, v := range in {
+		in[i] = softClip(float64(v) * g)
+	}
+	return in
+}
+
+// softClip maps v on the int16 scale through a tanh curve above softKnee. The curve meets the
+// straight line below it with the same slope, and approaches but never passes full scale.
+func softClip(v float64) int16 {
+	x := math.Abs(v) / float64(rawMax)
+	if x > softKnee {
+		x = softKnee + (1-softKnee)*math.Tanh((x-softKnee)/(1-softKnee))
+	}
+	return Clip16(math.Copysign(x*float64(rawMax), v))
+}
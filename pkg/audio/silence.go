@@ -0,0 +1,68 @@
+package audio
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSilenceThreshold is the level, in dB SPL, below which audio counts as silent
+	DefaultSilenceThreshold = -70.0
+	// audio that stops arriving altogether counts as silent after this long
+	silenceStall = time.Second
+)
+
+// SilenceDetector tracks how long audio has been silent: either quieter than a threshold,
+// or not arriving at all because the source stopped.
+type SilenceDetector struct {
+	mu         sync.Mutex
+	threshold  float64
+	lastUpdate time.Time
+	quietSince time.Time // zero while audio is above the threshold
+}
+
+func NewSilenceDetector(threshold float64) *SilenceDetector {
+	now := time.Now()
+	return &SilenceDetector{
+		threshold:  threshold,
+		lastUpdate: now,
+		quietSince: now,
+	}
+}
+
+// Update records the level of the latest buffer, in dB SPL
+func (s *SilenceDetector) Update(level float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.lastUpdate = now
+	switch {
+	case level >= s.threshold:
+		s.quietSince = time.Time{}
+	case s.quietSince.IsZero():
+		s.quietSince = now
+	}
+}
+
+// SilentFor returns how long audio has been silent, or 0 if it isn't
+func (s *SilenceDetector) SilentFor() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if stalled := now.Sub(s.lastUpdate); stalled > silenceStall {
+		if s.quietSince.IsZero() {
+			return stalled
+		}
+	}
+	if s.quietSince.IsZero() {
+		return 0
+	}
+	return now.Sub(s.quietSince)
+}
+
+// SetThreshold changes the level, in dB SPL, below which audio counts as silent
+func (s *SilenceDetector) SetThreshold(threshold float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.threshold = threshold
+}
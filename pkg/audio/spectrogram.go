@@ -0,0 +1,139 @@
+package audio
+
+import (
+	"fmt"
+	"image"
+	imgcolor "image/color"
+	"image/png"
+	"io"
+	"sync"
+)
+
+// Spectrogram keeps a rolling history of spectrum frames, for waterfall displays
+type Spectrogram struct {
+	mu     sync.Mutex
+	bins   int
+	frames [][]float64 // ring of frames
+	next   int         // ring index the next frame is written to
+	count  int
+}
+
+// NewSpectrogram keeps the last history frames, each reduced to bins values.
+// Attach it with Analyzer.AttachSpectrogram to record every analysed buffer.
+func NewSpectrogram(history, bins int) (*Spectrogram, error) {
+	if history < 1 {
+		return nil, fmt.Errorf("history %d must be at least 1", history)
+	}
+	if bins < 1 {
+		return nil, fmt.Errorf("bin count %d must be at least 1", bins)
+	}
+	s := &Spectrogram{
+		bins:   bins,
+		frames: make([][]float64, history),
+	}
+	for i := range s.frames {
+		s.frames[i] = make([]float64, bins)
+	}
+	return s, nil
+}
+
+// Push adds a spectrum frame, averaging or repeating its values to fit the bin count
+func (s *Spectrogram) Push(spectrum []float64) {
+	if len(spectrum) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	frame := s.frames[s.next]
+	for b := range frame {
+		lo := b * len(spectrum) / s.bins
+		hi := (b + 1) * len(spectrum) / s.bins
+		if hi <= lo {
+			hi = lo + 1
+		}
+		var sum float64
+		for _, v := range spectrum[lo:hi] {
+			sum += v
+		}
+		frame[b] = sum / float64(hi-lo)
+	}
+	s.next = (s.next + 1) % len(s.frames)
+	if s.count < len(s.frames) {
+		s.count++
+	}
+}
+
+// Snapshot returns a copy of the kept frames, oldest first
+func (s *Spectrogram) Snapshot() [][]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([][]float64, s.count)
+	start := (s.next - s.count + len(s.frames)) % len(s.frames)
+	for i := range out {
+		out[i] = append([]float64(nil), s.frames[(start+i)%len(s.frames)]...)
+	}
+	return out
+}
+
+// WriteToImage renders the history as a greyscale PNG, time from left to right and
+// low frequencies at the bottom. Values are scaled to the loudest bin in the history.
+func (s *Spectrogram) WriteToImage(w io.Writer) error {
+	frames := s.Snapshot()
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to write")
+	}
+	var max float64
+	for _, f := range frames {
+		for _, v := range f {
+			if v > max {
+				max = v
+			}
+		}
+	}
+	img := image.NewGray(image.Rect(0, 0, len(frames), s.bins))
+	for x, f := range frames {
+		for b, v := range f {
+			var level uint8
+			if max > 0 && v > 0 {
+				level = uint8(v / max * 255)
+			}
+			img.SetGray(x, s.bins-1-b, imgcolor.Gray{Y: level})
+		}
+	}
+	if err := png.Encode(w, img); err != nil {
+		return fmt.Errorf("error encoding png: %w", err)
+	}
+	return nil
+}
+
+// AttachSpectrogram records the spectrum of every analysed buffer into s
+func (a *analyzer) AttachSpectrogram(s *Spectrogram) {
+	a.specMu.Lock()
+	defer a.specMu.Unlock()
+	a.spectrograms = append(a.spectrograms, s)
+}
+
+// DetachSpectrogram stops recording into s
+func (a *analyzer) DetachSpectrogram(s *Spectrogram) {
+	a.specMu.Lock()
+	defer a.specMu.Unlock()
+	for i := range a.spectrograms {
+		if a.spectrograms[i] == s {
+			a.spectrograms = append(a.spectrograms[:i], a.spectrograms[i+1:]...)
+			return
+		}
+	}
+}
+
+// pushes the current fft magnitudes to attached spectrograms
+func (a *analyzer) updateSpectrograms() {
+	a.specMu.Lock()
+	defer a.specMu.Unlock()
+	if len(a.spectrograms) == 0 {
+		return
+	}
+	norm := a.pvoc.Grain().Norm()
+	for _, s := range a.spectrograms {
+		s.Push(norm)
+	}
+}
@@ -0,0 +1,167 @@
+package audio
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// tempos are folded by octaves into this range, so onsets on off-beats or every other beat still count
+	minBPM = 60.0
+	maxBPM = 180.0
+	// number of recent onset intervals the tempo is estimated from
+	tempoHistory = 16
+	// smoothing of the tempo towards each new estimate, and of the phase towards each onset
+	tempoSmoothing = 0.2
+	phaseSmoothing = 0.3
+	// onsets longer apart than this restart tracking
+	tempoTimeout = 4 * time.Second
+)
+
+// TempoTracker estimates the tempo from onset times and keeps a beat phase running between them,
+// so effects can move with the beat even when no onset is detected.
+//
+// Each interval between onsets is folded into 60-180 BPM, and the tempo follows the median of
+// recent intervals with smoothing, so single missed or extra onsets don't throw it off.
+// The phase is nudged towards each onset like a phase locked loop.
+type TempoTracker struct {
+	mu         sync.Mutex
+	lastOnset  time.Time
+	intervals  []float64 // recent folded onset intervals, in seconds, oldest first
+	period     float64   // smoothed beat period, in seconds. 0 until a tempo is known
+	beat       time.Time // time of a beat, the reference for the phase
+	confidence float64
+}
+
+func NewTempoTracker() *TempoTracker {
+	return &TempoTracker{}
+}
+
+// Onset records an onset detected at t. Onsets must be given in order.
+func (tt *TempoTracker) Onset(t time.Time) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	last := tt.lastOnset
+	tt.lastOnset = t
+	if last.IsZero() || t.Sub(last) > tempoTimeout {
+		tt.intervals = tt.intervals[:0]
+		tt.period, tt.confidence = 0, 0
+		tt.beat = t
+		return
+	}
+	interval := foldInterval(t.Sub(last).Seconds())
+	if interval == 0 {
+		return
+	}
+	tt.intervals = append(tt.intervals, interval)
+	if len(tt.intervals) > tempoHistory {
+		tt.intervals = tt.intervals[1:]
+	}
+
+	estimate := median(tt.intervals)
+	if tt.period == 0 {
+		tt.period = estimate
+	} else {
+		// re-anchor the beat so the phase carries on from where it is at t at the new period,
+		// rather than jumping by every beat since the anchor times the change in period
+		phase := tt.phaseLocked(t)
+		tt.period += tempoSmoothing * (estimate - tt.period)
+		tt.beat = t.Add(-time.Duration(phase * tt.period * float64(time.Second)))
+	}
+
+	// pull the beat reference towards this onset by a fraction of the phase error.
+	// Onsets nearer the off-beat than the beat don't move it.
+	offset := math.Mod(t.Sub(tt.beat).Seconds()/tt.period, 1)
+	switch {
+	case offset > 0.5:
+		offset--
+	case offset < -0.5:
+		offset++
+	}
+	if math.Abs(offset) < 0.25 {
+		tt.beat = tt.beat.Add(time.Duration(phaseSmoothing * offset * tt.period * float64(time.Second)))
+	}
+
+	tt.confidence = tt.confidenceLocked()
+}
+
+// confidence is high when recent intervals agree with the tempo and there are enough of them
+func (tt *TempoTracker) confidenceLocked() float64 {
+	var dev float64
+	for _, x := range tt.intervals {
+		dev += math.Abs(x-tt.period) / tt.period
+	}
+	dev /= float64(len(tt.intervals))
+	fill := float64(len(tt.intervals)) / tempoHistory
+	return math.Max(0, 1-4*dev) * fill
+}
+
+// BPM is the estimated tempo, or 0 until enough onsets have been seen
+func (tt *TempoTracker) BPM() float64 {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	if tt.period == 0 {
+		return 0
+	}
+	return 60 / tt.period
+}
+
+// Phase is how far through the current beat it is now, from 0 on the beat up to 1
+func (tt *TempoTracker) Phase() float64 {
+	return tt.PhaseAt(time.Now())
+}
+
+func (tt *TempoTracker) PhaseAt(t time.Time) float64 {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	if tt.period == 0 {
+		return 0
+	}
+	return tt.phaseLocked(t)
+}
+
+func (tt *TempoTracker) phaseLocked(t time.Time) float64 {
+	phase := math.Mod(t.Sub(tt.beat).Seconds()/tt.period, 1)
+	if phase < 0 {
+		phase++
+	}
+	return phase
+}
+
+// Confidence is from 0 to 1, how consistent recent onsets are with the tempo.
+// It decays to 0 when onsets stop.
+func (tt *TempoTracker) Confidence() float64 {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	if tt.lastOnset.IsZero() || time.Since(tt.lastOnset) > tempoTimeout {
+		return 0
+	}
+	return tt.confidence
+}
+
+// foldInterval doubles or halves an onset interval into the tracked tempo range. Returns 0 for nonsense intervals.
+func foldInterval(s float64) float64 {
+	if s <= 0 {
+		return 0
+	}
+	for s > 60/minBPM {
+		s /= 2
+	}
+	for s < 60/maxBPM {
+		s *= 2
+	}
+	return s
+}
+
+func median(xs []float64) float64 {
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -2,6 +2,7 @@ package bridgeapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -52,13 +53,17 @@ func NewServer(callback func(buf audio.Buffer), mux *http.ServeMux) (s *Server,
 	// Output adder handlers
 	s.mux.HandleFunc("/api/bridge/add/output/airplay", s.handleAddOutputAirPlay)
 	s.mux.HandleFunc("/api/bridge/add/output/local", s.handleAddOutputLocal)
+	s.mux.HandleFunc("/api/bridge/add/output/pipe", s.handleAddOutputPipe)
 
 	// Ctl handlers
 	s.mux.HandleFunc("/api/bridge/ctl/youtube/set", s.handleCtlYouTube)
 	s.mux.HandleFunc("/api/bridge/ctl/airplay/set", s.handleCtlAirPlaySet)
+	s.mux.HandleFunc("/api/bridge/ctl/airplay/routing", s.handleCtlAirPlayRouting)
+	s.mux.HandleFunc("/api/bridge/ctl/autoswitch", s.handleCtlAutoSwitch)
 
 	// Info handlers
 	s.mux.HandleFunc("/api/bridge/get/inputs/local", s.handleGetLocalInputs)
+	s.mux.HandleFunc("/api/bridge/get/inputs/local/ws", s.handleWatchLocalInputsWs)
 
 	/* TODO statpoll for these endpoints
 	s.mux.HandleFunc("/api/bridge/ctl/airplay/clients", s.handleCtlAirPlayGetClients)
@@ -146,6 +151,58 @@ func (s *Server) handleCtlAirPlaySet(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusOK)
 }
+func (s *Server) handleCtlAirPlayRouting(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		w.Write([]byte(fmt.Sprintf("method '%s' is not allowed", r.Method)))
+		return
+	}
+
+	logger.Logger.WithField("context", "AudioBridge").Infoln("Got AirPlay routing CTL request...")
+	bodyBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Logger.WithField("context", "AudioBridge").Errorf("Error reading request body: %v", err)
+		w.Write(errToJson(err))
+		return
+	}
+
+	respBytes, err := s.Br.JSONWrapper().CTL().AirPlayRouting(bodyBytes)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Logger.WithField("context", "AudioBridge").Errorf("Error running AirPlay routing CTL action: %v", err)
+		w.Write(errToJson(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(respBytes)
+}
+func (s *Server) handleCtlAutoSwitch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		w.Write([]byte(fmt.Sprintf("method '%s' is not allowed", r.Method)))
+		return
+	}
+
+	logger.Logger.WithField("context", "AudioBridge").Infoln("Got auto switch CTL request...")
+	bodyBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Logger.WithField("context", "AudioBridge").Errorf("Error reading request body: %v", err)
+		w.Write(errToJson(err))
+		return
+	}
+
+	respBytes, err := s.Br.JSONWrapper().CTL().AutoSwitch(bodyBytes)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Logger.WithField("context", "AudioBridge").Errorf("Error running auto switch CTL action: %v", err)
+		w.Write(errToJson(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(respBytes)
+}
 func (s *Server) handleCtlAirPlayGetClients(w http.ResponseWriter, r *http.Request) {
 	logger.Logger.WithField("context", "AudioBridge").Infoln("Got AirPlay GET CTL request...")
 	if r.Method != http.MethodGet {
@@ -261,6 +318,24 @@ func (s *Server) handleAddOutputLocal(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (s *Server) handleAddOutputPipe(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Logger.WithField("context", "AudioBridge").Errorf("Error reading request body: %v", err)
+		w.Write(errToJson(err))
+		return
+	}
+	logger.Logger.WithField("context", "AudioBridge").Infoln("Adding named pipe output...")
+	if err := s.Br.JSONWrapper().AddPipeOutput(bodyBytes); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		logger.Logger.WithField("context", "AudioBridge").Errorf("Error starting pipe output: %v", err)
+		w.Write(errToJson(err))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 func (s *Server) handleGetLocalInputs(w http.ResponseWriter, r *http.Request) {
 	infos, err := audio.GetAudioDevices()
 	if err != nil {
@@ -280,6 +355,37 @@ func (s *Server) handleGetLocalInputs(w http.ResponseWriter, r *http.Request) {
 	w.Write(infoBytes)
 }
 
+// handleWatchLocalInputsWs streams a JSON audio.DeviceChange over the websocket for every device plugged in or removed
+func (s *Server) handleWatchLocalInputsWs(w http.ResponseWriter, r *http.Request) {
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Logger.WithField("context", "AudioBridge").Errorf("Error upgrading connection to websocket: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(errToJson(err))
+		return
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// nothing is read from the client, but reading notices when it goes away
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for change := range s.Br.Controller().WatchDevices(ctx) {
+		if err := ws.WriteJSON(change); err != nil {
+			logger.Logger.WithField("context", "AudioBridge").Debugf("Error writing device change to websocket: %v", err)
+			return
+		}
+	}
+}
+
 // ############### END LOCAL ###############
 
 // ############## BEGIN MISC ##############
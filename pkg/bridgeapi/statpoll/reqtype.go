@@ -5,6 +5,7 @@ type ReqParam string
 const (
 	ParamInputType ReqParam = "input_type"
 	ParamOutputs   ReqParam = "outputs"
+	ParamClipping  ReqParam = "clipping"
 
 	YtParamNowPlaying    ReqParam = "yt_now_playing"
 	YtParamTrackDuration ReqParam = "yt_track_duration"
@@ -33,6 +33,8 @@ func (s *StatPoller) socketLoop(ws *websocket.Conn, statReq *Request) {
 				resp.Values[ParamInputType] = s.br.Controller().InputType()
 			case ParamOutputs:
 				resp.Values[ParamOutputs] = s.br.Controller().Outputs()
+			case ParamClipping:
+				resp.Values[ParamClipping] = s.br.Controller().Clipping()
 			case YtParamNowPlaying:
 				resp.Values[YtParamNowPlaying], err = s.br.Controller().YouTube().NowPlaying()
 			case YtParamTrackDuration:
@@ -2,6 +2,8 @@ package color
 
 import (
 	"fmt"
+	"math"
+	"strings"
 	"testing"
 )
 
@@ -66,6 +68,41 @@ func TestNewPalette(t *testing.T) {
 	}
 }
 
+func TestLoadPalette(t *testing.T) {
+	ggr := `GIMP Gradient
+Name: Test
+2
+0 0.25 0.5 1 0 0 1 0 0 1 1 0 0 0 0
+0.5 0.75 1 0 0 1 1 0 1 0 1 0 0
+`
+	cases := []struct {
+		format, data string
+		pass         bool
+		css          string
+	}{
+		{"css", "#ff0000, #00f\n// comment\nred // trailing", true, "linear-gradient(90deg, rgb(255, 0, 0) 0%, rgb(0, 0, 255) 50%, rgb(255, 0, 0) 100%)"},
+		{"css", "#ff0000", true, "linear-gradient(90deg, rgb(255, 0, 0) 0%, rgb(255, 0, 0) 100%)"},
+		{"css", "#ff0000\n#12345", false, "line 2"},
+		{"css", "// nothing", false, "no colors"},
+		{"ggr", ggr, true, "linear-gradient(90deg, rgb(255, 0, 0) 0%, rgb(127.5, 0, 127.5) 25%, rgb(0, 0, 255) 50%, rgb(0, 0, 255) 50%, rgb(0, 127.5, 127.5) 75%, rgb(0, 255, 0) 100%)"},
+		{"ggr", "GIMP Gradient\n1\n0 0.5 1 1 0 0 1 0 0 2 1 0 0", false, "line 3"},
+		{"ggr", "GIMP Gradient\n2\n0 0.5 1 1 0 0 1 0 0 1 1 0 0", false, "line 3"},
+		{"ggr", "not a gradient", false, "line 1"},
+		{"gpl", "", false, "unknown palette format"},
+	}
+	for _, c := range cases {
+		p, err := LoadPalette(strings.NewReader(c.data), c.format)
+		switch {
+		case c.pass && err != nil:
+			t.Errorf("Expected: %s palette to load\r\n Got: %v", c.format, err)
+		case c.pass && p.String() != c.css:
+			t.Errorf("Expected: %s\r\n Got: %s", c.css, p.String())
+		case !c.pass && (err == nil || !strings.Contains(err.Error(), c.css)):
+			t.Errorf("Expected: error containing '%s'\r\n Got: %v", c.css, err)
+		}
+	}
+}
+
 func TestKernelBlur(t *testing.T) {
 	for _, v := range TestPixels {
 		b := NewBlurrer(len(v), 1) // use largest kernel, most demanding
@@ -202,3 +239,35 @@ func TestFillBetween(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestHSV(t *testing.T) {
+	cases := []struct {
+		h, s, v float64
+		a       Color
+	}{
+		{0, 1, 1, Color{1, 0, 0}},
+		{1. / 3, 1, 1, Color{0, 1, 0}},
+		{2. / 3, 1, 1, Color{0, 0, 1}},
+		{1, 1, 1, Color{1, 0, 0}},       // wraps to red
+		{-1. / 3, 1, 1, Color{0, 0, 1}}, // wraps backwards to blue
+		{0.5, 0, 0.5, Color{0.5, 0.5, 0.5}},
+	}
+	for _, c := range cases {
+		guess := HSVToRGB(c.h, c.s, c.v)
+		for k := range guess {
+			if math.Abs(guess[k]-c.a[k]) > 1e-9 {
+				t.Errorf("Failed to convert hsv(%f, %f, %f): expected %v but got %v", c.h, c.s, c.v, c.a, guess)
+				break
+			}
+		}
+		// round trip, ignoring the hue of greys
+		h, s, v := RGBToHSV(guess)
+		back := HSVToRGB(h, s, v)
+		for k := range back {
+			if math.Abs(back[k]-guess[k]) > 1e-9 {
+				t.Errorf("Failed round trip of %v: got %v", guess, back)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package color
+
+import (
+	"math"
+)
+
+// HSVToRGB converts hue, saturation and value (all 0-1) to an RGB color.
+// Hue wraps around, so 1.25 and -0.75 are both the same as 0.25.
+func HSVToRGB(h, s, v float64) Color {
+	h = math.Mod(h, 1)
+	if h < 0 {
+		h++
+	}
+	h *= 6
+	sector := math.Floor(h)
+	f := h - sector
+	p := v * (1 - s)
+	q := v * (1 - s*f)
+	t := v * (1 - s*(1-f))
+	switch int(sector) {
+	case 0:
+		return Color{v, t, p}
+	case 1:
+		return Color{q, v, p}
+	case 2:
+		return Color{p, v, t}
+	case 3:
+		return Color{p, q, v}
+	case 4:
+		return Color{t, p, v}
+	default:
+		return Color{v, p, q}
+	}
+}
+
+// RGBToHSV converts an RGB color to hue, saturation and value (all 0-1).
+// Greys have no hue, so h is 0 for them.
+func RGBToHSV(col Color) (h, s, v float64) {
+	r, g, b := col[0], col[1], col[2]
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	delta := max - min
+	v = max
+	if max == 0 {
+		return 0, 0, v
+	}
+	s = delta / max
+	if delta == 0 {
+		return 0, s, v
+	}
+	switch max {
+	case r:
+		h = (g - b) / delta
+	case g:
+		h = 2 + (b-r)/delta
+	default:
+		h = 4 + (r-g)/delta
+	}
+	h /= 6
+	if h < 0 {
+		h++
+	}
+	return h, s, v
+}
@@ -0,0 +1,159 @@
+package color
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// a color at a position along a palette, from 0 to 1
+type paletteStop struct {
+	color Color
+	pos   float64
+}
+
+/*
+Loads a palette from r in the given format:
+  - "css": hex colors such as #ff8800 or #f80, or color names, separated by commas,
+    spaces or new lines and spread evenly along the palette. Text after // is ignored.
+  - "ggr": a GIMP gradient. Each segment's ends and midpoint become stops. Blending
+    and HSV coloring are approximated by the palette's own smooth RGB blending.
+
+The palette's String is its CSS, so loaded palettes can be stored in config like any other.
+*/
+func LoadPalette(r io.Reader, format string) (*Palette, error) {
+	var stops []paletteStop
+	var err error
+	switch strings.ToLower(format) {
+	case "css":
+		stops, err = readCSSStops(r)
+	case "ggr":
+		stops, err = readGGRStops(r)
+	default:
+		return nil, fmt.Errorf("%w: unknown palette format '%s'", errInvalidPalette, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParsePalette(stopsToCSS(stops))
+}
+
+func readCSSStops(r io.Reader) ([]paletteStop, error) {
+	var colors []Color
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "//")
+		for _, field := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			c, err := NewColor(expandHex(field))
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: invalid color '%s'", errInvalidPalette, line, field)
+			}
+			colors = append(colors, c)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading palette: %w", err)
+	}
+	if len(colors) == 0 {
+		return nil, fmt.Errorf("%w: no colors", errInvalidPalette)
+	}
+	if len(colors) == 1 {
+		return []paletteStop{{colors[0], 0}, {colors[0], 1}}, nil
+	}
+	stops := make([]paletteStop, len(colors))
+	for i, c := range colors {
+		stops[i] = paletteStop{c, float64(i) / float64(len(colors)-1)}
+	}
+	return stops, nil
+}
+
+// expands 3 digit hex colors, eg. #f80 to #ff8800
+func expandHex(s string) string {
+	if len(s) != 4 || s[0] != '#' {
+		return s
+	}
+	return string([]byte{'#', s[1], s[1], s[2], s[2], s[3], s[3]})
+}
+
+/*
+GIMP gradients are a header line, an optional name line, the number of segments, then a line per segment:
+"left middle right r0 g0 b0 a0 r1 g1 b1 a1 blending coloring" with values from 0 to 1, and
+optionally the left and right endpoint color types. Alpha is ignored.
+*/
+func readGGRStops(r io.Reader) ([]paletteStop, error) {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	next := func() (string, bool) {
+		for scanner.Scan() {
+			line++
+			if text := strings.TrimSpace(scanner.Text()); text != "" {
+				return text, true
+			}
+		}
+		return "", false
+	}
+
+	header, ok := next()
+	if !ok || header != "GIMP Gradient" {
+		return nil, fmt.Errorf("%w: line %d: expected 'GIMP Gradient' header", errInvalidPalette, line)
+	}
+	text, ok := next()
+	if ok && strings.HasPrefix(text, "Name:") {
+		text, ok = next()
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: line %d: expected the number of segments", errInvalidPalette, line)
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("%w: line %d: invalid number of segments '%s'", errInvalidPalette, line, text)
+	}
+
+	stops := make([]paletteStop, 0, 3*n)
+	for i := 0; i < n; i++ {
+		text, ok := next()
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d: expected %d segments, got %d", errInvalidPalette, line, n, i)
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 13 {
+			return nil, fmt.Errorf("%w: line %d: expected at least 13 values in segment, got %d", errInvalidPalette, line, len(fields))
+		}
+		var v [11]float64
+		for j := range v {
+			if v[j], err = strconv.ParseFloat(fields[j], 64); err != nil || v[j] < 0 || v[j] > 1 {
+				return nil, fmt.Errorf("%w: line %d: invalid value '%s'", errInvalidPalette, line, fields[j])
+			}
+		}
+		left, middle, right := v[0], v[1], v[2]
+		if left > middle || middle > right {
+			return nil, fmt.Errorf("%w: line %d: segment positions must ascend", errInvalidPalette, line)
+		}
+		if len(stops) > 0 && left < stops[len(stops)-1].pos {
+			return nil, fmt.Errorf("%w: line %d: segment overlaps the one before", errInvalidPalette, line)
+		}
+		c0, c1 := Color{v[3], v[4], v[5]}, Color{v[7], v[8], v[9]}
+		mid := Color{(c0[0] + c1[0]) / 2, (c0[1] + c1[1]) / 2, (c0[2] + c1[2]) / 2}
+		stops = append(stops, paletteStop{c0, left}, paletteStop{mid, middle}, paletteStop{c1, right})
+	}
+	return stops, nil
+}
+
+// writes stops as a CSS linear gradient, as parsed by ParsePalette
+func stopsToCSS(stops []paletteStop) string {
+	var b strings.Builder
+	b.WriteString("linear-gradient(90deg")
+	for _, s := range stops {
+		fmt.Fprintf(&b, ", rgb(%s, %s, %s) %s%%",
+			formatChannel(s.color[0]), formatChannel(s.color[1]), formatChannel(s.color[2]),
+			strconv.FormatFloat(s.pos*100, 'f', -1, 64))
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+func formatChannel(v float64) string {
+	return strconv.FormatFloat(v*255, 'f', -1, 64)
+}
@@ -20,15 +20,17 @@ const configName string = "config"
 
 // config values which can be set by command line args
 var (
-	configPath  string
-	hostArg     string
-	portArg     int
-	noLogoArg   bool
-	noTrayArg   bool
-	noUpdateArg bool
-	noScanArg   bool
-	openUiArg   bool
-	logLevelArg int
+	configPath    string
+	hostArg       string
+	portArg       int
+	noLogoArg     bool
+	noTrayArg     bool
+	noUpdateArg   bool
+	noScanArg     bool
+	openUiArg     bool
+	logLevelArg   int
+	metricsArg    bool
+	levelsRateArg int
 )
 
 var AllowSaving bool = true
@@ -42,14 +44,28 @@ var store *config = &config{
 }
 
 type BaseDeviceConfig struct {
-	PixelCount int    `mapstructure:"pixel_count" json:"pixel_count" description:"Number of pixels on the device" validate:"required"` // TODO be smarter about this
-	Name       string `mapstructure:"name" json:"name" description:"Display name for the device" validate:"required"`
+	PixelCount int     `mapstructure:"pixel_count" json:"pixel_count" description:"Number of pixels on the device" validate:"required"` // TODO be smarter about this
+	Name       string  `mapstructure:"name" json:"name" description:"Display name for the device" validate:"required"`
+	Gamma      float64 `mapstructure:"gamma" json:"gamma" description:"Gamma correction for perceptually linear fades. 1 disables it" default:"1" validate:"gte=0.1,lte=5"`
+	MaxFPS     int     `mapstructure:"max_fps" json:"max_fps" description:"Most frames per second sent to the device, extra frames are coalesced. 0 is unlimited" default:"0" validate:"gte=0,lte=240"`
+	// matrix devices are 2D panels, wired row after row
+	MatrixWidth      int  `mapstructure:"matrix_width" json:"matrix_width" description:"Pixels per row of a matrix panel. 0 for a strip" default:"0" validate:"gte=0,lte=1000"`
+	MatrixHeight     int  `mapstructure:"matrix_height" json:"matrix_height" description:"Rows of a matrix panel. 0 for a strip" default:"0" validate:"gte=0,lte=1000"`
+	MatrixSerpentine bool `mapstructure:"matrix_serpentine" json:"matrix_serpentine" description:"Every other row of the matrix is wired back right to left" default:"false" validate:""`
 }
 
 type ControllerConfig struct {
 	Name      string `mapstructure:"name" json:"name" description:"Display name for the controller" validate:"required"`
 	IconName  string `mapstructure:"icon_name" json:"icon_name" description:"Icon name to identify this controller" default:"alert-circle-outline" validate:""`
 	FrameRate int    `mapstructure:"framerate" json:"framerate" description:"Target framerate" default:"60" validate:"gte=5,lte=120"`
+	// idle mode takes over the devices while audio is silent
+	IdleTimeout  int    `mapstructure:"idle_timeout" json:"idle_timeout" description:"Seconds of silence before fading to the idle effect. 0 disables it" default:"0" validate:"gte=0,lte=3600"`
+	IdleEffect   string `mapstructure:"idle_effect" json:"idle_effect" description:"Idle effect, a solid color or a slowly scrolling gradient" default:"color" validate:"oneof=color gradient"`
+	IdleColor    string `mapstructure:"idle_color" json:"idle_color" description:"Color of the color idle effect" default:"#000000" validate:""`
+	IdleGradient string `mapstructure:"idle_gradient" json:"idle_gradient" description:"Gradient of the gradient idle effect" default:"rainbow" validate:""`
+	// transitions crossfade between effects when the controller's effect changes
+	TransitionTime   float64 `mapstructure:"transition_time" json:"transition_time" description:"Seconds to crossfade between effects. 0 cuts straight to the new effect" default:"1" validate:"gte=0,lte=5"`
+	TransitionEasing string  `mapstructure:"transition_easing" json:"transition_easing" description:"Easing curve of the crossfade between effects" default:"ease_in_out" validate:"oneof=linear ease_in_out"`
 	// Span      bool            `mapstructure:"span" json:"span"`
 	// Outputs   []ControllerOutput `mapstructure:"outputs" json:"outputs"`
 }
@@ -91,6 +107,8 @@ func init() {
 	pflag.BoolVarP(&noScanArg, "no_scan", "s", false, "Disable automatic WLED scanning and configuration in LedFx")
 	pflag.BoolVarP(&openUiArg, "open_ui", "o", false, "Automatically open the web interface at startup")
 	pflag.IntVarP(&logLevelArg, "log_level", "l", 2, "Set log level [0: debug, 1: info, 2: warnings]")
+	pflag.BoolVarP(&metricsArg, "metrics", "m", false, "Expose Prometheus metrics at /metrics")
+	pflag.IntVar(&levelsRateArg, "levels_rate", 30, "Rate in Hz at which audio levels are pushed to the web interface")
 	// pflag.BoolP("offline", "o", false, "Disable automated updates and sentry crash logger")
 
 	pflag.Parse()
@@ -103,12 +121,13 @@ func init() {
 
 	// validate all the command line args
 	SettingsConfigArgs := SettingsConfig{
-		Host:     hostArg,
-		Port:     portArg,
-		NoLogo:   noLogoArg,
-		OpenUi:   openUiArg,
-		NoUpdate: noUpdateArg,
-		LogLevel: logLevelArg,
+		Host:       hostArg,
+		Port:       portArg,
+		NoLogo:     noLogoArg,
+		OpenUi:     openUiArg,
+		NoUpdate:   noUpdateArg,
+		LogLevel:   logLevelArg,
+		LevelsRate: levelsRateArg,
 	}
 	err := validate.Struct(&SettingsConfigArgs)
 	if err != nil {
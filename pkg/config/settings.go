@@ -12,14 +12,16 @@ import (
 )
 
 type SettingsConfig struct {
-	Host     string `mapstructure:"host" json:"host" default:"0.0.0.0" validate:"ip" description:"Web interface hostname"`
-	Port     int    `mapstructure:"port" json:"port" default:"8080" validate:"gte=0,lte=65535" description:"Web interface port"`
-	NoLogo   bool   `mapstructure:"no_logo" json:"no_logo" default:"false" validate:"" description:"Hide the command line logo at startup"`
-	NoUpdate bool   `mapstructure:"no_update" json:"no_update" default:"false" validate:"" description:"Disable automatic updates at startup"`
-	NoTray   bool   `mapstructure:"no_tray" json:"no_tray" default:"false" validate:"" description:"Disable system tray icon to access LedFx"`
-	NoScan   bool   `mapstructure:"no_scan" json:"no_scan" default:"false" validate:"" description:"Disable automatic WLED scanning and configuration in LedFx"`
-	OpenUi   bool   `mapstructure:"open_ui" json:"open_ui" default:"false" validate:"" description:"Automatically open the web interface at startup"`
-	LogLevel int    `mapstructure:"log_level" json:"log_level" default:"2" validate:"gte=0,lte=2" description:"Set log level [0: debug, 1: info, 2: warnings]"`
+	Host       string `mapstructure:"host" json:"host" default:"0.0.0.0" validate:"ip" description:"Web interface hostname"`
+	Port       int    `mapstructure:"port" json:"port" default:"8080" validate:"gte=0,lte=65535" description:"Web interface port"`
+	NoLogo     bool   `mapstructure:"no_logo" json:"no_logo" default:"false" validate:"" description:"Hide the command line logo at startup"`
+	NoUpdate   bool   `mapstructure:"no_update" json:"no_update" default:"false" validate:"" description:"Disable automatic updates at startup"`
+	NoTray     bool   `mapstructure:"no_tray" json:"no_tray" default:"false" validate:"" description:"Disable system tray icon to access LedFx"`
+	NoScan     bool   `mapstructure:"no_scan" json:"no_scan" default:"false" validate:"" description:"Disable automatic WLED scanning and configuration in LedFx"`
+	OpenUi     bool   `mapstructure:"open_ui" json:"open_ui" default:"false" validate:"" description:"Automatically open the web interface at startup"`
+	LogLevel   int    `mapstructure:"log_level" json:"log_level" default:"2" validate:"gte=0,lte=2" description:"Set log level [0: debug, 1: info, 2: warnings]"`
+	Metrics    bool   `mapstructure:"metrics" json:"metrics" default:"false" validate:"" description:"Expose Prometheus metrics at /metrics"`
+	LevelsRate int    `mapstructure:"levels_rate" json:"levels_rate" default:"30" validate:"gte=1,lte=120" description:"Rate in Hz at which audio levels are pushed to the web interface"`
 }
 
 // Generate settings config schema
@@ -49,6 +51,8 @@ func GetSettings() SettingsConfig {
 	noTray := pflag.Lookup("no_tray")
 	openUi := pflag.Lookup("open_ui")
 	logLevel := pflag.Lookup("log_level")
+	metrics := pflag.Lookup("metrics")
+	levelsRate := pflag.Lookup("levels_rate")
 
 	if host.Changed {
 		settings.Host = hostArg
@@ -74,6 +78,12 @@ func GetSettings() SettingsConfig {
 	if logLevel.Changed {
 		settings.LogLevel = logLevelArg
 	}
+	if metrics.Changed {
+		settings.Metrics = metricsArg
+	}
+	if levelsRate.Changed {
+		settings.LevelsRate = levelsRateArg
+	}
 	return settings
 }
 
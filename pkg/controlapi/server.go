@@ -0,0 +1,151 @@
+package controlapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/LedFx/ledfx/pkg/audio/audiobridge"
+	"github.com/LedFx/ledfx/pkg/handlers/raop"
+	"github.com/LedFx/ledfx/pkg/handlers/rtsp"
+	"github.com/LedFx/ledfx/pkg/integrations/airplay2"
+	"github.com/LedFx/ledfx/pkg/logger"
+)
+
+// Server exposes the audio bridge JsonCTL actions over HTTP.
+//
+// Every action route takes a POST with a JSON body and answers with JSON.
+// JsonCTL itself stays transport-agnostic; this package only handles HTTP.
+type Server struct {
+	mux *http.ServeMux
+	ctl *audiobridge.JsonCTL
+}
+
+func NewServer(ctl *audiobridge.JsonCTL, mux *http.ServeMux) *Server {
+	s := &Server{
+		mux: mux,
+		ctl: ctl,
+	}
+
+	s.mux.HandleFunc("/api/airplay", s.post(s.handleAirPlay))
+	s.mux.HandleFunc("/api/airplay/routing", s.post(s.ctl.AirPlayRouting))
+	s.mux.HandleFunc("/api/airplay/restart", s.post(s.ctl.AirPlayRestart))
+	s.mux.HandleFunc("/api/airplay/volume", s.post(s.ctl.AirPlayVolume))
+	s.mux.HandleFunc("/api/airplay/clients", s.get(s.ctl.AirPlayGetClients))
+	s.mux.HandleFunc("/api/airplay/info", s.get(s.ctl.AirPlayGetInfo))
+	s.mux.HandleFunc("/api/capture", s.post(s.handleCapture))
+	s.mux.HandleFunc("/api/playback", s.post(s.handlePlayback))
+	s.mux.HandleFunc("/api/youtube", s.post(s.ctl.YouTubeSet))
+	s.mux.HandleFunc("/api/youtube/info", s.get(s.ctl.YouTubeGetInfo))
+	s.mux.HandleFunc("/api/log", s.post(s.ctl.Log))
+	s.mux.HandleFunc("/api/test_tone", s.post(s.handleTestTone))
+
+	return s
+}
+
+func (s *Server) handleAirPlay(body []byte) ([]byte, error) {
+	return nil, s.ctl.AirPlaySet(body)
+}
+
+func (s *Server) handleCapture(body []byte) ([]byte, error) {
+	return nil, s.ctl.Capture(body)
+}
+
+func (s *Server) handlePlayback(body []byte) ([]byte, error) {
+	return nil, s.ctl.Playback(body)
+}
+
+func (s *Server) handleTestTone(body []byte) ([]byte, error) {
+	return nil, s.ctl.TestTone(body)
+}
+
+// post wraps a JsonCTL action that takes the request body
+func (s *Server) post(action func(body []byte) ([]byte, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, fmt.Errorf("error reading request body: %w", err))
+			return
+		}
+		resp, err := action(body)
+		if err != nil {
+			writeError(w, r, statusCode(err), err)
+			return
+		}
+		writeJSON(w, resp)
+	}
+}
+
+// get wraps a JsonCTL query that takes no input
+func (s *Server) get(query func() ([]byte, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		resp, err := query()
+		if err != nil {
+			writeError(w, r, statusCode(err), err)
+			return
+		}
+		writeJSON(w, resp)
+	}
+}
+
+// allowMethod sets the CORS headers and reports whether the handler should continue.
+// Preflight requests are answered here.
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", method+", OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, cache-control")
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+		return false
+	case method:
+		return true
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, fmt.Errorf("method '%s' is not allowed", r.Method))
+		return false
+	}
+}
+
+// statusCode maps a JsonCTL error to the HTTP status that best describes it
+func statusCode(err error) int {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return http.StatusBadRequest
+	case errors.Is(err, audiobridge.ErrUnknownAction), errors.Is(err, audiobridge.ErrInvalidField), errors.Is(err, audiobridge.ErrInvalidLogLevel):
+		return http.StatusBadRequest
+	case errors.Is(err, airplay2.ErrInvalidVolume), errors.Is(err, airplay2.ErrInvalidVolumeCurve), errors.Is(err, airplay2.ErrInvalidBind):
+		return http.StatusBadRequest
+	case errors.Is(err, audiobridge.ErrNotActive), errors.Is(err, audiobridge.ErrTestToneActive), errors.Is(err, rtsp.ErrPortInUse), errors.Is(err, raop.ErrNoDacp):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, resp []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	if resp == nil {
+		resp = []byte("{}")
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	logger.Logger.WithField("context", "Control API").Errorf("Error handling %s %s: %v", r.Method, r.URL.Path, err)
+	b, _ := json.Marshal(map[string]string{"error": err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
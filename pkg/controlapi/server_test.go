@@ -0,0 +1,105 @@
+package controlapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LedFx/ledfx/pkg/audio"
+	"github.com/LedFx/ledfx/pkg/audio/audiobridge"
+	"github.com/LedFx/ledfx/pkg/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestServer(t *testing.T) *http.ServeMux {
+	br, err := audiobridge.NewBridge(func(buf audio.Buffer) {})
+	if err != nil {
+		t.Fatalf("Error initializing new bridge: %v", err)
+	}
+	mux := http.NewServeMux()
+	NewServer(br.JSONWrapper().CTL(), mux)
+	return mux
+}
+
+func TestStatusCodes(t *testing.T) {
+	mux := newTestServer(t)
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{http.MethodOptions, "/api/capture", "", http.StatusNoContent},
+		{http.MethodGet, "/api/capture", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/capture", "{", http.StatusBadRequest},
+		{http.MethodPost, "/api/capture", `{"action": 42}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/capture", `{"action": 0}`, http.StatusConflict},
+		{http.MethodPost, "/api/airplay", `{"action": "stop"}`, http.StatusConflict},
+		{http.MethodGet, "/api/airplay/info", "", http.StatusConflict},
+		{http.MethodPost, "/api/log", `{"action": "set_level", "level": "loud"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/log", `{"action": "get_level"}`, http.StatusOK},
+		{http.MethodPost, "/api/airplay/volume", `{"action": "get_volume"}`, http.StatusConflict},
+		{http.MethodPost, "/api/airplay/volume", `{"action": "louder"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/airplay", `{"action": "stop", "force": true}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/airplay", `{}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/airplay/restart", `{"action": "restart", "options": {"name": "Kitchen"}}`, http.StatusConflict},
+		{http.MethodPost, "/api/airplay/routing", `{"action": "stop"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/airplay/volume", `{"action": "set_volume", "volume": "loud"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/test_tone", `{"action": "hum"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/test_tone", `{"action": "play", "duration_ms": 60000}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/test_tone", `{"action": "play", "waveform": "saw"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/test_tone", `{"action": "stop"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s %q: Expected status %d, got %d (%s)", tt.method, tt.path, tt.body, tt.want, rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+			t.Errorf("%s %s: Expected CORS header to be set", tt.method, tt.path)
+		}
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	mux := newTestServer(t)
+	defer logger.Logger.SetLevel(logger.Logger.GetLevel())
+
+	for _, body := range []string{`{"action": "set_level", "level": "debug"}`, `{"action": "get_level"}`} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/log", strings.NewReader(body)))
+		if want := `{"level":"debug"}`; rec.Body.String() != want {
+			t.Errorf("Expected: %s\r\n Got: %s", want, rec.Body.String())
+		}
+	}
+}
+
+func TestRecentLogs(t *testing.T) {
+	mux := newTestServer(t)
+	logger.Logger.WithField("context", "Log Test").Warn("first")
+	logger.Logger.WithField("context", "Log Test").Error("second")
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/log", strings.NewReader(`{"action": "recent", "lines": 2}`)))
+	var got audiobridge.RecentLogs
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Error unmarshalling %s: %v", rec.Body.String(), err)
+	}
+	if len(got.Lines) != 2 || got.Lines[0].Message != "first" || got.Lines[1].Level != "error" || got.Lines[1].Context != "Log Test" {
+		t.Errorf("Expected: the last two lines, oldest first\r\n Got: %+v", got.Lines)
+	}
+
+	// a full ring drops its oldest lines
+	ring := logger.NewRingHook(2)
+	for _, msg := range []string{"a", "b", "c"} {
+		ring.Fire(&logrus.Entry{Message: msg, Level: logrus.InfoLevel})
+	}
+	if lines := ring.Recent(0); len(lines) != 2 || lines[0].Message != "b" || lines[1].Message != "c" {
+		t.Errorf("Expected: lines b and c\r\n Got: %+v", lines)
+	}
+}
@@ -61,12 +61,20 @@ func ConnectEffect(effectID, controllerID string) error {
 		// if it's already assigned to a controller, disconnect it first
 		if eID == effectID || vID == controllerID {
 			delete(connectionsEffect, eID)
-			otherv, _ := Get(vID)
-			otherv.Effect = nil
+			// this controller's effect is swapped below in one go, so it can crossfade to the new one
+			if otherv, _ := Get(vID); otherv != v {
+				otherv.setEffect(nil)
+			}
+		}
+	}
+	// an effect still fading out on another controller must stop rendering there first
+	for _, otherv := range controllerInstances {
+		if otherv.loop != nil {
+			otherv.loop.Release(e)
 		}
 	}
 	connectionsEffect[effectID] = controllerID
-	v.Effect = e
+	v.setEffect(e)
 	// if the controller has a device, initialise the effect with the pixel count
 	if len(v.Devices) != 0 {
 		v.Effect.UpdatePixelCount(v.PixelCount())
@@ -136,7 +144,7 @@ func DisconnectEffect(effectID, controllerID string) error {
 	}
 	delete(connectionsEffect, effectID)
 	v.Stop()
-	v.Effect = nil
+	v.setEffect(nil)
 	config.SetConnections(connectionsEffect, connectionsDevice)
 	// invoke event
 	event.Invoke(event.ConnectionsUpdate,
@@ -1,8 +1,11 @@
 package controller
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/LedFx/ledfx/pkg/audio"
+	"github.com/LedFx/ledfx/pkg/color"
 	"github.com/LedFx/ledfx/pkg/config"
 	"github.com/LedFx/ledfx/pkg/device"
 	"github.com/LedFx/ledfx/pkg/effect"
@@ -20,11 +23,16 @@ type Controller struct {
 	Devices map[string]*device.Device
 	State   bool
 	Config  config.ControllerConfig
-	ticker  *time.Ticker
-	done    chan bool
+	loop    *render.Loop
 	pixels  *render.PixelGroup
+	idle    *render.Idle // nil when idle mode is disabled
+	// crossfade between effects, nil when disabled
+	transition *render.Transition
 }
 
+// crossfade time in and out of idle mode
+const idleFade = 2 * time.Second
+
 func (v *Controller) Initialize(id string, c map[string]interface{}) (err error) {
 	v.ID = id
 	v.State = false
@@ -37,6 +45,11 @@ func (v *Controller) Initialize(id string, c map[string]interface{}) (err error)
 	if err != nil {
 		return err
 	}
+	v.idle, err = idleFromConfig(v.Config)
+	if err != nil {
+		return err
+	}
+	v.transition = transitionFromConfig(v.Config)
 	err = config.AddEntry(
 		v.ID,
 		config.ControllerEntry{
@@ -68,24 +81,64 @@ func (v *Controller) PixelCount() int {
 	return pc
 }
 
-func (v *Controller) renderLoop() {
-	for {
-		select {
-		case <-v.ticker.C:
-			if v.Effect == nil {
-				return
-			}
-			v.Effect.Render(v.pixels) // todo catch errors in send?
-			for _, d := range v.Devices {
-				d.Send(v.pixels.Group[d.ID])
-			}
-			// if err != nil {
-			// 	logger.Logger.WithField("context", "Controller").Error(err)
-			// }
-		case <-v.done:
-			return
+// sets the effect, swapping it into the render loop if the controller is running
+func (v *Controller) setEffect(e *effect.Effect) {
+	v.Effect = e
+	if v.loop == nil {
+		return
+	}
+	if e == nil {
+		v.loop.SetRenderer(nil)
+	} else {
+		v.loop.SetRenderer(e)
+	}
+}
+
+// builds the idle mode from config, returning nil when it is disabled
+func idleFromConfig(c config.ControllerConfig) (*render.Idle, error) {
+	if c.IdleTimeout == 0 {
+		return nil, nil
+	}
+	idle := &render.Idle{
+		Timeout:   time.Duration(c.IdleTimeout) * time.Second,
+		Fade:      idleFade,
+		SilentFor: audio.Analyzer.Silence.SilentFor,
+	}
+	var err error
+	switch c.IdleEffect {
+	case "gradient":
+		if idle.Palette, err = color.NewPalette(c.IdleGradient); err != nil {
+			return nil, fmt.Errorf("invalid idle gradient: %w", err)
+		}
+	default:
+		if idle.Color, err = color.NewColor(c.IdleColor); err != nil {
+			return nil, fmt.Errorf("invalid idle color: %w", err)
 		}
 	}
+	return idle, nil
+}
+
+// builds the transition between effects from config, returning nil when it is disabled
+func transitionFromConfig(c config.ControllerConfig) *render.Transition {
+	if c.TransitionTime == 0 {
+		return nil
+	}
+	t := &render.Transition{
+		Duration: time.Duration(c.TransitionTime * float64(time.Second)),
+		Easing:   render.EaseInOut,
+	}
+	if c.TransitionEasing == "linear" {
+		t.Easing = render.EaseLinear
+	}
+	return t
+}
+
+// Frame timing of the render loop
+func (v *Controller) Stats() render.LoopStats {
+	if v.loop == nil {
+		return render.LoopStats{}
+	}
+	return v.loop.Stats()
 }
 
 func (v *Controller) Start() error {
@@ -107,9 +160,18 @@ func (v *Controller) Start() error {
 	if err != nil {
 		logger.Logger.WithField("context", "Controller").Errorf("failed to start %s: %s", v.ID, err)
 	}
-	v.ticker = time.NewTicker(time.Duration(1000/v.Config.FrameRate) * time.Millisecond)
-	v.done = make(chan bool)
-	go v.renderLoop()
+	outputs := make(map[string]render.Output, len(v.Devices))
+	for id, d := range v.Devices {
+		outputs[id] = d
+	}
+	if v.loop != nil {
+		v.loop.Stop()
+	}
+	v.loop = render.NewLoop(v.Config.FrameRate, v.pixels, outputs)
+	v.loop.SetRenderer(v.Effect)
+	v.loop.SetIdle(v.idle)
+	v.loop.SetTransition(v.transition)
+	v.loop.Start()
 	v.State = true
 	logger.Logger.WithField("context", "Controllers").Infof("Activated %s", v.ID)
 	// invoke event
@@ -124,11 +186,8 @@ func (v *Controller) Start() error {
 }
 
 func (v *Controller) Stop() {
-	if v.ticker != nil {
-		v.ticker.Stop()
-	}
-	if v.done != nil {
-		v.done <- true
+	if v.loop != nil {
+		v.loop.Stop()
 	}
 	v.State = false
 	for _, d := range v.Devices {
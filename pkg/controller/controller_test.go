@@ -11,6 +11,7 @@ import (
 	"github.com/LedFx/ledfx/pkg/color"
 	"github.com/LedFx/ledfx/pkg/device"
 	"github.com/LedFx/ledfx/pkg/effect"
+	"github.com/LedFx/ledfx/pkg/render"
 )
 
 func TestController(t *testing.T) {
@@ -51,7 +52,7 @@ func TestController(t *testing.T) {
 		t.Error(err)
 	}
 
-	p := make(color.Pixels, bdc["pixel_count"].(int))
+	pg := testPixelGroup(d.ID, bdc["pixel_count"].(int))
 
 	br, err := audiobridge.NewBridge(audio.Analyzer.BufferCallback)
 	if err != nil {
@@ -75,8 +76,8 @@ func TestController(t *testing.T) {
 	for {
 		select {
 		case <-ticker.C:
-			e.Render(p)
-			err = d.Send(p)
+			e.Render(pg)
+			err = d.Send(pg.Group[d.ID])
 			if err != nil {
 				t.Error(err)
 			}
@@ -124,15 +125,86 @@ func BenchmarkController(t *testing.B) {
 		t.Error(err)
 	}
 
-	p := make(color.Pixels, bdc["pixel_count"].(int))
+	pg := testPixelGroup(d.ID, bdc["pixel_count"].(int))
 
 	t.Run(fmt.Sprintf("%d pixels", bdc["pixel_count"].(int)), func(t *testing.B) {
 		for i := 0; i < t.N; i++ {
-			e.Render(p)
-			err = d.Send(p)
+			e.Render(pg)
+			err = d.Send(pg.Group[d.ID])
 			if err != nil {
 				t.Error(err)
 			}
 		}
 	})
 }
+
+func testPixelGroup(id string, n int) *render.PixelGroup {
+	return &render.PixelGroup{
+		Group:      map[string]color.Pixels{id: make(color.Pixels, n)},
+		Order:      []string{id},
+		Largest:    id,
+		Smallest:   id,
+		LargestLen: n,
+		TotalLen:   n,
+	}
+}
+
+func TestConnectEffectTransition(t *testing.T) {
+	newController := func(id string) *Controller {
+		v, _, err := New(id, map[string]interface{}{"name": id, "transition_time": 5.0})
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, _, err := device.NewMock(8, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v.Devices[d.ID] = d
+		return v
+	}
+	a, b := newController("transition_a"), newController("transition_b")
+	defer Destroy(a.ID)
+	defer Destroy(b.ID)
+	e1, _, err := effect.New("transition_e1", "palette", 8, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2, _, err := effect.New("transition_e2", "fade", 8, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer effect.Destroy(e1.ID)
+	defer effect.Destroy(e2.ID)
+
+	if err := ConnectEffect(e1.ID, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer a.Stop()
+	time.Sleep(50 * time.Millisecond)
+
+	// swapping the controller's effect crossfades from the old one
+	if err := ConnectEffect(e2.ID, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if !a.loop.Transitioning() {
+		t.Error("Expected: crossfade after changing the effect")
+	}
+	if a.Effect != e2 {
+		t.Errorf("Expected: %s\r\n Got: %v", e2.ID, a.Effect)
+	}
+
+	// moving the outgoing effect to another controller stops the crossfade
+	if err := ConnectEffect(e1.ID, b.ID); err != nil {
+		t.Fatal(err)
+	}
+	if a.loop.Transitioning() {
+		t.Error("Expected: crossfade stopped once the outgoing effect moved to another controller")
+	}
+	if a.Effect != e2 || b.Effect != e1 {
+		t.Errorf("Expected: %s on a and %s on b\r\n Got: %v, %v", e2.ID, e1.ID, a.Effect, b.Effect)
+	}
+}
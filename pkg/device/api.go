@@ -37,6 +37,32 @@ func NewAPI(mux *http.ServeMux) {
 		}
 	})
 
+	mux.HandleFunc("/api/devices/reachable", func(writer http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+			s, err := json.Marshal(GetReachability())
+			if util.InternalError("Device API", err, writer) {
+				return
+			}
+			writer.Write(s)
+		default:
+			writer.WriteHeader(http.StatusNotImplemented)
+		}
+	})
+
+	mux.HandleFunc("/api/devices/framerate", func(writer http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+			s, err := json.Marshal(GetFrameRates())
+			if util.InternalError("Device API", err, writer) {
+				return
+			}
+			writer.Write(s)
+		default:
+			writer.WriteHeader(http.StatusNotImplemented)
+		}
+	})
+
 	mux.HandleFunc("/api/devices", func(writer http.ResponseWriter, request *http.Request) {
 		switch request.Method {
 		case http.MethodGet:
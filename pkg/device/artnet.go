@@ -1,11 +1,7 @@
 package device
 
 import (
-	"net"
-	"strconv"
-
 	"github.com/LedFx/ledfx/pkg/color"
-	"github.com/LedFx/ledfx/pkg/logger"
 
 	"github.com/creasty/defaults"
 	"github.com/mitchellh/mapstructure"
@@ -13,7 +9,7 @@ import (
 
 type ArtNet struct {
 	config     ArtNetConfig
-	connection net.Conn
+	connection *udpConn
 	pb         *packetBuilder
 }
 
@@ -40,31 +36,20 @@ func (d *ArtNet) initialize(base *Device, config map[string]interface{}) (err er
 
 func (d *ArtNet) send(p color.Pixels) (err error) {
 	d.pb.Build(p)
-	for i := range d.pb.packets {
-		_, err = d.connection.Write(d.pb.packets[i])
-	}
-	return err
+	return d.connection.write(d.pb.packets)
 }
 
 func (d *ArtNet) connect() (err error) {
-	service := d.config.IP + ":" + strconv.Itoa(d.config.Port)
-	remoteAddr, err := net.ResolveUDPAddr("udp", service)
-	if err != nil {
-		return err
-	}
-	conn, err := net.DialUDP("udp", nil, remoteAddr)
-	if err != nil {
-		return err
-	}
-	d.connection = conn
-	logger.Logger.Debugf("Established connection to %s \n", service)
-	logger.Logger.Debugf("Remote UDP address : %s \n", conn.RemoteAddr().String())
-	logger.Logger.Debugf("Local UDP client address : %s \n", conn.LocalAddr().String())
-	return nil
+	d.connection = newUDPConn(d.config.IP, d.config.Port)
+	return d.connection.open()
 }
 
 func (d *ArtNet) disconnect() error {
-	return d.connection.Close()
+	return d.connection.close()
+}
+
+func (d *ArtNet) reachable() bool {
+	return d.connection != nil && d.connection.isReachable()
 }
 
 func (d *ArtNet) getConfig() (c map[string]interface{}) {
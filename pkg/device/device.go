@@ -21,12 +21,22 @@ type PixelPusher interface {
 	getConfig() map[string]interface{} // pointer to config
 }
 
+// reachabilityReporter is implemented by pixel pushers which can tell whether their device is answering
+type reachabilityReporter interface {
+	reachable() bool
+}
+
 type Device struct {
 	ID          string
 	Type        string
 	pixelPusher PixelPusher
 	State       State
 	Config      config.BaseDeviceConfig
+	gamma       *GammaLUT    // nil when gamma is 1
+	scratch     color.Pixels // corrected pixels, so the caller's frame is left untouched
+	matrix      *MatrixMap   // nil for strips
+	mapped      color.Pixels // frame reordered into wiring order for a matrix
+	limiter     *frameLimiter
 }
 
 func (d *Device) Initialize(id string, baseConfig map[string]interface{}, implConfig map[string]interface{}) (err error) {
@@ -41,6 +51,23 @@ func (d *Device) Initialize(id string, baseConfig map[string]interface{}, implCo
 	if err != nil {
 		return err
 	}
+	d.matrix = nil
+	if d.Config.MatrixWidth != 0 || d.Config.MatrixHeight != 0 {
+		if d.matrix, err = NewMatrixMap(d.Config.MatrixWidth, d.Config.MatrixHeight, d.Config.MatrixSerpentine); err != nil {
+			return err
+		}
+		if err = d.matrix.Validate(d.Config.PixelCount); err != nil {
+			return err
+		}
+	}
+	d.gamma = nil
+	if d.Config.Gamma != 1 {
+		d.gamma = GammaTable(d.Config.Gamma)
+	}
+	if d.limiter != nil {
+		d.limiter.stop()
+	}
+	d.limiter = newFrameLimiter(d.Config.MaxFPS, d.pixelPusher.send)
 	err = d.pixelPusher.initialize(d, implConfig)
 	if err != nil {
 		return err
@@ -92,6 +119,9 @@ func (d *Device) Connect() (err error) {
 
 func (d *Device) Disconnect() (err error) {
 	d.State = Disconnecting
+	if d.limiter != nil {
+		d.limiter.stop()
+	}
 	err = d.pixelPusher.disconnect()
 	if err == nil {
 		d.State = Disconnected
@@ -114,7 +144,36 @@ func (d *Device) Send(p color.Pixels) (err error) {
 	if d.State != Connected {
 		return errors.New("device isn't connected")
 	}
-	return d.pixelPusher.send(p)
+	p = d.correct(d.mapMatrix(p))
+	if d.limiter == nil {
+		return d.pixelPusher.send(p)
+	}
+	return d.limiter.offer(p)
+}
+
+// Reachable reports whether the device is connected and, for network devices, frames are getting through.
+// Network devices which stop answering are reconnected in the background.
+func (d *Device) Reachable() bool {
+	if d.State != Connected {
+		return false
+	}
+	if r, ok := d.pixelPusher.(reachabilityReporter); ok {
+		return r.reachable()
+	}
+	return true
+}
+
+// MaxFPS is the configured cap on frames sent to the device. 0 is uncapped.
+func (d *Device) MaxFPS() int {
+	return d.Config.MaxFPS
+}
+
+// SendRate is the rate frames are actually being sent to the device, in frames per second
+func (d *Device) SendRate() float64 {
+	if d.limiter == nil {
+		return 0
+	}
+	return d.limiter.sendRate()
 }
 
 func (d *Device) FullConfig() (base, impl map[string]interface{}) {
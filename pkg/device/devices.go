@@ -142,6 +142,32 @@ func GetStates() map[string]State {
 	return states
 }
 
+// GetReachability reports whether each device is currently reachable
+func GetReachability() map[string]bool {
+	reachable := map[string]bool{}
+	for _, d := range deviceInstances {
+		reachable[d.ID] = d.Reachable()
+	}
+	return reachable
+}
+
+// FrameRate is a device's frame cap and the rate it is actually being sent frames
+type FrameRate struct {
+	MaxFPS  int     `json:"max_fps"`
+	SendFPS float64 `json:"send_fps"`
+}
+
+func GetFrameRates() map[string]FrameRate {
+	rates := map[string]FrameRate{}
+	for _, d := range deviceInstances {
+		rates[d.ID] = FrameRate{
+			MaxFPS:  d.MaxFPS(),
+			SendFPS: d.SendRate(),
+		}
+	}
+	return rates
+}
+
 func LoadFromConfig() error {
 	storedDevices := config.GetDevices()
 	for id, entry := range storedDevices {
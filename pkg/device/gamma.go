@@ -0,0 +1,78 @@
+package device
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/LedFx/ledfx/pkg/color"
+
+	"go.uber.org/atomic"
+)
+
+// GammaLUT maps an 8 bit channel value to its gamma corrected value
+type GammaLUT [256]byte
+
+var (
+	gammaMu     sync.Mutex
+	gammaTables = map[float64]*GammaLUT{}
+
+	// global brightness applied to every device before transmit
+	brightness = atomic.NewFloat64(1)
+)
+
+// GammaTable returns the lookup table for gamma. Tables are cached, so devices sharing a gamma share a table.
+func GammaTable(gamma float64) *GammaLUT {
+	gammaMu.Lock()
+	defer gammaMu.Unlock()
+	if t, ok := gammaTables[gamma]; ok {
+		return t
+	}
+	t := new(GammaLUT)
+	for i := range t {
+		t[i] = byte(math.Round(math.Pow(float64(i)/255, gamma) * 255))
+	}
+	gammaTables[gamma] = t
+	return t
+}
+
+// ApplyGamma corrects the pixels in place
+func (t *GammaLUT) ApplyGamma(p color.Pixels) {
+	for i := range p {
+		for k := 0; k < 3; k++ {
+			v := math.Min(math.Max(p[i][k], 0), 1)
+			p[i][k] = float64(t[byte(math.Round(v*255))]) / 255
+		}
+	}
+}
+
+// SetBrightness sets the global brightness multiplier, applied after effects and before transmit
+func SetBrightness(scale float64) error {
+	if scale < 0 || scale > 1 {
+		return fmt.Errorf("brightness %f must be between 0 and 1", scale)
+	}
+	brightness.Store(scale)
+	return nil
+}
+
+func Brightness() float64 {
+	return brightness.Load()
+}
+
+// applies global brightness then gamma to p, writing into the device's scratch buffer
+func (d *Device) correct(p color.Pixels) color.Pixels {
+	scale := Brightness()
+	if scale == 1 && d.gamma == nil {
+		return p
+	}
+	if len(d.scratch) != len(p) {
+		d.scratch = make(color.Pixels, len(p))
+	}
+	for i := range p {
+		d.scratch[i] = color.Color{p[i][0] * scale, p[i][1] * scale, p[i][2] * scale}
+	}
+	if d.gamma != nil {
+		d.gamma.ApplyGamma(d.scratch)
+	}
+	return d.scratch
+}
@@ -0,0 +1,42 @@
+package device
+
+import (
+	"testing"
+
+	"github.com/LedFx/ledfx/pkg/color"
+)
+
+func TestGammaTable(t *testing.T) {
+	lut := GammaTable(2.2)
+	if lut[0] != 0 || lut[255] != 255 || lut[128] != 56 {
+		t.Errorf("Expected: 0, 56, 255\r\n Got: %d, %d, %d", lut[0], lut[128], lut[255])
+	}
+	if GammaTable(2.2) != lut {
+		t.Error("Expected: cached table to be reused")
+	}
+}
+
+func TestSendCorrection(t *testing.T) {
+	d, m, err := NewMock(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.gamma = GammaTable(2)
+	if err := SetBrightness(0.5); err != nil {
+		t.Fatal(err)
+	}
+	defer SetBrightness(1)
+
+	p := color.Pixels{{1, 1, 1}}
+	d.Send(p)
+	// 0.5 brightness, then gamma 2 -> 0.25
+	if got := m.Last()[0][0]; got < 0.24 || got > 0.26 {
+		t.Errorf("Expected: ~0.25\r\n Got: %f", got)
+	}
+	if p[0][0] != 1 {
+		t.Errorf("Expected: caller's pixels untouched\r\n Got: %f", p[0][0])
+	}
+	if err := SetBrightness(2); err == nil {
+		t.Error("Expected: error for brightness above 1")
+	}
+}
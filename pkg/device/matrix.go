@@ -0,0 +1,60 @@
+package device
+
+import (
+	"fmt"
+
+	"github.com/LedFx/ledfx/pkg/color"
+)
+
+// MatrixMap translates positions on a 2D panel of LEDs, wired row after row, into indexes along the wiring.
+// Effects render into a framebuffer of Width pixels per row, top row first, which Map reorders for transmit.
+type MatrixMap struct {
+	Width      int
+	Height     int
+	Serpentine bool // every other row is wired back the other way, right to left
+}
+
+func NewMatrixMap(width, height int, serpentine bool) (*MatrixMap, error) {
+	if width < 1 || height < 1 {
+		return nil, fmt.Errorf("invalid matrix dimensions %dx%d", width, height)
+	}
+	return &MatrixMap{Width: width, Height: height, Serpentine: serpentine}, nil
+}
+
+// Validate checks the matrix covers exactly pixelCount pixels
+func (m *MatrixMap) Validate(pixelCount int) error {
+	if m.Width*m.Height != pixelCount {
+		return fmt.Errorf("a %dx%d matrix has %d pixels, not %d", m.Width, m.Height, m.Width*m.Height, pixelCount)
+	}
+	return nil
+}
+
+// Index returns the position along the wiring of the pixel x from the left on row y from the top
+func (m *MatrixMap) Index(x, y int) int {
+	if m.Serpentine && y%2 == 1 {
+		x = m.Width - 1 - x
+	}
+	return y*m.Width + x
+}
+
+// Map reorders a framebuffer into dst in wiring order. Both must be Width*Height long.
+func (m *MatrixMap) Map(dst, frame color.Pixels) {
+	for y := 0; y < m.Height; y++ {
+		row := frame[y*m.Width : (y+1)*m.Width]
+		for x, c := range row {
+			dst[m.Index(x, y)] = c
+		}
+	}
+}
+
+// reorders a framebuffer into wiring order for a matrix device. Strips, and frames which don't fill the matrix, pass through.
+func (d *Device) mapMatrix(p color.Pixels) color.Pixels {
+	if d.matrix == nil || len(p) != d.matrix.Width*d.matrix.Height {
+		return p
+	}
+	if len(d.mapped) != len(p) {
+		d.mapped = make(color.Pixels, len(p))
+	}
+	d.matrix.Map(d.mapped, p)
+	return d.mapped
+}
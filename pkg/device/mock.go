@@ -0,0 +1,160 @@
+package device
+
+import (
+	"fmt"
+	"image"
+	imgcolor "image/color"
+	"image/png"
+	"os"
+	"sync"
+
+	"github.com/LedFx/ledfx/pkg/color"
+	"github.com/LedFx/ledfx/pkg/config"
+
+	"github.com/creasty/defaults"
+	"github.com/mitchellh/mapstructure"
+)
+
+// Mock is a loopback pixel pusher. Instead of sending pixels anywhere it keeps the most
+// recent frames in a ring so effects can be rendered and inspected without hardware.
+type Mock struct {
+	config MockConfig
+	mu     sync.Mutex
+	frames []color.Pixels
+	next   int // ring index the next frame is written to
+	count  int // total frames received
+}
+
+type MockConfig struct {
+	Frames int `mapstructure:"frames" json:"frames" description:"Number of recent frames to keep" default:"16" validate:"gte=1,lte=4096"`
+}
+
+// NewMock creates a connected device backed by a Mock which keeps the last n frames.
+// The device is not registered or saved to config.
+func NewMock(pixelCount, n int) (*Device, *Mock, error) {
+	m := &Mock{}
+	d := &Device{
+		ID:          "mock",
+		Type:        "mock",
+		pixelPusher: m,
+		Config: config.BaseDeviceConfig{
+			PixelCount: pixelCount,
+			Name:       "Mock",
+			Gamma:      1,
+		},
+	}
+	if err := m.initialize(d, map[string]interface{}{"frames": n}); err != nil {
+		return nil, nil, err
+	}
+	d.State = Connected
+	return d, m, nil
+}
+
+func (m *Mock) initialize(base *Device, config map[string]interface{}) (err error) {
+	defaults.Set(&m.config)
+	err = mapstructure.Decode(&config, &m.config)
+	if err != nil {
+		return err
+	}
+	err = validate.Struct(&m.config)
+	if err != nil {
+		return err
+	}
+	m.frames = make([]color.Pixels, m.config.Frames)
+	return nil
+}
+
+func (m *Mock) send(p color.Pixels) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// the caller reuses its pixel buffer, so keep a copy
+	frame := make(color.Pixels, len(p))
+	copy(frame, p)
+	m.frames[m.next] = frame
+	m.next = (m.next + 1) % len(m.frames)
+	m.count++
+	return nil
+}
+
+func (m *Mock) connect() error {
+	return nil
+}
+
+func (m *Mock) disconnect() error {
+	return nil
+}
+
+func (m *Mock) getConfig() (c map[string]interface{}) {
+	mapstructure.Decode(&m.config, &c)
+	return c
+}
+
+// Count returns the total number of frames received, including those no longer kept
+func (m *Mock) Count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.count
+}
+
+// Frames returns the kept frames, oldest first
+func (m *Mock) Frames() []color.Pixels {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := m.count
+	if n > len(m.frames) {
+		n = len(m.frames)
+	}
+	frames := make([]color.Pixels, n)
+	start := (m.next - n + len(m.frames)) % len(m.frames)
+	for i := range frames {
+		frames[i] = m.frames[(start+i)%len(m.frames)]
+	}
+	return frames
+}
+
+// Last returns the most recent frame, or nil if nothing has been sent yet
+func (m *Mock) Last() color.Pixels {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.count == 0 {
+		return nil
+	}
+	return m.frames[(m.next-1+len(m.frames))%len(m.frames)]
+}
+
+// Reset discards all kept frames
+func (m *Mock) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.frames = make([]color.Pixels, len(m.frames))
+	m.next = 0
+	m.count = 0
+}
+
+// WritePNG saves the kept frames as an image, one row per frame, oldest at the top
+func (m *Mock) WritePNG(filename string) error {
+	frames := m.Frames()
+	if len(frames) == 0 {
+		return fmt.Errorf("no frames to write")
+	}
+	img := image.NewRGBA(image.Rect(0, 0, len(frames[0]), len(frames)))
+	for y, frame := range frames {
+		for x, c := range frame {
+			img.SetRGBA(x, y, imgcolor.RGBA{
+				R: byte(c[0] * 255),
+				G: byte(c[1] * 255),
+				B: byte(c[2] * 255),
+				A: 255,
+			})
+		}
+	}
+	fi, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("error creating file '%s': %w", filename, err)
+	}
+	defer fi.Close()
+	if err := png.Encode(fi, img); err != nil {
+		return fmt.Errorf("error encoding png: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package device
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/LedFx/ledfx/pkg/color"
+)
+
+func TestMockRing(t *testing.T) {
+	d, m, err := NewMock(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Last() != nil {
+		t.Errorf("Expected: no frames\r\n Got: %v", m.Last())
+	}
+	p := make(color.Pixels, 2)
+	for i := 0; i < 5; i++ {
+		p[0] = color.Color{float64(i) / 4, 0, 0}
+		if err := d.Send(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.Count() != 5 {
+		t.Errorf("Expected: 5 frames received\r\n Got: %d", m.Count())
+	}
+	frames := m.Frames()
+	if len(frames) != 3 {
+		t.Fatalf("Expected: 3 frames kept\r\n Got: %d", len(frames))
+	}
+	for i, want := range []float64{0.5, 0.75, 1} {
+		if frames[i][0][0] != want {
+			t.Errorf("Expected: frame %d red %f\r\n Got: %f", i, want, frames[i][0][0])
+		}
+	}
+	if m.Last()[0][0] != 1 {
+		t.Errorf("Expected: last frame red 1\r\n Got: %f", m.Last()[0][0])
+	}
+	if err := m.WritePNG(filepath.Join(t.TempDir(), "frames.png")); err != nil {
+		t.Error(err)
+	}
+	m.Reset()
+	if len(m.Frames()) != 0 {
+		t.Errorf("Expected: no frames after reset\r\n Got: %d", len(m.Frames()))
+	}
+}
@@ -0,0 +1,109 @@
+package device
+
+import (
+	"sync"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/color"
+	"github.com/LedFx/ledfx/pkg/logger"
+)
+
+// frameLimiter caps the rate frames are sent to a device. Frames arriving faster than the cap
+// are coalesced: only the latest is kept, and it is sent when the next slot opens.
+// It also measures the rate frames actually go out, with or without a cap.
+type frameLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time    // when the last frame was sent
+	pending  color.Pixels // latest frame waiting for a slot
+	timer    *time.Timer  // armed while a frame is pending
+	send     func(p color.Pixels) error
+
+	// send rate, measured over windows of about a second
+	windowStart time.Time
+	windowSent  int
+	rate        float64
+}
+
+// newFrameLimiter caps sends at maxFPS. 0 is uncapped.
+func newFrameLimiter(maxFPS int, send func(p color.Pixels) error) *frameLimiter {
+	l := &frameLimiter{
+		send:        send,
+		windowStart: time.Now(),
+	}
+	if maxFPS > 0 {
+		l.interval = time.Second / time.Duration(maxFPS)
+	}
+	return l
+}
+
+// offer sends p now if a slot is open, otherwise keeps a copy to send when one opens
+func (l *frameLimiter) offer(p color.Pixels) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if wait := l.last.Add(l.interval).Sub(now); wait > 0 {
+		if cap(l.pending) < len(p) {
+			l.pending = make(color.Pixels, len(p))
+		}
+		l.pending = l.pending[:len(p)]
+		copy(l.pending, p)
+		if l.timer == nil {
+			l.timer = time.AfterFunc(wait, l.flush)
+		}
+		return nil
+	}
+	return l.sendLocked(p, now)
+}
+
+// flush sends the pending frame once its slot opens
+func (l *frameLimiter) flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.timer = nil
+	if len(l.pending) == 0 {
+		return
+	}
+	if err := l.sendLocked(l.pending, time.Now()); err != nil {
+		logger.Logger.WithField("context", "Device").Warnf("Error sending coalesced frame: %v", err)
+	}
+}
+
+// must be called with mu held
+func (l *frameLimiter) sendLocked(p color.Pixels, now time.Time) error {
+	// a frame sent directly supersedes any pending one
+	l.pending = l.pending[:0]
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	l.last = now
+	l.windowSent++
+	if elapsed := now.Sub(l.windowStart); elapsed >= time.Second {
+		l.rate = float64(l.windowSent) / elapsed.Seconds()
+		l.windowStart, l.windowSent = now, 0
+	}
+	return l.send(p)
+}
+
+// sendRate is the measured rate frames were sent at, in frames per second
+func (l *frameLimiter) sendRate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// a stalled sender reports its rate falling rather than the last full window
+	if elapsed := time.Since(l.windowStart); elapsed > 2*time.Second {
+		return float64(l.windowSent) / elapsed.Seconds()
+	}
+	return l.rate
+}
+
+// stop discards any pending frame
+func (l *frameLimiter) stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = nil
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+}
@@ -0,0 +1,48 @@
+package device
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/color"
+)
+
+func TestFrameLimiterCoalesces(t *testing.T) {
+	var mu sync.Mutex
+	var sent []float64
+	l := newFrameLimiter(20, func(p color.Pixels) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, p[0][0])
+		return nil
+	})
+
+	// the first frame goes straight out, the rest arrive inside its 50ms slot
+	for i := 0; i < 5; i++ {
+		if err := l.offer(color.Pixels{{float64(i), 0, 0}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 2 || sent[0] != 0 || sent[1] != 4 {
+		t.Errorf("Expected: first and latest frames sent [0 4]\r\n Got: %v", sent)
+	}
+}
+
+func TestFrameLimiterUncapped(t *testing.T) {
+	n := 0
+	l := newFrameLimiter(0, func(p color.Pixels) error {
+		n++
+		return nil
+	})
+	for i := 0; i < 5; i++ {
+		l.offer(color.Pixels{{}})
+	}
+	if n != 5 {
+		t.Errorf("Expected: 5 frames sent\r\n Got: %d", n)
+	}
+}
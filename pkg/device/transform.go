@@ -0,0 +1,30 @@
+package device
+
+import "github.com/LedFx/ledfx/pkg/color"
+
+// Reverse flips the pixels in place, for strips mounted end to start
+func Reverse(p color.Pixels) {
+	for i, j := 0, len(p)-1; i < j; i, j = i+1, j-1 {
+		p[i], p[j] = p[j], p[i]
+	}
+}
+
+// Rotate shifts the pixels in place so pixel i moves to i+offset, wrapping around the end.
+// Negative offsets rotate the other way, and offsets longer than p wrap.
+func Rotate(p color.Pixels, offset int) {
+	n := len(p)
+	if n == 0 {
+		return
+	}
+	k := offset % n
+	if k < 0 {
+		k += n
+	}
+	if k == 0 {
+		return
+	}
+	// rotating right by k is three reversals
+	Reverse(p)
+	Reverse(p[:k])
+	Reverse(p[k:])
+}
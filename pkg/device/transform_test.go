@@ -0,0 +1,119 @@
+package device
+
+import (
+	"testing"
+
+	"github.com/LedFx/ledfx/pkg/color"
+)
+
+func testPixels(n int) color.Pixels {
+	p := make(color.Pixels, n)
+	for i := range p {
+		p[i] = color.Color{float64(i), 0, 0}
+	}
+	return p
+}
+
+func reds(p color.Pixels) []float64 {
+	out := make([]float64, len(p))
+	for i := range p {
+		out[i] = p[i][0]
+	}
+	return out
+}
+
+func equalReds(p color.Pixels, want []float64) bool {
+	got := reds(p)
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestReverse(t *testing.T) {
+	for n, want := range map[int][]float64{
+		0: {},
+		1: {0},
+		4: {3, 2, 1, 0},
+		5: {4, 3, 2, 1, 0},
+	} {
+		p := testPixels(n)
+		Reverse(p)
+		if !equalReds(p, want) {
+			t.Errorf("Expected: %v\r\n Got: %v", want, reds(p))
+		}
+	}
+}
+
+func TestRotate(t *testing.T) {
+	for _, c := range []struct {
+		n, offset int
+		want      []float64
+	}{
+		{5, 0, []float64{0, 1, 2, 3, 4}},
+		{5, 2, []float64{3, 4, 0, 1, 2}},
+		{5, -1, []float64{1, 2, 3, 4, 0}},
+		{5, 12, []float64{3, 4, 0, 1, 2}},
+		{5, -13, []float64{3, 4, 0, 1, 2}},
+		{4, 5, []float64{3, 0, 1, 2}},
+		{0, 3, []float64{}},
+	} {
+		p := testPixels(c.n)
+		Rotate(p, c.offset)
+		if !equalReds(p, c.want) {
+			t.Errorf("Expected: rotate %d by %d to give %v\r\n Got: %v", c.n, c.offset, c.want, reds(p))
+		}
+	}
+}
+
+func TestMatrixMap(t *testing.T) {
+	if _, err := NewMatrixMap(0, 4, false); err == nil {
+		t.Error("Expected: error for an empty matrix")
+	}
+	m, err := NewMatrixMap(3, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Validate(5); err == nil {
+		t.Error("Expected: error validating a 3x2 matrix against 5 pixels")
+	}
+	if err := m.Validate(6); err != nil {
+		t.Error(err)
+	}
+	// the second row runs back right to left
+	if got := m.Index(0, 1); got != 5 {
+		t.Errorf("Expected: 5\r\n Got: %d", got)
+	}
+	dst := make(color.Pixels, 6)
+	m.Map(dst, testPixels(6))
+	if want := []float64{0, 1, 2, 5, 4, 3}; !equalReds(dst, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, reds(dst))
+	}
+	m.Serpentine = false
+	m.Map(dst, testPixels(6))
+	if want := []float64{0, 1, 2, 3, 4, 5}; !equalReds(dst, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, reds(dst))
+	}
+
+	// devices send frames in wiring order, leaving the caller's frame untouched
+	d, mock, err := NewMock(6, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.matrix, _ = NewMatrixMap(2, 3, true)
+	frame := testPixels(6)
+	if err := d.Send(frame); err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{0, 1, 3, 2, 4, 5}; !equalReds(mock.Last(), want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, reds(mock.Last()))
+	}
+	if !equalReds(frame, []float64{0, 1, 2, 3, 4, 5}) {
+		t.Errorf("Expected: frame untouched\r\n Got: %v", reds(frame))
+	}
+}
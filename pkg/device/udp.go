@@ -1,11 +1,7 @@
 package device
 
 import (
-	"net"
-	"strconv"
-
 	"github.com/LedFx/ledfx/pkg/color"
-	"github.com/LedFx/ledfx/pkg/logger"
 
 	"github.com/creasty/defaults"
 	"github.com/mitchellh/mapstructure"
@@ -13,7 +9,7 @@ import (
 
 type UDP struct {
 	config     UDPConfig
-	connection net.Conn
+	connection *udpConn
 	pb         *packetBuilder
 }
 
@@ -42,31 +38,20 @@ func (d *UDP) initialize(base *Device, config map[string]interface{}) (err error
 
 func (d *UDP) send(p color.Pixels) (err error) {
 	d.pb.Build(p)
-	for i := range d.pb.packets {
-		_, err = d.connection.Write(d.pb.packets[i])
-	}
-	return err
+	return d.connection.write(d.pb.packets)
 }
 
 func (d *UDP) connect() (err error) {
-	service := d.config.IP + ":" + strconv.Itoa(d.config.Port)
-	remoteAddr, err := net.ResolveUDPAddr("udp", service)
-	if err != nil {
-		return err
-	}
-	conn, err := net.DialUDP("udp", nil, remoteAddr)
-	if err != nil {
-		return err
-	}
-	d.connection = conn
-	logger.Logger.Debugf("Established connection to %s \n", service)
-	logger.Logger.Debugf("Remote UDP address : %s \n", conn.RemoteAddr().String())
-	logger.Logger.Debugf("Local UDP client address : %s \n", conn.LocalAddr().String())
-	return nil
+	d.connection = newUDPConn(d.config.IP, d.config.Port)
+	return d.connection.open()
 }
 
 func (d *UDP) disconnect() error {
-	return d.connection.Close()
+	return d.connection.close()
+}
+
+func (d *UDP) reachable() bool {
+	return d.connection != nil && d.connection.isReachable()
 }
 
 func (d *UDP) getConfig() (c map[string]interface{}) {
@@ -0,0 +1,135 @@
+package device
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/logger"
+)
+
+const (
+	reconnectMinBackoff = 250 * time.Millisecond
+	reconnectMaxBackoff = 10 * time.Second
+)
+
+var errReconnecting = fmt.Errorf("controller unreachable, waiting to reconnect")
+
+// udpConn is a connected UDP socket to a controller which reopens itself when sends fail.
+// A controller that reboots can leave the socket stale, and the host answering with ICMP
+// port unreachable shows up as an error on a later write. The socket is then closed and
+// re-resolved with exponential backoff, dropping frames until it is back. Dialing UDP
+// succeeds whether or not anything is listening, so the controller only counts as back
+// once writes have gone through for reconnectMinBackoff without an error.
+type udpConn struct {
+	service string
+
+	mu        sync.Mutex
+	conn      net.Conn
+	reachable bool
+	failures  int
+	retryAt   time.Time
+	dialedAt  time.Time
+}
+
+func newUDPConn(ip string, port int) *udpConn {
+	return &udpConn{
+		service: net.JoinHostPort(ip, fmt.Sprint(port)),
+	}
+}
+
+// open resolves and dials the controller. Failing to open is returned, rather than retried.
+func (c *udpConn) open() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.dialLocked(); err != nil {
+		return err
+	}
+	c.reachable = true
+	c.failures = 0
+	logger.Logger.Debugf("Established connection to %s \n", c.service)
+	logger.Logger.Debugf("Remote UDP address : %s \n", c.conn.RemoteAddr().String())
+	logger.Logger.Debugf("Local UDP client address : %s \n", c.conn.LocalAddr().String())
+	return nil
+}
+
+// must be called with mu held
+func (c *udpConn) dialLocked() error {
+	remoteAddr, err := net.ResolveUDPAddr("udp", c.service)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialUDP("udp", nil, remoteAddr)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+	c.dialedAt = time.Now()
+	return nil
+}
+
+// write sends each packet, reopening the socket if it has gone stale
+func (c *udpConn) write(packets [][]byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		if time.Now().Before(c.retryAt) {
+			return errReconnecting
+		}
+		if err := c.dialLocked(); err != nil {
+			c.failLocked(err)
+			return fmt.Errorf("error reconnecting to %s: %w", c.service, err)
+		}
+	}
+	for _, p := range packets {
+		if _, err := c.conn.Write(p); err != nil {
+			c.failLocked(err)
+			return err
+		}
+	}
+	if !c.reachable && time.Since(c.dialedAt) >= reconnectMinBackoff {
+		logger.Logger.WithField("context", "UDP").Infof("Reconnected to %s", c.service)
+		c.reachable = true
+		c.failures = 0
+	}
+	return nil
+}
+
+// must be called with mu held
+func (c *udpConn) failLocked(err error) {
+	if c.reachable {
+		logger.Logger.WithField("context", "UDP").Warnf("Lost connection to %s, reconnecting: %v", c.service, err)
+	}
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	c.reachable = false
+	backoff := reconnectMinBackoff << c.failures
+	if backoff > reconnectMaxBackoff || backoff <= 0 {
+		backoff = reconnectMaxBackoff
+	} else {
+		c.failures++
+	}
+	c.retryAt = time.Now().Add(backoff)
+}
+
+// isReachable reports whether the last send got through
+func (c *udpConn) isReachable() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reachable
+}
+
+func (c *udpConn) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reachable = false
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
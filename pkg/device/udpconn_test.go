@@ -0,0 +1,86 @@
+package device
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestUDPConnReconnects(t *testing.T) {
+	l, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.LocalAddr().(*net.UDPAddr).Port
+	c := newUDPConn("127.0.0.1", port)
+	if err := c.open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.close()
+	if err := c.write([][]byte{{1}}); err != nil || !c.isReachable() {
+		t.Fatalf("Expected: write to a listening controller\r\n Got: %v", err)
+	}
+
+	// the controller goes away, so the host answers with port unreachable
+	l.Close()
+	deadline := time.Now().Add(time.Second)
+	for c.isReachable() && time.Now().Before(deadline) {
+		c.write([][]byte{{1}})
+		time.Sleep(10 * time.Millisecond)
+	}
+	if c.isReachable() {
+		t.Skip("port unreachable not reported on this platform")
+	}
+	if err := c.write([][]byte{{1}}); !errors.Is(err, errReconnecting) {
+		t.Errorf("Expected: %v during backoff\r\n Got: %v", errReconnecting, err)
+	}
+
+	// the controller comes back on the same port
+	if l, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	deadline = time.Now().Add(2 * time.Second)
+	for !c.isReachable() && time.Now().Before(deadline) {
+		c.write([][]byte{{1}})
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !c.isReachable() || c.failures != 0 {
+		t.Errorf("Expected: reconnected after backoff\r\n Got: reachable %v, %d failures", c.isReachable(), c.failures)
+	}
+}
+
+func TestUDPConnBackoffGrows(t *testing.T) {
+	l, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newUDPConn("127.0.0.1", l.LocalAddr().(*net.UDPAddr).Port)
+	if err := c.open(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.close()
+	l.Close()
+
+	// redialing succeeds each period, but the controller stays away
+	deadline := time.Now().Add(3 * time.Second)
+	reachable := 0
+	for c.failures < 3 && time.Now().Before(deadline) {
+		c.write([][]byte{{1}})
+		if c.isReachable() {
+			reachable++
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if c.failures == 0 {
+		t.Skip("port unreachable not reported on this platform")
+	}
+	if c.failures < 3 {
+		t.Errorf("Expected: backoff to grow over several periods\r\n Got: %d failures", c.failures)
+	}
+	// only the writes before the first error was reported find it reachable
+	if reachable > 2 {
+		t.Errorf("Expected: unreachable while the controller is away\r\n Got: reachable for %d writes", reachable)
+	}
+}
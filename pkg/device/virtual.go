@@ -0,0 +1,137 @@
+package device
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/LedFx/ledfx/pkg/color"
+)
+
+// Segment binds part of a virtual strip to a device
+type Segment struct {
+	Device  *Device
+	Start   int  // first pixel of the virtual strip in this segment
+	End     int  // one past the last pixel
+	Offset  int  // device pixel the segment starts at
+	Reverse bool // the segment runs backwards along the device
+	Rotate  int  // pixels the segment is rotated by, after reversal. See Rotate.
+}
+
+func (s Segment) len() int {
+	return s.End - s.Start
+}
+
+// Virtual is one logical strip split across several devices, such as a run of LEDs
+// driven by more than one controller. Its segments cover the strip with no gaps or overlaps,
+// and segments sharing a device use separate pixels of it.
+type Virtual struct {
+	length   int
+	segments []Segment
+
+	mu     sync.Mutex
+	frames map[*Device]color.Pixels // per device frame, reused between renders
+}
+
+// NewVirtual validates the segments of a strip of length pixels and returns the virtual device
+func NewVirtual(length int, segments []Segment) (*Virtual, error) {
+	if length <= 0 {
+		return nil, fmt.Errorf("virtual length %d must be positive", length)
+	}
+	segs := append([]Segment(nil), segments...)
+	sort.Slice(segs, func(i, j int) bool { return segs[i].Start < segs[j].Start })
+
+	next := 0
+	onDevice := map[*Device][]Segment{}
+	for _, s := range segs {
+		switch {
+		case s.Device == nil:
+			return nil, fmt.Errorf("segment %d-%d has no device", s.Start, s.End)
+		case s.Start > next:
+			return nil, fmt.Errorf("gap in segments between pixels %d and %d", next, s.Start)
+		case s.Start < next:
+			return nil, fmt.Errorf("segment %d-%d overlaps the previous segment", s.Start, s.End)
+		case s.End <= s.Start:
+			return nil, fmt.Errorf("segment %d-%d is empty", s.Start, s.End)
+		case s.Offset < 0 || s.Offset+s.len() > s.Device.Config.PixelCount:
+			return nil, fmt.Errorf("segment %d-%d at offset %d doesn't fit device %s with %d pixels", s.Start, s.End, s.Offset, s.Device.ID, s.Device.Config.PixelCount)
+		}
+		for _, o := range onDevice[s.Device] {
+			if s.Offset < o.Offset+o.len() && o.Offset < s.Offset+s.len() {
+				return nil, fmt.Errorf("segments %d-%d and %d-%d overlap on device %s", o.Start, o.End, s.Start, s.End, s.Device.ID)
+			}
+		}
+		onDevice[s.Device] = append(onDevice[s.Device], s)
+		next = s.End
+	}
+	if next != length {
+		return nil, fmt.Errorf("segments cover %d of %d pixels", next, length)
+	}
+
+	return &Virtual{
+		length:   length,
+		segments: segs,
+		frames:   map[*Device]color.Pixels{},
+	}, nil
+}
+
+// Length is the number of pixels in the virtual strip
+func (v *Virtual) Length() int {
+	return v.length
+}
+
+// Segments returns the segments ordered along the strip
+func (v *Virtual) Segments() []Segment {
+	return append([]Segment(nil), v.segments...)
+}
+
+// Render fans the pixels out to the segments' devices, sending each device one frame.
+// Device pixels outside every segment are left dark.
+func (v *Virtual) Render(p color.Pixels) error {
+	if len(p) != v.length {
+		return fmt.Errorf("got %d pixels for a virtual strip of %d", len(p), v.length)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// the frames are reused, so clear them before writing the segments
+	var order []*Device
+	for _, s := range v.segments {
+		frame, ok := v.frames[s.Device]
+		if !ok || len(frame) != s.Device.Config.PixelCount {
+			frame = make(color.Pixels, s.Device.Config.PixelCount)
+			v.frames[s.Device] = frame
+		}
+		if !contains(order, s.Device) {
+			for i := range frame {
+				frame[i] = color.Color{}
+			}
+			order = append(order, s.Device)
+		}
+	}
+	for _, s := range v.segments {
+		dst := v.frames[s.Device][s.Offset : s.Offset+s.len()]
+		copy(dst, p[s.Start:s.End])
+		if s.Reverse {
+			Reverse(dst)
+		}
+		Rotate(dst, s.Rotate)
+	}
+
+	var firstErr error
+	for _, d := range order {
+		if err := d.Send(v.frames[d]); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("error sending to device %s: %w", d.ID, err)
+		}
+	}
+	return firstErr
+}
+
+func contains(devices []*Device, d *Device) bool {
+	for _, x := range devices {
+		if x == d {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package device
+
+import (
+	"testing"
+
+	"github.com/LedFx/ledfx/pkg/color"
+)
+
+func TestVirtualRender(t *testing.T) {
+	a, ma, err := NewMock(3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, mb, err := NewMock(4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := NewVirtual(5, []Segment{
+		{Device: b, Start: 2, End: 5, Offset: 1, Reverse: true},
+		{Device: a, Start: 0, End: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := make(color.Pixels, 5)
+	for i := range p {
+		p[i] = color.Color{float64(i + 1), 0, 0}
+	}
+	if err := v.Render(p); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ma.Last(), []float64{1, 2, 0}; !equalReds(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, reds(got))
+	}
+	if got, want := mb.Last(), []float64{0, 5, 4, 3}; !equalReds(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, reds(got))
+	}
+}
+
+func TestVirtualValidate(t *testing.T) {
+	d, _, err := NewMock(4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, segs := range map[string][]Segment{
+		"gap":         {{Device: d, Start: 0, End: 1}, {Device: d, Start: 2, End: 4, Offset: 1}},
+		"overlap":     {{Device: d, Start: 0, End: 2}, {Device: d, Start: 1, End: 4}},
+		"short":       {{Device: d, Start: 0, End: 3}},
+		"no device":   {{Start: 0, End: 4}},
+		"too long":    {{Device: d, Start: 0, End: 4, Offset: 1}},
+		"same pixels": {{Device: d, Start: 0, End: 2}, {Device: d, Start: 2, End: 4, Offset: 1}},
+	} {
+		if _, err := NewVirtual(4, segs); err == nil {
+			t.Errorf("Expected: error for %s segments", name)
+		}
+	}
+
+	// segments can share a device on separate pixels
+	if _, err := NewVirtual(3, []Segment{{Device: d, Start: 0, End: 2, Offset: 2}, {Device: d, Start: 2, End: 3}}); err != nil {
+		t.Errorf("Expected: no error for segments on separate pixels\r\n Got: %v", err)
+	}
+}
@@ -48,6 +48,9 @@ func NewAPI(mux *http.ServeMux) {
 			if util.InternalError("Effects API", err, writer) {
 				return
 			}
+			if data.ExtraConfig != nil && !updateExtraConfig(effect, data.ExtraConfig, writer) {
+				return
+			}
 			c, _ := config.GetEffect(data.ID)
 			b, err := json.Marshal(c)
 			if util.InternalError("Effects API", err, writer) {
@@ -63,10 +66,15 @@ func NewAPI(mux *http.ServeMux) {
 			if util.BadRequest("Effects API", err, writer) {
 				return
 			}
-			_, id, err := New(data.ID, data.Type, 100, data.BaseConfig)
+			effect, id, err := New(data.ID, data.Type, 100, data.BaseConfig)
 			if util.InternalError("Effects API", err, writer) {
 				return
 			}
+			if data.ExtraConfig != nil && !updateExtraConfig(effect, data.ExtraConfig, writer) {
+				// don't leave an effect behind with the default config in place of the one asked for
+				Destroy(id)
+				return
+			}
 			c, err := config.GetEffect(id)
 			if util.InternalError("Effects API", err, writer) {
 				return
@@ -128,3 +136,16 @@ func NewAPI(mux *http.ServeMux) {
 		}
 	})
 }
+
+// updateExtraConfig applies and saves c, writing the error response if it fails. Config the
+// effect rejects is a bad request, while failing to save it is an internal error.
+func updateExtraConfig(effect *Effect, c map[string]interface{}, writer http.ResponseWriter) (ok bool) {
+	raw, err := json.Marshal(c)
+	if util.BadRequest("Effects API", err, writer) {
+		return false
+	}
+	if util.BadRequest("Effects API", effect.setExtraConfig(raw), writer) {
+		return false
+	}
+	return !util.InternalError("Effects API", effect.save(), writer)
+}
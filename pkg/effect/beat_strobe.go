@@ -0,0 +1,79 @@
+package effect
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/audio"
+	"github.com/LedFx/ledfx/pkg/color"
+	"github.com/LedFx/ledfx/pkg/render"
+)
+
+// Flashes the whole strip on each beat, then fades it out
+type BeatStrobe struct {
+	config    BeatStrobeConfig
+	lastOnset time.Time // latest onset seen, so each one flashes once
+	flashAt   time.Time // start of the current flash
+}
+
+type BeatStrobeConfig struct {
+	Mode          string  `mapstructure:"mode" json:"mode" description:"Flash white, a single color from the palette, or the full palette across the strip" default:"white" validate:"oneof=white color palette"`
+	ColorPosition float64 `mapstructure:"color_position" json:"color_position" description:"Position on the palette of the flash color, in color mode" default:"0" validate:"gte=0,lte=1"`
+	FlashTime     float64 `mapstructure:"flash_time" json:"flash_time" description:"Seconds each flash holds at full brightness" default:"0.05" validate:"gte=0,lte=1"`
+	FadeTime      float64 `mapstructure:"fade_time" json:"fade_time" description:"Seconds each flash takes to fade out after holding" default:"0.2" validate:"gte=0,lte=2"`
+	Sensitivity   float64 `mapstructure:"sensitivity" json:"sensitivity" description:"How readily onsets flash. Low values only flash on a steady beat" default:"0.8" validate:"gte=0,lte=1"`
+}
+
+func (e *BeatStrobe) Config() interface{} {
+	return e.config
+}
+
+func (e *BeatStrobe) SetConfig(raw json.RawMessage) error {
+	return decodeConfig(raw, &e.config)
+}
+
+// Apply new pixels to an existing pixel array.
+func (e *BeatStrobe) assembleFrame(base *Effect, pg *render.PixelGroup) {
+	// operate on the largest pixel output in group, then clone to others
+	p := pg.Group[pg.Largest]
+
+	onset := audio.Analyzer.RecentOnset
+	switch {
+	case e.lastOnset.IsZero():
+		// an onset from before the effect started doesn't flash
+		e.lastOnset = onset
+	case onset.After(e.lastOnset):
+		e.lastOnset = onset
+		// onsets are only trusted as beats when audio is playing and the tempo is confident enough
+		if audio.Analyzer.Silence.SilentFor() == 0 && audio.Analyzer.Tempo.Confidence() >= 1-e.config.Sensitivity {
+			e.flashAt = onset
+		}
+	}
+
+	value := 0.0
+	if !e.flashAt.IsZero() {
+		value = flashEnvelope(time.Since(e.flashAt).Seconds(), e.config.FlashTime, e.config.FadeTime)
+	}
+	for i := range p {
+		switch e.config.Mode {
+		case "palette":
+			p[i] = color.Color{float64(i) / base.pixelScaler, 1, value}
+		case "color":
+			p[i] = color.Color{e.config.ColorPosition, 1, value}
+		default:
+			p[i] = color.Color{0, 0, value}
+		}
+	}
+	pg.CloneToAll(pg.Largest)
+}
+
+// brightness t seconds into a flash which holds for hold seconds, then fades out linearly over fade seconds
+func flashEnvelope(t, hold, fade float64) float64 {
+	switch {
+	case t < hold:
+		return 1
+	case t < hold+fade:
+		return 1 - (t-hold)/fade
+	}
+	return 0
+}
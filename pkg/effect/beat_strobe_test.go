@@ -0,0 +1,103 @@
+package effect
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/audio"
+	"github.com/LedFx/ledfx/pkg/color"
+)
+
+func TestFlashEnvelope(t *testing.T) {
+	cases := []struct {
+		t, hold, fade, want float64
+	}{
+		{0, 0.05, 0.2, 1},
+		{0.04, 0.05, 0.2, 1},
+		{0.05, 0.05, 0.2, 1},
+		{0.15, 0.05, 0.2, 0.5},
+		{0.25, 0.05, 0.2, 0},
+		{1, 0.05, 0.2, 0},
+		// no hold fades straight away, no fade cuts straight to black
+		{0.1, 0, 0.2, 0.5},
+		{0.01, 0.05, 0, 1},
+		{0.05, 0.05, 0, 0},
+		{0, 0, 0, 0},
+	}
+	for _, c := range cases {
+		if got := flashEnvelope(c.t, c.hold, c.fade); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("Expected: %f at %fs with hold %f, fade %f\r\n Got: %f", c.want, c.t, c.hold, c.fade, got)
+		}
+	}
+}
+
+// a tracker which has seen a steady beat up to now, so is fully confident
+func confidentTempo() *audio.TempoTracker {
+	tt := audio.NewTempoTracker()
+	start := time.Now().Add(-8 * time.Second)
+	for i := 0; i <= 16; i++ {
+		tt.Onset(start.Add(time.Duration(i) * 500 * time.Millisecond))
+	}
+	return tt
+}
+
+func TestBeatStrobeGate(t *testing.T) {
+	onset, silence, tempo := audio.Analyzer.RecentOnset, audio.Analyzer.Silence, audio.Analyzer.Tempo
+	defer func() {
+		audio.Analyzer.RecentOnset, audio.Analyzer.Silence, audio.Analyzer.Tempo = onset, silence, tempo
+	}()
+
+	playing := audio.NewSilenceDetector(audio.DefaultSilenceThreshold)
+	playing.Update(0)
+	// a new detector counts as quiet until it hears something
+	silent := audio.NewSilenceDetector(audio.DefaultSilenceThreshold)
+
+	cases := []struct {
+		name        string
+		silence     *audio.SilenceDetector
+		tempo       *audio.TempoTracker
+		sensitivity float64
+		newOnset    bool
+		flash       bool
+	}{
+		{"onset on a confident beat", playing, confidentTempo(), 0.8, true, true},
+		{"no new onset", playing, confidentTempo(), 0.8, false, false},
+		{"onset during silence", silent, confidentTempo(), 0.8, true, false},
+		{"onset without a tempo", playing, audio.NewTempoTracker(), 0.8, true, false},
+		{"onset without a tempo at full sensitivity", playing, audio.NewTempoTracker(), 1, true, true},
+		{"onset on a confident beat at no sensitivity", playing, confidentTempo(), 0, true, true},
+	}
+	for _, c := range cases {
+		e := &BeatStrobe{}
+		if err := e.SetConfig(nil); err != nil {
+			t.Fatal(err)
+		}
+		e.config.Sensitivity = c.sensitivity
+		pg := testPixelGroup(make(color.Pixels, 10))
+		base := &Effect{pixelScaler: 9}
+		audio.Analyzer.Silence, audio.Analyzer.Tempo = c.silence, c.tempo
+
+		// the first frame takes the latest onset as already seen
+		audio.Analyzer.RecentOnset = time.Now().Add(-time.Second)
+		e.assembleFrame(base, pg)
+		if !e.flashAt.IsZero() {
+			t.Errorf("%s: Expected: no flash for an onset from before the effect started", c.name)
+		}
+
+		if c.newOnset {
+			audio.Analyzer.RecentOnset = time.Now()
+		}
+		e.assembleFrame(base, pg)
+		if flashed := !e.flashAt.IsZero(); flashed != c.flash {
+			t.Errorf("%s: Expected: flash %v\r\n Got: %v", c.name, c.flash, flashed)
+		}
+		want := 0.0
+		if c.flash {
+			want = 1
+		}
+		if v := pg.Group[pg.Largest][0][2]; v != want {
+			t.Errorf("%s: Expected: brightness %f\r\n Got: %f", c.name, want, v)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package effect
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/LedFx/ledfx/pkg/audio"
+	"github.com/LedFx/ledfx/pkg/color"
+	"github.com/LedFx/ledfx/pkg/logger"
+	"github.com/LedFx/ledfx/pkg/render"
+)
+
+// longest frame gap the pattern moves across, so a stalled or first frame doesn't jump it along the strip
+const chaseMaxStep = 0.1
+
+// Moves a pattern along the strip, faster the louder the audio
+type Chase struct {
+	config ChaseConfig
+	travel float64 // pixels moved so far
+}
+
+type ChaseConfig struct {
+	Pattern       string  `mapstructure:"pattern" json:"pattern" description:"Shape moved along the strip" default:"gradient" validate:"oneof=gradient bars dots"`
+	PatternLength int     `mapstructure:"pattern_length" json:"pattern_length" description:"Length of the pattern in pixels" default:"20" validate:"gte=1,lte=1000"`
+	Source        string  `mapstructure:"source" json:"source" description:"Audio level which sets the speed" default:"volume" validate:"oneof=volume lows mids highs"`
+	Speed         float64 `mapstructure:"speed" json:"speed" description:"Pixels per second moved at full audio level" default:"60" validate:"gte=0,lte=500"`
+	Wrap          bool    `mapstructure:"wrap" json:"wrap" description:"Repeat the pattern along the strip, wrapping around at the end. Otherwise one copy bounces between the ends" default:"true" validate:""`
+}
+
+func (e *Chase) Config() interface{} {
+	return e.config
+}
+
+func (e *Chase) SetConfig(raw json.RawMessage) error {
+	return decodeConfig(raw, &e.config)
+}
+
+// Apply new pixels to an existing pixel array.
+func (e *Chase) assembleFrame(base *Effect, pg *render.PixelGroup) {
+	// operate on the largest pixel output in group, then clone to others
+	p := pg.Group[pg.Largest]
+
+	level, err := e.level(base)
+	if err != nil {
+		logger.Logger.WithField("context", "Effect Chase").Error(err)
+		return
+	}
+	dt := math.Min(base.deltaPrevFrame.Seconds(), chaseMaxStep)
+	e.travel += e.config.Speed * level * dt
+
+	// travel is kept within one repeat of the movement, so it doesn't grow without bound
+	length := float64(e.config.PatternLength)
+	var offset float64
+	if e.config.Wrap {
+		e.travel = math.Mod(e.travel, length)
+		offset = e.travel
+	} else {
+		// bounce the start of the pattern between the two ends of the strip
+		span := math.Max(float64(len(p))-length, 1)
+		e.travel = math.Mod(e.travel, 2*span)
+		offset = span - math.Abs(e.travel-span)
+	}
+	for i := range p {
+		x := (float64(i) - offset) / length
+		if e.config.Wrap {
+			x -= math.Floor(x)
+		} else if x < 0 || x >= 1 {
+			p[i] = color.Color{0, 0, 0}
+			continue
+		}
+		p[i] = e.pattern(x)
+	}
+	pg.CloneToAll(pg.Largest)
+}
+
+// the audio level driving the speed, from 0 to 1
+func (e *Chase) level(base *Effect) (float64, error) {
+	if e.config.Source == "volume" {
+		return math.Max(0, math.Min(audio.Analyzer.Vol.Volume, 1)), nil
+	}
+	mel, err := audio.Analyzer.GetMelbank(base.ID)
+	if err != nil {
+		return 0, err
+	}
+	switch e.config.Source {
+	case "lows":
+		return mel.LowsAmplitude(), nil
+	case "mids":
+		return mel.MidsAmplitude(), nil
+	}
+	return mel.HighAmplitude(), nil
+}
+
+// the color x of the way along the pattern, from 0 to 1
+func (e *Chase) pattern(x float64) color.Color {
+	switch e.config.Pattern {
+	case "bars":
+		if x < 0.5 {
+			return color.Color{x * 2, 1, 1}
+		}
+		return color.Color{0, 0, 0}
+	case "dots":
+		return color.Color{0.5, 1, math.Pow(1-2*math.Abs(x-0.5), 4)}
+	}
+	return color.Color{x, 1, 1}
+}
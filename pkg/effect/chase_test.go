@@ -0,0 +1,87 @@
+package effect
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/audio"
+	"github.com/LedFx/ledfx/pkg/color"
+)
+
+// lit returns the indexes of pixels at full brightness
+func lit(p color.Pixels) (idx []int) {
+	for i, c := range p {
+		if c[2] == 1 {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+func TestChaseMovement(t *testing.T) {
+	volume := audio.Analyzer.Vol.Volume
+	defer func() { audio.Analyzer.Vol.Volume = volume }()
+
+	// 4 pixel bars moving 20 pixels per second at full level, 1 pixel per 50ms frame
+	cases := []struct {
+		name   string
+		wrap   bool
+		level  float64
+		frames int
+		lit    []int
+	}{
+		{"wrap after one frame", true, 1, 1, []int{1, 2, 5, 6, 9}},
+		{"wrap at half level", true, 0.5, 2, []int{1, 2, 5, 6, 9}},
+		{"wrap a whole pattern along", true, 1, 5, []int{1, 2, 5, 6, 9}},
+		{"wrap from a standstill", true, 0, 3, []int{0, 1, 4, 5, 8, 9}},
+		{"bounce after one frame", false, 1, 1, []int{1, 2}},
+		{"bounce back from the far end", false, 1, 8, []int{4, 5}},
+		{"bounce back to the start", false, 1, 12, []int{0, 1}},
+	}
+	for _, c := range cases {
+		e := &Chase{}
+		if err := e.SetConfig(nil); err != nil {
+			t.Fatal(err)
+		}
+		conf := fmt.Sprintf(`{"pattern": "bars", "pattern_length": 4, "speed": 20, "wrap": %v}`, c.wrap)
+		if err := e.SetConfig(json.RawMessage(conf)); err != nil {
+			t.Fatal(err)
+		}
+		audio.Analyzer.Vol.Volume = c.level
+		base := &Effect{deltaPrevFrame: 50 * time.Millisecond}
+		pg := testPixelGroup(make(color.Pixels, 10))
+		for i := 0; i < c.frames; i++ {
+			e.assembleFrame(base, pg)
+		}
+		if got := lit(pg.Group[pg.Largest]); fmt.Sprint(got) != fmt.Sprint(c.lit) {
+			t.Errorf("%s: Expected: pixels %v lit\r\n Got: %v", c.name, c.lit, got)
+		}
+	}
+}
+
+func TestChaseTravelBounded(t *testing.T) {
+	volume := audio.Analyzer.Vol.Volume
+	defer func() { audio.Analyzer.Vol.Volume = volume }()
+	audio.Analyzer.Vol.Volume = 1
+
+	for _, wrap := range []bool{true, false} {
+		e := &Chase{}
+		if err := e.SetConfig(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.SetConfig(json.RawMessage(fmt.Sprintf(`{"pattern_length": 4, "speed": 500, "wrap": %v}`, wrap))); err != nil {
+			t.Fatal(err)
+		}
+		base := &Effect{deltaPrevFrame: 100 * time.Millisecond}
+		pg := testPixelGroup(make(color.Pixels, 10))
+		for i := 0; i < 1000; i++ {
+			e.assembleFrame(base, pg)
+		}
+		// one pattern length when wrapping, there and back across the 6 pixel span when bouncing
+		if limit := map[bool]float64{true: 4, false: 12}[wrap]; e.travel < 0 || e.travel >= limit {
+			t.Errorf("Expected: travel within [0, %f) with wrap %v\r\n Got: %f", limit, wrap, e.travel)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"reflect"
 	"time"
 
 	"github.com/LedFx/ledfx/pkg/audio"
@@ -27,6 +28,16 @@ type PixelGenerator interface {
 	assembleFrame(base *Effect, pixelGroup *render.PixelGroup)
 }
 
+/*
+Configurable is implemented by effect types with parameters of their own, beyond BaseEffectConfig.
+Config returns the typed parameter struct. SetConfig decodes json over it, keeping any keys not given,
+or resets it to defaults when given nil.
+*/
+type Configurable interface {
+	Config() interface{}
+	SetConfig(json.RawMessage) error
+}
+
 type Effect struct {
 	ID             string
 	Type           string
@@ -127,31 +138,100 @@ func (e *Effect) UpdateBaseConfig(c interface{}) (err error) {
 	// apply config to effect
 	e.Config = newConfig
 
-	// save to config store
+	return e.save()
+}
+
+// Returns the config of the effect type, or nil if it has none beyond the base config
+func (e *Effect) ExtraConfig() interface{} {
+	if c, ok := e.pixelGenerator.(Configurable); ok {
+		return c.Config()
+	}
+	return nil
+}
+
+/*
+Updates the config of the effect type from raw json.
+Keys not given keep their current values, and nil resets the config to defaults.
+*/
+func (e *Effect) UpdateExtraConfig(raw json.RawMessage) error {
+	if err := e.setExtraConfig(raw); err != nil {
+		return err
+	}
+	return e.save()
+}
+
+// applies extra config without saving it. Errors are config the effect rejects.
+func (e *Effect) setExtraConfig(raw json.RawMessage) error {
+	c, ok := e.pixelGenerator.(Configurable)
+	if !ok {
+		return fmt.Errorf("effect type %s has no extra config", e.Type)
+	}
+	return c.SetConfig(raw)
+}
+
+// saves the effect to the config store and notifies listeners of its new config
+func (e *Effect) save() error {
 	mapConfig := map[string]interface{}{}
-	err = mapstructure.Decode(newConfig, &mapConfig)
+	err := mapstructure.Decode(e.Config, &mapConfig)
 	if err != nil {
 		return err
 	}
+	var extraConfig map[string]interface{}
+	if extra := e.ExtraConfig(); extra != nil {
+		extraConfig = map[string]interface{}{}
+		if err = mapstructure.Decode(extra, &extraConfig); err != nil {
+			return err
+		}
+	}
 	err = config.AddEntry(
 		e.ID,
 		config.EffectEntry{
-			ID:         e.ID,
-			Type:       e.Type,
-			BaseConfig: mapConfig,
+			ID:          e.ID,
+			Type:        e.Type,
+			BaseConfig:  mapConfig,
+			ExtraConfig: extraConfig,
 		},
 	)
 
 	// invoke event
 	event.Invoke(event.EffectUpdate,
 		map[string]interface{}{
-			"id":          e.ID,
-			"type":        e.Type,
-			"base_config": mapConfig,
+			"id":           e.ID,
+			"type":         e.Type,
+			"base_config":  mapConfig,
+			"extra_config": extraConfig,
 		})
 	return err
 }
 
+/*
+Decodes raw json over an effect type's config struct, pointed to by c.
+The result is validated before it is kept. nil sets the config to defaults.
+*/
+func decodeConfig(raw json.RawMessage, c interface{}) error {
+	// decode into a fresh copy, so c is untouched if decoding or validation fails
+	newConfig := reflect.New(reflect.TypeOf(c).Elem())
+	if raw == nil {
+		if err := defaults.Set(newConfig.Interface()); err != nil {
+			return err
+		}
+	} else {
+		newConfig.Elem().Set(reflect.ValueOf(c).Elem())
+		if err := json.Unmarshal(raw, newConfig.Interface()); err != nil {
+			return fmt.Errorf("error decoding config: %w", err)
+		}
+	}
+	if errs, ok := validate.Struct(newConfig.Interface()).(validator.ValidationErrors); ok {
+		errString := "Validation Errors: "
+		for _, err := range errs {
+			errString += fmt.Sprintf("Field %s with value %v; ", err.Field(), err.Value())
+		}
+		return errors.New(errString)
+	}
+	reflect.ValueOf(c).Elem().Set(newConfig.Elem())
+	return nil
+}
+
 // updates properties and objects which are generated from the config
 // eg. melbanks, made using the config frequency range; palette, which is generated from the palette string
 func (e *Effect) updateStoredProperties(newConfig BaseEffectConfig) {
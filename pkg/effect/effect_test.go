@@ -79,8 +79,7 @@ func TestEffectBaseFunctions(t *testing.T) {
 	_ = GetIDs()
 
 	// Run the effect on some pixels
-	p := make(color.Pixels, 100)
-	effect.Render(p)
+	effect.Render(testPixelGroup(make(color.Pixels, 100)))
 
 	// Try to update with an invalid json
 	c["nonsense"] = "data" // unknown keys are discarded
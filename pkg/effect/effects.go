@@ -1,6 +1,7 @@
 package effect
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -27,7 +28,7 @@ type EffectInfo struct {
 }
 
 /*
-NEW EFFECTS MUST BE REGISTERED IN THIS MAP AND FUNCTION =====================
+NEW EFFECTS MUST BE REGISTERED IN THESE MAPS =====================
 */
 
 // Order them nicely and only create a new category if you need ;)
@@ -50,6 +51,12 @@ var effectTypes = map[string]EffectInfo{
 		Category:    "Audio Reactive",
 		Preview:     []byte{},
 	},
+	"beat_strobe": {
+		Description: "Flashes the whole strip on each beat, fading out between beats",
+		GoodFor:     []string{"Steady beats", "Techno", "Dance"},
+		Category:    "Audio Reactive",
+		Preview:     []byte{},
+	},
 	"palette": {
 		Description: "Displays the full color palette",
 		GoodFor:     []string{"Ambience", "Static colors"},
@@ -110,67 +117,43 @@ var effectTypes = map[string]EffectInfo{
 		Category:    "Audio Reactive",
 		Preview:     []byte{},
 	},
+	"chase": {
+		Description: "A repeating pattern chasing along the strip, faster as the music gets louder",
+		GoodFor:     []string{"Most music", "Crowds", "Building energy"},
+		Category:    "Audio Reactive",
+		Preview:     []byte{},
+	},
+}
+
+// Constructors for each effect type, by the same names as effectTypes
+var generators = map[string]func() PixelGenerator{
+	"energy":            func() PixelGenerator { return &Energy{} },
+	"weave":             func() PixelGenerator { return &Weave{} },
+	"strobe":            func() PixelGenerator { return &Strobe{} },
+	"beat_strobe":       func() PixelGenerator { return &BeatStrobe{} },
+	"palette":           func() PixelGenerator { return &Palette{} },
+	"fade":              func() PixelGenerator { return &Fade{} },
+	"pulse":             func() PixelGenerator { return &Pulse{} },
+	"wavelength":        func() PixelGenerator { return &Wavelegth{} },
+	"block_reflections": func() PixelGenerator { return &BlockReflections{} },
+	"millipede":         func() PixelGenerator { return &Millipede{} },
+	"glitch":            func() PixelGenerator { return &Glitch{} },
+	"twinkle":           func() PixelGenerator { return &Twinkle{} },
+	"maelstrom":         func() PixelGenerator { return &Maelstrom{} },
+	"scroll":            func() PixelGenerator { return &Scroll{} },
+	"chase":             func() PixelGenerator { return &Chase{} },
 }
 
 // Creates a new effect and returns its unique id.
 // You can supply an ID. If an effect exists with this id, it will be destroyed and overwriten with this new effect
 func New(new_id, effect_type string, pixelCount int, new_config interface{}) (effect *Effect, id string, err error) {
-	switch effect_type {
-	case "energy":
-		effect = &Effect{
-			pixelGenerator: &Energy{},
-		}
-	case "palette":
-		effect = &Effect{
-			pixelGenerator: &Palette{},
-		}
-	case "fade":
-		effect = &Effect{
-			pixelGenerator: &Fade{},
-		}
-	case "weave":
-		effect = &Effect{
-			pixelGenerator: &Weave{},
-		}
-	case "pulse":
-		effect = &Effect{
-			pixelGenerator: &Pulse{},
-		}
-	case "strobe":
-		effect = &Effect{
-			pixelGenerator: &Strobe{},
-		}
-	case "wavelength":
-		effect = &Effect{
-			pixelGenerator: &Wavelegth{},
-		}
-	case "block_reflections":
-		effect = &Effect{
-			pixelGenerator: &BlockReflections{},
-		}
-	case "millipede":
-		effect = &Effect{
-			pixelGenerator: &Millipede{},
-		}
-	case "glitch":
-		effect = &Effect{
-			pixelGenerator: &Glitch{},
-		}
-	case "twinkle":
-		effect = &Effect{
-			pixelGenerator: &Twinkle{},
-		}
-	case "maelstrom":
-		effect = &Effect{
-			pixelGenerator: &Maelstrom{},
-		}
-	case "scroll":
-		effect = &Effect{
-			pixelGenerator: &Scroll{},
-		}
-	default:
+	newGenerator, ok := generators[effect_type]
+	if !ok {
 		return effect, id, fmt.Errorf("'%s' is not a known effect type. Has it been registered in effects.go?", effect_type)
 	}
+	effect = &Effect{
+		pixelGenerator: newGenerator(),
+	}
 	effect.Type = effect_type
 
 	if new_id != "" { // if an id is given, use it
@@ -199,6 +182,13 @@ func New(new_id, effect_type string, pixelCount int, new_config interface{}) (ef
 		Destroy(id)
 		return effect, id, err
 	}
+	// Set the effect type's own config to defaults
+	if c, ok := effect.pixelGenerator.(Configurable); ok {
+		if err = c.SetConfig(nil); err != nil {
+			Destroy(id)
+			return effect, id, err
+		}
+	}
 	// update with any given config
 	if err = effect.UpdateBaseConfig(new_config); err != nil {
 		logger.Logger.WithField("context", "Effects").Warnf("Effect %s created with invalid config - aborting", id)
@@ -209,6 +199,39 @@ func New(new_id, effect_type string, pixelCount int, new_config interface{}) (ef
 	return effect, id, err
 }
 
+/*
+Creates a new effect of the named type from its json config, as stored in an EffectEntry:
+{"id": ..., "base_config": {...}, "extra_config": {...}}. All keys are optional,
+and an id is generated if none is given.
+*/
+func NewFromJSON(effect_type string, cfg json.RawMessage) (effect *Effect, id string, err error) {
+	entry := struct {
+		ID          string          `json:"id"`
+		BaseConfig  json.RawMessage `json:"base_config"`
+		ExtraConfig json.RawMessage `json:"extra_config"`
+	}{}
+	if len(cfg) > 0 {
+		if err = json.Unmarshal(cfg, &entry); err != nil {
+			return effect, id, fmt.Errorf("error decoding effect config: %w", err)
+		}
+	}
+	var baseConfig interface{}
+	if len(entry.BaseConfig) > 0 {
+		baseConfig = []byte(entry.BaseConfig)
+	}
+	effect, id, err = New(entry.ID, effect_type, 100, baseConfig)
+	if err != nil {
+		return effect, id, err
+	}
+	if len(entry.ExtraConfig) > 0 {
+		if err = effect.UpdateExtraConfig(entry.ExtraConfig); err != nil {
+			Destroy(id)
+			return effect, id, err
+		}
+	}
+	return effect, id, nil
+}
+
 /*
 Nothing to modify below here =====================
 */
@@ -349,6 +372,19 @@ func Schema() (schema map[string]interface{}, err error) {
 	types := make(map[string]interface{})
 	mapstructure.Decode(&effectTypes, &types)
 	schema["types"] = types
+	// schemas for the effect types which have their own config
+	extra := make(map[string]interface{})
+	for name, newGenerator := range generators {
+		c, ok := newGenerator().(Configurable)
+		if !ok {
+			continue
+		}
+		extra[name], err = util.CreateSchema(reflect.TypeOf(c.Config()))
+		if err != nil {
+			return schema, err
+		}
+	}
+	schema["extra"] = extra
 	return schema, err
 }
 
@@ -364,10 +400,20 @@ func JsonSchema() (jsonSchema []byte, err error) {
 func LoadFromConfig() error {
 	storedEffects := config.GetEffects()
 	for id, entry := range storedEffects {
-		_, _, err := New(id, entry.Type, 100, entry.BaseConfig)
+		e, _, err := New(id, entry.Type, 100, entry.BaseConfig)
 		if err != nil {
 			return err
 		}
+		if entry.ExtraConfig == nil {
+			continue
+		}
+		raw, err := json.Marshal(entry.ExtraConfig)
+		if err != nil {
+			return err
+		}
+		if err = e.UpdateExtraConfig(raw); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -1,12 +1,31 @@
 package effect
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/LedFx/ledfx/pkg/color"
+	"github.com/LedFx/ledfx/pkg/config"
+	"github.com/LedFx/ledfx/pkg/render"
 )
 
+// wraps pixels in a group of one, as a controller renders
+func testPixelGroup(p color.Pixels) *render.PixelGroup {
+	return &render.PixelGroup{
+		Group:      map[string]color.Pixels{"test": p},
+		Order:      []string{"test"},
+		Largest:    "test",
+		Smallest:   "test",
+		LargestLen: len(p),
+		TotalLen:   len(p),
+	}
+}
+
 func BenchmarkEffects(t *testing.B) {
 	// Default config
 	c := map[string]interface{}{}
@@ -18,9 +37,10 @@ func BenchmarkEffects(t *testing.B) {
 				t.Error(err)
 			}
 			// Run the effect on some pixels
+			pg := testPixelGroup(p)
 			t.Run(fmt.Sprintf("%s %d pixels", eType, len(p)), func(t *testing.B) {
 				for i := 0; i < t.N; i++ {
-					effect.Render(p)
+					effect.Render(pg)
 				}
 			})
 			Destroy(effect.GetID())
@@ -64,7 +84,7 @@ func TestEffects(t *testing.T) {
 			}
 			for _, c := range testConfigs {
 				err = effect.UpdateBaseConfig(c) // Assign the config
-				effect.Render(p)                 // Run it on some pixels
+				effect.Render(testPixelGroup(p)) // Run it on some pixels
 				if err != nil {
 					t.Errorf("Failed on test config: %v", c)
 				}
@@ -74,6 +94,130 @@ func TestEffects(t *testing.T) {
 	}
 }
 
+func TestExtraConfig(t *testing.T) {
+	defaults := ChaseConfig{Pattern: "gradient", PatternLength: 20, Source: "volume", Speed: 60, Wrap: true}
+	cases := []struct {
+		name string
+		raw  json.RawMessage
+		want ChaseConfig
+		err  bool
+	}{
+		{"partial update keeps other keys", json.RawMessage(`{"speed": 120}`), ChaseConfig{Pattern: "dots", PatternLength: 20, Source: "volume", Speed: 120, Wrap: true}, false},
+		{"nil resets to defaults", nil, defaults, false},
+		{"invalid value is rejected", json.RawMessage(`{"speed": 120, "pattern": "stars"}`), ChaseConfig{Pattern: "dots", PatternLength: 20, Source: "volume", Speed: 60, Wrap: true}, true},
+		{"out of range value is rejected", json.RawMessage(`{"pattern_length": 0}`), ChaseConfig{Pattern: "dots", PatternLength: 20, Source: "volume", Speed: 60, Wrap: true}, true},
+		{"malformed json is rejected", json.RawMessage(`{"speed": "fast"`), ChaseConfig{Pattern: "dots", PatternLength: 20, Source: "volume", Speed: 60, Wrap: true}, true},
+	}
+	for _, c := range cases {
+		effect, id, err := New("", "chase", 100, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = effect.UpdateExtraConfig(json.RawMessage(`{"pattern": "dots"}`)); err != nil {
+			t.Fatal(err)
+		}
+		err = effect.UpdateExtraConfig(c.raw)
+		if (err != nil) != c.err {
+			t.Errorf("%s: Expected: error %v\r\n Got: %v", c.name, c.err, err)
+		}
+		if got := effect.ExtraConfig(); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: Expected: %+v\r\n Got: %+v", c.name, c.want, got)
+		}
+		Destroy(id)
+	}
+
+	// effect types without their own config have none to update
+	effect, id, err := New("", "energy", 100, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if effect.ExtraConfig() != nil {
+		t.Errorf("Expected: no extra config\r\n Got: %+v", effect.ExtraConfig())
+	}
+	if err = effect.UpdateExtraConfig(json.RawMessage(`{}`)); err == nil {
+		t.Error("Expected: error updating extra config of an effect type without any")
+	}
+	Destroy(id)
+}
+
+func TestNewFromJSON(t *testing.T) {
+	cases := []struct {
+		name       string
+		cfg        string
+		brightness float64
+		extra      interface{}
+		err        bool
+	}{
+		{"empty config uses defaults", ``, globalConfig.Brightness, StrobeConfig{BassThreshold: 0.6}, false},
+		{"base and extra config", `{"base_config": {"brightness": 0.5}, "extra_config": {"bass_threshold": 0.2}}`, 0.5, StrobeConfig{BassThreshold: 0.2}, false},
+		{"extra config alone", `{"extra_config": {"bass_threshold": 0.9}}`, globalConfig.Brightness, StrobeConfig{BassThreshold: 0.9}, false},
+		{"invalid base config", `{"base_config": {"brightness": 2}}`, 0, nil, true},
+		{"invalid extra config", `{"extra_config": {"bass_threshold": 2}}`, 0, nil, true},
+		{"malformed json", `{"base_config": `, 0, nil, true},
+	}
+	for _, c := range cases {
+		effect, id, err := NewFromJSON("strobe", json.RawMessage(c.cfg))
+		if (err != nil) != c.err {
+			t.Errorf("%s: Expected: error %v\r\n Got: %v", c.name, c.err, err)
+		}
+		if err != nil {
+			if _, getErr := Get(id); id != "" && getErr == nil {
+				t.Errorf("%s: Expected: no effect left behind after an error", c.name)
+			}
+			continue
+		}
+		if effect.Config.Brightness != c.brightness {
+			t.Errorf("%s: Expected: brightness %v\r\n Got: %v", c.name, c.brightness, effect.Config.Brightness)
+		}
+		if got := effect.ExtraConfig(); !reflect.DeepEqual(got, c.extra) {
+			t.Errorf("%s: Expected: %+v\r\n Got: %+v", c.name, c.extra, got)
+		}
+		Destroy(id)
+	}
+
+	effect, id, err := NewFromJSON("strobe", json.RawMessage(`{"id": "strobe_from_json"}`))
+	if err != nil || id != "strobe_from_json" || effect.GetID() != id {
+		t.Errorf("Expected: effect with the given id\r\n Got: %s, %v", id, err)
+	}
+	Destroy(id)
+}
+
+func TestExtraConfigRoundTrip(t *testing.T) {
+	want := ChaseConfig{Pattern: "bars", PatternLength: 8, Source: "lows", Speed: 200, Wrap: false}
+	effect, id, err := NewFromJSON("chase", json.RawMessage(`{"id": "chase_round_trip", "extra_config": {"pattern": "bars", "pattern_length": 8, "source": "lows", "speed": 200, "wrap": false}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Destroy(id)
+	if got := effect.ExtraConfig(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected: %+v\r\n Got: %+v", want, got)
+	}
+
+	// the config store holds the extra config, keyed as in json
+	entry, err := config.GetEffect(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.ExtraConfig["pattern"] != "bars" || entry.ExtraConfig["pattern_length"] != 8 {
+		t.Errorf("Expected: stored extra config matching %+v\r\n Got: %+v", want, entry.ExtraConfig)
+	}
+
+	// and recreates the effect with it
+	if err = LoadFromConfig(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded == effect {
+		t.Error("Expected: a new effect loaded from config")
+	}
+	if got := loaded.ExtraConfig(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected: %+v\r\n Got: %+v", want, got)
+	}
+}
+
 // cases := []struct {
 // 	q string
 // 	a Color
@@ -93,3 +237,31 @@ func TestEffects(t *testing.T) {
 // 		t.Errorf("Failed to parse %s: expected (%v, %v) but got (%v, %v)", c.q, c.a, c.e, guess, err)
 // 	}
 // }
+
+func TestAPIExtraConfigErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	NewAPI(mux)
+	request := func(method, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, "/api/effects", strings.NewReader(body)))
+		return rec
+	}
+
+	// an invalid extra config doesn't leave an effect with the default config behind
+	rec := request(http.MethodPost, `{"id": "api-chase", "type": "chase", "extra_config": {"pattern": "stars"}}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected: %d creating with an invalid extra config\r\n Got: %d %s", http.StatusBadRequest, rec.Code, rec.Body)
+	}
+	if _, err := Get("api-chase"); err == nil {
+		t.Errorf("Expected: no effect left after a failed create")
+	}
+
+	if rec := request(http.MethodPost, `{"id": "api-chase", "type": "chase"}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected: %d creating a chase\r\n Got: %d %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	defer Destroy("api-chase")
+	rec = request(http.MethodPut, `{"id": "api-chase", "extra_config": {"pattern_length": 0}}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected: %d updating with an invalid extra config\r\n Got: %d %s", http.StatusBadRequest, rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,75 @@
+package effect
+
+import (
+	"math"
+	"time"
+)
+
+/*
+Smoother eases jittery audio-reactive values, eg. decaying brightness after a beat.
+Rise and fall are time constants: the time taken to cover ~63% of a step up or down.
+Because they are in time rather than per frame, smoothing looks the same at any framerate.
+Use either Apply or ApplyN on a given smoother, as both advance the same clock.
+*/
+type Smoother struct {
+	Rise   time.Duration
+	Fall   time.Duration
+	value  float64
+	values []float64
+	last   time.Time
+}
+
+func NewSmoother(rise, fall time.Duration) *Smoother {
+	return &Smoother{
+		Rise: rise,
+		Fall: fall,
+	}
+}
+
+// Apply smooths a single value according to the time since the previous call
+func (s *Smoother) Apply(value float64) float64 {
+	dt, first := s.tick()
+	if first {
+		s.value = value
+	} else {
+		s.value = s.Step(s.value, value, dt)
+	}
+	return s.value
+}
+
+// ApplyN smooths each value independently, in place. Use it for per-pixel smoothing.
+func (s *Smoother) ApplyN(values []float64) []float64 {
+	dt, first := s.tick()
+	if first || len(s.values) != len(values) {
+		s.values = make([]float64, len(values))
+		copy(s.values, values)
+		return values
+	}
+	for i := range values {
+		s.values[i] = s.Step(s.values[i], values[i], dt)
+		values[i] = s.values[i]
+	}
+	return values
+}
+
+// Step moves prev towards target over dt, using the rise or fall time constant
+func (s *Smoother) Step(prev, target float64, dt time.Duration) float64 {
+	tau := s.Fall
+	if target > prev {
+		tau = s.Rise
+	}
+	if tau <= 0 {
+		return target
+	}
+	alpha := 1 - math.Exp(-dt.Seconds()/tau.Seconds())
+	return prev + alpha*(target-prev)
+}
+
+// returns the time since the previous call, and whether this is the first call
+func (s *Smoother) tick() (dt time.Duration, first bool) {
+	now := time.Now()
+	first = s.last.IsZero()
+	dt = now.Sub(s.last)
+	s.last = now
+	return dt, first
+}
@@ -0,0 +1,33 @@
+package effect
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSmootherStep(t *testing.T) {
+	s := NewSmoother(10*time.Millisecond, 100*time.Millisecond)
+	// one time constant covers 1-1/e of the step
+	if v := s.Step(0, 1, 10*time.Millisecond); math.Abs(v-(1-1/math.E)) > 1e-9 {
+		t.Errorf("Expected: %f\r\n Got: %f", 1-1/math.E, v)
+	}
+	if v := s.Step(1, 0, 100*time.Millisecond); math.Abs(v-1/math.E) > 1e-9 {
+		t.Errorf("Expected: %f\r\n Got: %f", 1/math.E, v)
+	}
+	// two half steps equal one full step, so smoothing is independent of framerate
+	half := s.Step(s.Step(1, 0, 5*time.Millisecond), 0, 5*time.Millisecond)
+	if full := s.Step(1, 0, 10*time.Millisecond); math.Abs(half-full) > 1e-9 {
+		t.Errorf("Expected: %f\r\n Got: %f", full, half)
+	}
+}
+
+func TestSmootherApplyN(t *testing.T) {
+	s := NewSmoother(0, time.Hour)
+	s.ApplyN([]float64{1, 0})
+	got := s.ApplyN([]float64{0, 1})
+	// instant rise, very slow fall
+	if got[0] < 0.99 || got[1] != 1 {
+		t.Errorf("Expected: [~1 1]\r\n Got: %v", got)
+	}
+}
@@ -1,6 +1,7 @@
 package effect
 
 import (
+	"encoding/json"
 	"math/rand"
 
 	"github.com/LedFx/ledfx/pkg/audio"
@@ -8,7 +9,21 @@ import (
 	"github.com/LedFx/ledfx/pkg/render"
 )
 
-type Strobe struct{}
+type Strobe struct {
+	config StrobeConfig
+}
+
+type StrobeConfig struct {
+	BassThreshold float64 `mapstructure:"bass_threshold" json:"bass_threshold" description:"Bass level which flashes the full strip" default:"0.6" validate:"gte=0,lte=1"`
+}
+
+func (e *Strobe) Config() interface{} {
+	return e.config
+}
+
+func (e *Strobe) SetConfig(raw json.RawMessage) error {
+	return decodeConfig(raw, &e.config)
+}
 
 // Apply new pixels to an existing pixel array.
 func (e *Strobe) assembleFrame(base *Effect, pg *render.PixelGroup) {
@@ -20,7 +35,7 @@ func (e *Strobe) assembleFrame(base *Effect, pg *render.PixelGroup) {
 		return
 	}
 	// set full strip to colour if bass
-	if mel.LowsAmplitude() > 0.6 {
+	if mel.LowsAmplitude() > e.config.BassThreshold {
 		for i := range p {
 			p[i] = color.Full
 		}
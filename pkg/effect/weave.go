@@ -1,6 +1,7 @@
 package effect
 
 import (
+	"encoding/json"
 	"math"
 
 	"github.com/LedFx/ledfx/pkg/audio"
@@ -13,6 +14,19 @@ type Weave struct {
 	lowsPos int
 	midsPos int
 	highPos int
+	config  WeaveConfig
+}
+
+type WeaveConfig struct {
+	Speed float64 `mapstructure:"speed" json:"speed" description:"How quickly the bands snake along the strip, scaled by intensity" default:"3" validate:"gte=0,lte=10"`
+}
+
+func (e *Weave) Config() interface{} {
+	return e.config
+}
+
+func (e *Weave) SetConfig(raw json.RawMessage) error {
+	return decodeConfig(raw, &e.config)
 }
 
 // Apply new pixels to an existing pixel array.
@@ -25,9 +39,9 @@ func (e *Weave) assembleFrame(base *Effect, pg *render.PixelGroup) {
 		logger.Logger.WithField("context", "Effect Weave").Error(err)
 		return
 	}
-	lowsStep := base.deltaStart.Seconds()*base.Config.Intensity*3 + 0
-	midsStep := base.deltaStart.Seconds()*base.Config.Intensity*3 + 0.5
-	highStep := base.deltaStart.Seconds()*base.Config.Intensity*3 + 1
+	lowsStep := base.deltaStart.Seconds()*base.Config.Intensity*e.config.Speed + 0
+	midsStep := base.deltaStart.Seconds()*base.Config.Intensity*e.config.Speed + 0.5
+	highStep := base.deltaStart.Seconds()*base.Config.Intensity*e.config.Speed + 1
 
 	lowsNew := int(weavePosition(lowsStep, 1) * base.pixelScaler)
 	midsNew := int(weavePosition(midsStep, 1) * base.pixelScaler)
@@ -24,6 +24,7 @@ const (
 	DeviceDelete
 	ConnectionsUpdate
 	SettingsUpdate
+	AudioClipping
 )
 
 func (et EventType) String() string {
@@ -52,6 +53,8 @@ func (et EventType) String() string {
 		return "Connections Update"
 	case SettingsUpdate:
 		return "Settings Update"
+	case AudioClipping:
+		return "Audio Clipping"
 	default:
 		return "Unknown"
 	}
@@ -115,6 +118,8 @@ func Invoke(et EventType, data map[string]interface{}) {
 		err = checkKeys(data, []string{"effects", "devices"})
 	case SettingsUpdate:
 		err = checkKeys(data, []string{"settings"})
+	case AudioClipping:
+		err = checkKeys(data, []string{"rate", "total"})
 	}
 
 	// Do not invoke the event if it's missing keys
@@ -2,6 +2,7 @@ package player
 
 import (
 	"github.com/LedFx/ledfx/pkg/handlers/rtsp"
+	"github.com/LedFx/ledfx/pkg/handlers/sdp"
 )
 
 // Player defines a player for outputting the data packets from the session
@@ -16,6 +17,12 @@ type Player interface {
 	GetAlbumArt() []byte
 }
 
+// FormatChecker is implemented by players that can refuse a stream they can't decode.
+// The server checks an announced stream with it before setting up the session.
+type FormatChecker interface {
+	CheckFormat(description *sdp.SessionDescription) error
+}
+
 // Track represents a track playing by the player
 type Track struct {
 	Artist  string
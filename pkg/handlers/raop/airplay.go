@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -50,9 +51,15 @@ type AirplayServer struct {
 	name          string
 	rtspServer    *rtsp.Server
 	zerconfServer *zeroconf.Server
+	advertMu      sync.Mutex
 	sessions      *sessionMap
 	player        player.Player
 	doneCh        chan struct{}
+	netWatchQuit  chan struct{}
+	ipv4Only      bool
+	bindIP        net.IP         // nil listens and advertises on every interface
+	bindIface     *net.Interface // the interface holding bindIP
+	receiveBuffer int            // milliseconds of audio queued per session, 0 for rtsp.DefaultReceiveBuffer
 }
 
 // Parameter types
@@ -108,6 +115,8 @@ func (sm *sessionMap) getSession(address string) *airplaySession {
 }
 
 func (sm *sessionMap) getSessions() []*airplaySession {
+	sm.RLock()
+	defer sm.RUnlock()
 	sessions := make([]*airplaySession, 0, len(sm.sessions))
 
 	for _, value := range sm.sessions {
@@ -122,17 +131,43 @@ func NewAirplayServer(port int, name string, player player.Player) *AirplayServe
 	return &as
 }
 
-// Start starts the airplay server, broadcasting on bonjour, ready to accept requests
-func (a *AirplayServer) Start(advertise bool) (err error) {
-	if advertise {
-		if err = a.initAdvertise(); err != nil {
-			return err
-		}
+// SetIPv4Only restricts both the RTSP listener and the mDNS advertisement
+// to IPv4 for senders that misbehave on dual-stack networks. It must be
+// called before Start.
+func (a *AirplayServer) SetIPv4Only(v4Only bool) {
+	a.ipv4Only = v4Only
+}
+
+// SetBind restricts both the RTSP listener and the mDNS advertisement to ip,
+// which is assigned to iface. It must be called before Start.
+func (a *AirplayServer) SetBind(ip net.IP, iface *net.Interface) {
+	a.bindIP = ip
+	a.bindIface = iface
+}
+
+// BindHost returns ip as a host to listen on. An IPv6 link-local address is only unique
+// within its interface, so it is zoned to iface.
+func BindHost(ip net.IP, iface *net.Interface) string {
+	if ip.To4() == nil && ip.IsLinkLocalUnicast() && iface != nil {
+		return ip.String() + "%" + iface.Name
 	}
+	return ip.String()
+}
 
-	rtspServer := rtsp.NewServer(a.port)
+// SetReceiveBuffer sets the milliseconds of audio queued between the network and the player
+// for sessions announced from now on. 0 uses rtsp.DefaultReceiveBuffer.
+func (a *AirplayServer) SetReceiveBuffer(ms int) {
+	a.receiveBuffer = ms
+}
 
-	a.rtspServer = rtspServer
+// Start starts the airplay server, broadcasting on bonjour, ready to accept requests.
+// A port of 0 listens on a free port, which is then advertised.
+func (a *AirplayServer) Start(advertise bool) (err error) {
+	rtspServer := rtsp.NewServer(a.port)
+	rtspServer.SetIPv4Only(a.ipv4Only)
+	if a.bindIP != nil {
+		rtspServer.SetBindIP(BindHost(a.bindIP, a.bindIface))
+	}
 
 	rtspServer.AddHandler(rtsp.Options, handleOptions)
 	rtspServer.AddHandler(rtsp.Announce, a.handleAnnounce)
@@ -141,17 +176,54 @@ func (a *AirplayServer) Start(advertise bool) (err error) {
 	rtspServer.AddHandler(rtsp.Set_Parameter, a.handleSetParameter)
 	rtspServer.AddHandler(rtsp.Flush, handleFlush)
 	rtspServer.AddHandler(rtsp.Teardown, a.handleTeardown)
-	a.doneCh = make(chan struct{})
-	rtspServer.Start(a.doneCh)
+	doneCh := make(chan struct{}, 1)
+	// listen before advertising, so the advertised port is the one bound
+	if err = rtspServer.Start(doneCh); err != nil {
+		return err
+	}
+	a.rtspServer = rtspServer
+	a.doneCh = doneCh
+	a.port = rtspServer.Port()
+
+	if advertise {
+		a.advertMu.Lock()
+		err = a.initAdvertise()
+		a.advertMu.Unlock()
+		if err != nil {
+			rtspServer.Stop()
+			return err
+		}
+	}
+
+	a.netWatchQuit = make(chan struct{})
+	go a.watchNetwork(a.netWatchQuit)
 	return nil
 }
 
+// Remote returns the DACP client of an active session, for controlling the sender's playback.
+// It returns ErrNoDacp if no connected sender negotiated DACP.
+func (a *AirplayServer) Remote() (*DacpClient, error) {
+	for _, as := range a.sessions.getSessions() {
+		if as.client != nil {
+			return as.client, nil
+		}
+	}
+	return nil, ErrNoDacp
+}
+
+// Port returns the RTSP port, which is only known after Start if 0 was requested
+func (a *AirplayServer) Port() int {
+	return a.port
+}
+
 func (a *AirplayServer) Wait() {
 	<-a.doneCh
 }
 
 // ToggleAdvertise will toggle whether to advertise as an airplay service
 func (a *AirplayServer) ToggleAdvertise(shouldAdvertise bool) (err error) {
+	a.advertMu.Lock()
+	defer a.advertMu.Unlock()
 	if !shouldAdvertise {
 		if a.zerconfServer == nil {
 			log.Logger.WithField("context", "RAOP Airplay").Println("Currently not advertising, ignoring turn off advertise request")
@@ -179,6 +251,8 @@ func (a *AirplayServer) ChangeName(newName string) (err error) {
 	if strings.TrimSpace(newName) == "" {
 		return errors.New("new name must be non-empty")
 	}
+	a.advertMu.Lock()
+	defer a.advertMu.Unlock()
 	a.name = strings.TrimSpace(newName)
 	// if we are advertising, stop the zeroconf server and start it so it
 	// reflects the name change
@@ -192,11 +266,41 @@ func (a *AirplayServer) ChangeName(newName string) (err error) {
 	return nil
 }
 
+// ReannounceService re-registers the mDNS records so they carry the host's
+// current addresses. This runs automatically when the addresses change, but can
+// be called manually when that detection isn't reliable.
+func (a *AirplayServer) ReannounceService() (err error) {
+	a.advertMu.Lock()
+	defer a.advertMu.Unlock()
+	if a.zerconfServer == nil {
+		return errors.New("service is not being advertised")
+	}
+	a.zerconfServer.Shutdown()
+	a.zerconfServer = nil
+	return a.initAdvertise()
+}
+
+func (a *AirplayServer) advertising() bool {
+	a.advertMu.Lock()
+	defer a.advertMu.Unlock()
+	return a.zerconfServer != nil
+}
+
+// initAdvertise must be called with advertMu held
 func (a *AirplayServer) initAdvertise() (err error) {
 	// as per the protocol, the mac address makes up part of the service name
 	serviceName := fmt.Sprintf("%s@%s", strings.ReplaceAll(getMacAddr().String(), ":", ""), a.name)
 
-	if a.zerconfServer, err = zeroconf.Register(serviceName, airTunesServiceType, domain, a.port, airtunesServiceProperties, nil); err != nil {
+	switch {
+	case a.bindIP != nil:
+		a.zerconfServer, err = registerBound(serviceName, a.port, a.bindIP, *a.bindIface)
+	case a.ipv4Only:
+		a.zerconfServer, err = registerIPv4Only(serviceName, a.port)
+	default:
+		// zeroconf publishes both A and AAAA records for every multicast interface
+		a.zerconfServer, err = zeroconf.Register(serviceName, airTunesServiceType, domain, a.port, airtunesServiceProperties, nil)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to start ZeroConf server: %w", err)
 	}
 
@@ -208,6 +312,37 @@ func (a *AirplayServer) initAdvertise() (err error) {
 	return nil
 }
 
+// registerIPv4Only publishes the service with A records only
+func registerIPv4Only(serviceName string, port int) (*zeroconf.Server, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine host: %w", err)
+	}
+	ips := make([]string, 0)
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine host IP addresses: %w", err)
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			ips = append(ips, ipNet.IP.String())
+		}
+	}
+	if len(ips) == 0 {
+		return nil, errors.New("no IPv4 address to advertise")
+	}
+	return zeroconf.RegisterProxy(serviceName, airTunesServiceType, domain, port, host, ips, airtunesServiceProperties, nil)
+}
+
+// registerBound publishes the service with just ip, answering queries on iface only
+func registerBound(serviceName string, port int, ip net.IP, iface net.Interface) (*zeroconf.Server, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine host: %w", err)
+	}
+	return zeroconf.RegisterProxy(serviceName, airTunesServiceType, domain, port, host, []string{ip.String()}, airtunesServiceProperties, []net.Interface{iface})
+}
+
 func handleOptions(req *rtsp.Request, resp *rtsp.Response, localAddress string, _ string) {
 	resp.Status = rtsp.Ok
 	resp.Headers["Public"] = strings.Join(rtsp.GetMethods(), " ")
@@ -231,6 +366,13 @@ func (a *AirplayServer) handleAnnounce(req *rtsp.Request, resp *rtsp.Response, _
 			resp.Status = rtsp.BadRequest
 			return
 		}
+		if fc, ok := a.player.(player.FormatChecker); ok {
+			if err := fc.CheckFormat(description); err != nil {
+				log.Logger.WithField("context", "RAOP Handler: Announce").Warnf("Rejecting stream: %v", err)
+				resp.Status = rtsp.UnsupportedMediaType
+				return
+			}
+		}
 		// right now, we only maintain one audio session, so close any existing one
 		a.closeAllSessions()
 		var decoder rtsp.Decrypter
@@ -262,6 +404,9 @@ func (a *AirplayServer) handleAnnounce(req *rtsp.Request, resp *rtsp.Response, _
 		activeRemote := req.Headers["Active-Remote"]
 		dacpClient := DiscoverDacpClient(dacpID, activeRemote)
 		s := rtsp.NewSession(description, decoder)
+		if a.receiveBuffer > 0 {
+			s.SetReceiveBuffer(a.receiveBuffer)
+		}
 		if err = s.InitReceive(); err != nil {
 			log.Logger.WithField("context", "RAOP Handler: Announce").Println("error initializing data receiving", err)
 			resp.Status = rtsp.InternalServerError
@@ -276,18 +421,14 @@ func (a *AirplayServer) handleAnnounce(req *rtsp.Request, resp *rtsp.Response, _
 func (a *AirplayServer) handleSetup(req *rtsp.Request, resp *rtsp.Response, _ string, remoteAddress string) {
 	transport, hasTransport := req.Headers["Transport"]
 	as := a.sessions.getSession(remoteAddress)
+	if as == nil {
+		// the announce was refused or never made
+		log.Logger.WithField("context", "RAOP Handler: Setup").Warnf("No announced session for %s", remoteAddress)
+		resp.Status = rtsp.SessionNotFound
+		return
+	}
 	if hasTransport {
-		transportParts := strings.Split(transport, ";")
-		var controlPort int
-		var timingPort int
-		for _, part := range transportParts {
-			if strings.Contains(part, "control_port") {
-				controlPort, _ = strconv.Atoi(strings.Split(part, "=")[1])
-			}
-			if strings.Contains(part, "timing_port") {
-				timingPort, _ = strconv.Atoi(strings.Split(part, "=")[1])
-			}
-		}
+		controlPort, timingPort := parseTransportPorts(transport)
 		as.session.RemotePorts.Address = remoteAddress
 		as.session.RemotePorts.Control = controlPort
 		as.session.RemotePorts.Timing = timingPort
@@ -304,6 +445,25 @@ func (a *AirplayServer) handleSetup(req *rtsp.Request, resp *rtsp.Response, _ st
 	resp.Status = rtsp.Ok
 }
 
+// parseTransportPorts extracts the control and timing ports from a Transport header.
+// Parameters are matched by exact key so values carrying IPv6 addresses
+// (e.g. "destination=fe80::1") can't be mistaken for ports.
+func parseTransportPorts(transport string) (controlPort, timingPort int) {
+	for _, part := range strings.Split(transport, ";") {
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "control_port":
+			controlPort, _ = strconv.Atoi(strings.TrimSpace(value))
+		case "timing_port":
+			timingPort, _ = strconv.Atoi(strings.TrimSpace(value))
+		}
+	}
+	return controlPort, timingPort
+}
+
 func (a *AirplayServer) handleRecord(_ *rtsp.Request, resp *rtsp.Response, _ string, remoteAddress string) {
 	as := a.sessions.getSession(remoteAddress)
 	err := as.session.StartReceiving()
@@ -374,9 +534,18 @@ func (a *AirplayServer) handleTeardown(_ *rtsp.Request, resp *rtsp.Response, _ s
 func (a *AirplayServer) Stop() {
 	log.Logger.WithField("context", "AirPlay").Warnln("Stopping AirPlay server")
 	a.closeAllSessions()
-	a.rtspServer.Stop()
+	if a.rtspServer != nil {
+		a.rtspServer.Stop()
+	}
+	if a.netWatchQuit != nil {
+		close(a.netWatchQuit)
+		a.netWatchQuit = nil
+	}
+	a.advertMu.Lock()
+	defer a.advertMu.Unlock()
 	if a.zerconfServer != nil {
 		a.zerconfServer.Shutdown()
+		a.zerconfServer = nil
 	}
 }
 
@@ -248,3 +248,26 @@ func TestSetMetadata(t *testing.T) {
 	}
 
 }
+
+func TestReannounceServiceNotAdvertising(t *testing.T) {
+	a := NewAirplayServer(444, "Test", &FakePlayer{})
+	if err := a.ReannounceService(); err == nil {
+		t.Errorf("Expected an error re-announcing a service that is not advertised")
+	}
+}
+
+func TestAddrFingerprintStable(t *testing.T) {
+	if first, second := addrFingerprint(), addrFingerprint(); first != second {
+		t.Errorf("Expected: %s\r\n Got: %s", first, second)
+	}
+}
+
+func TestParseTransportPortsIPv6(t *testing.T) {
+	controlPort, timingPort := parseTransportPorts("RTP/AVP/UDP;unicast;destination=fe80::1;mode=record;control_port=6001;timing_port=6002")
+	if controlPort != 6001 {
+		t.Errorf("Expected: %d\r\n Got: %d", 6001, controlPort)
+	}
+	if timingPort != 6002 {
+		t.Errorf("Expected: %d\r\n Got: %d", 6002, timingPort)
+	}
+}
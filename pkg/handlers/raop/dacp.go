@@ -2,6 +2,7 @@ package raop
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -11,6 +12,9 @@ import (
 	"github.com/grandcat/zeroconf"
 )
 
+// ErrNoDacp is returned when no sender has a DACP remote control channel
+var ErrNoDacp = errors.New("DACP not negotiated")
+
 // DacpClient used to perform DACP operations
 type DacpClient struct {
 	dacpID       string
@@ -44,14 +48,25 @@ func (d *DacpClient) Next() error {
 	return d.executeMethod("nextitem")
 }
 
+func (d *DacpClient) Previous() error {
+	return d.executeMethod("previtem")
+}
+
 func (d *DacpClient) executeMethod(method string) error {
 	req, err := http.NewRequest("GET", fmt.Sprintf("http://%s:%d/ctrl-int/1/%s", d.ipAddress, d.port, method), nil)
 	if err != nil {
 		return err
 	}
 	req.Header.Add("Active-Remote", d.activeRemote)
-	_, err = d.httpClient.Do(req)
-	return err
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("DACP %s failed: %s", method, resp.Status)
+	}
+	return nil
 }
 
 // DiscoverDacpClient will try to find the matching DACP client for stream operations
@@ -17,7 +17,7 @@ func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return f(req), nil
 }
 
-//NewTestClient returns *http.Client with Transport replaced to avoid making real calls
+// NewTestClient returns *http.Client with Transport replaced to avoid making real calls
 func NewTestClient(fn RoundTripFunc) *http.Client {
 	return &http.Client{
 		Transport: RoundTripFunc(fn),
@@ -158,3 +158,48 @@ func TestNext(t *testing.T) {
 		t.Errorf("Expected: %s\r\n Received: %s\r\n", expectedRemote, header)
 	}
 }
+
+func TestPrevious(t *testing.T) {
+	dc := newDacpClient("1.1.1.1", 333, "testID", "testActiveRemote")
+	url := ""
+	dc.httpClient = NewTestClient(func(req *http.Request) *http.Response {
+		url = req.URL.String()
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(`OK`)),
+			Header:     make(http.Header),
+		}
+	})
+	if err := dc.Previous(); err != nil {
+		t.Errorf("Expected: nil\r\n Received: %v\r\n", err)
+	}
+	expectedURL := "http://1.1.1.1:333/ctrl-int/1/previtem"
+	if url != expectedURL {
+		t.Errorf("Expected: %s\r\n Received: %s\r\n", expectedURL, url)
+	}
+}
+
+func TestRemoteErrors(t *testing.T) {
+	a := NewAirplayServer(444, "Test", &FakePlayer{})
+	if _, err := a.Remote(); err != ErrNoDacp {
+		t.Errorf("Expected: %v\r\n Received: %v\r\n", ErrNoDacp, err)
+	}
+
+	dc := newDacpClient("1.1.1.1", 333, "testID", "testActiveRemote")
+	dc.httpClient = NewTestClient(func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: 403,
+			Status:     "403 Forbidden",
+			Body:       ioutil.NopCloser(bytes.NewBufferString(``)),
+			Header:     make(http.Header),
+		}
+	})
+	a.sessions.addSession("10.0.0.0", newAirplaySession(nil, dc))
+	remote, err := a.Remote()
+	if err != nil || remote != dc {
+		t.Fatalf("Expected: session's DACP client\r\n Received: %v, %v\r\n", remote, err)
+	}
+	if err := remote.Next(); err == nil {
+		t.Error("Expected: error for a rejected command")
+	}
+}
@@ -0,0 +1,65 @@
+package raop
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/LedFx/ledfx/pkg/logger"
+)
+
+// netWatchInterval is how often the host's interface addresses are checked for changes
+const netWatchInterval = 5 * time.Second
+
+// addrFingerprint returns a stable representation of every unicast address
+// assigned to an interface that is up and not a loopback.
+func addrFingerprint() string {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+	addrs := make([]string, 0)
+	for _, i := range interfaces {
+		if i.Flags&net.FlagUp == 0 || i.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		ifAddrs, err := i.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range ifAddrs {
+			addrs = append(addrs, i.Name+"="+addr.String())
+		}
+	}
+	sort.Strings(addrs)
+	return strings.Join(addrs, ",")
+}
+
+// watchNetwork re-announces the service whenever the host's addresses change,
+// e.g. after a DHCP renewal hands out a new lease. It returns once quit is closed.
+func (a *AirplayServer) watchNetwork(quit chan struct{}) {
+	ticker := time.NewTicker(netWatchInterval)
+	defer ticker.Stop()
+
+	last := addrFingerprint()
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+			current := addrFingerprint()
+			if current == last {
+				continue
+			}
+			last = current
+			if !a.advertising() {
+				continue
+			}
+			log.Logger.WithField("context", "RAOP Advert").Infoln("Network addresses changed, re-announcing service...")
+			if err := a.ReannounceService(); err != nil {
+				log.Logger.WithField("context", "RAOP Advert").Errorf("Error re-announcing service: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package rtp
+
+import (
+	"sync"
+
+	"github.com/LedFx/ledfx/pkg/handlers/rtsp"
+)
+
+// DefaultDepth is the number of packets held by a JitterBuffer when none is configured.
+// At 352 frames per packet and 44.1kHz this is about 64ms of audio.
+const DefaultDepth = 8
+
+// resyncAfter is the number of consecutive late packets after which the sender is taken
+// to have jumped its sequence numbers, or restarted, and the buffer follows it
+const resyncAfter = 16
+
+// JitterBuffer reorders RTP packets by sequence number. It holds up to depth packets,
+// so one arriving out of order can still be slotted in before its neighbours are released.
+// Packets arriving after a later one has been released are dropped, unless so many arrive
+// in a row that the sender must have jumped or reset its sequence numbers.
+type JitterBuffer struct {
+	mu      sync.Mutex
+	depth   int
+	packets []rtsp.Packet // ordered by sequence number, oldest first
+	next    uint16        // sequence number expected to be released next
+	started bool
+	late    int
+	lateRun int // consecutive late packets
+}
+
+// JitterStats describes the state of a JitterBuffer
+type JitterStats struct {
+	Depth    int `json:"depth"`
+	Buffered int `json:"buffered"`
+	Late     int `json:"late_drops"`
+}
+
+// NewJitterBuffer creates a buffer holding depth packets. Depth 0 releases packets
+// immediately, only dropping those which arrive late.
+func NewJitterBuffer(depth int) *JitterBuffer {
+	if depth < 0 {
+		depth = 0
+	}
+	return &JitterBuffer{
+		depth:   depth,
+		packets: make([]rtsp.Packet, 0, depth+1),
+	}
+}
+
+// Push adds a packet and returns any packets now released, in sequence order.
+// Released packets may have gaps between them where packets were lost.
+func (j *JitterBuffer) Push(pkt rtsp.Packet) []rtsp.Packet {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.started {
+		j.next, j.started = pkt.Sequence, true
+	}
+	var resynced []rtsp.Packet
+	offset := pkt.Sequence - j.next
+	if offset >= 0x8000 {
+		if j.lateRun++; j.lateRun < resyncAfter {
+			j.late++
+			return nil
+		}
+		// the packets held belong before the jump, so go out first
+		resynced = j.release(len(j.packets))
+		j.next, offset = pkt.Sequence, 0
+	}
+	j.lateRun = 0
+	i := len(j.packets)
+	for i > 0 && j.packets[i-1].Sequence-j.next > offset {
+		i--
+	}
+	if i > 0 && j.packets[i-1].Sequence == pkt.Sequence {
+		// duplicate
+		return resynced
+	}
+	j.packets = append(j.packets, rtsp.Packet{})
+	copy(j.packets[i+1:], j.packets[i:])
+	j.packets[i] = pkt
+
+	if len(j.packets) <= j.depth {
+		return resynced
+	}
+	return append(resynced, j.release(len(j.packets)-j.depth)...)
+}
+
+// Flush returns every buffered packet in sequence order
+func (j *JitterBuffer) Flush() []rtsp.Packet {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.release(len(j.packets))
+}
+
+func (j *JitterBuffer) release(n int) []rtsp.Packet {
+	if n == 0 {
+		return nil
+	}
+	out := make([]rtsp.Packet, n)
+	copy(out, j.packets[:n])
+	j.packets = append(j.packets[:0], j.packets[n:]...)
+	j.next = out[n-1].Sequence + 1
+	return out
+}
+
+// Stats returns the configured depth, packets currently held and late packets dropped
+func (j *JitterBuffer) Stats() JitterStats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return JitterStats{
+		Depth:    j.depth,
+		Buffered: len(j.packets),
+		Late:     j.late,
+	}
+}
@@ -0,0 +1,87 @@
+package rtp
+
+import (
+	"testing"
+
+	"github.com/LedFx/ledfx/pkg/handlers/rtsp"
+)
+
+func pushAll(j *JitterBuffer, seqs ...uint16) (out []uint16) {
+	for _, s := range seqs {
+		for _, p := range j.Push(rtsp.Packet{Sequence: s}) {
+			out = append(out, p.Sequence)
+		}
+	}
+	return out
+}
+
+func equalSeqs(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestJitterBufferReorders(t *testing.T) {
+	j := NewJitterBuffer(3)
+	got := pushAll(j, 65534, 0, 65535, 2, 1, 3, 5, 4)
+	got = append(got, func() (s []uint16) {
+		for _, p := range j.Flush() {
+			s = append(s, p.Sequence)
+		}
+		return s
+	}()...)
+	want := []uint16{65534, 65535, 0, 1, 2, 3, 4, 5}
+	if !equalSeqs(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+}
+
+func TestJitterBufferDropsLate(t *testing.T) {
+	j := NewJitterBuffer(1)
+	got := pushAll(j, 10, 12, 13, 11, 13)
+	if want := []uint16{10, 12}; !equalSeqs(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+	want := JitterStats{Depth: 1, Buffered: 1, Late: 1}
+	if stats := j.Stats(); stats != want {
+		t.Errorf("Expected: %+v\r\n Got: %+v", want, stats)
+	}
+}
+
+func TestJitterBufferZeroDepth(t *testing.T) {
+	j := NewJitterBuffer(0)
+	got := pushAll(j, 1, 2, 4, 3)
+	if want := []uint16{1, 2, 4}; !equalSeqs(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+	if j.Stats().Late != 1 {
+		t.Errorf("Expected: 1 late drop\r\n Got: %d", j.Stats().Late)
+	}
+}
+
+func TestJitterBufferResyncs(t *testing.T) {
+	j := NewJitterBuffer(2)
+	pushAll(j, 0, 1, 2, 3)
+	// the sender jumps more than half the sequence space, so its packets look late
+	seqs := make([]uint16, resyncAfter+4)
+	for i := range seqs {
+		seqs[i] = uint16(40000 + i)
+	}
+	got := pushAll(j, seqs...)
+	want := []uint16{2, 3}
+	for i := resyncAfter - 1; i < len(seqs)-2; i++ {
+		want = append(want, seqs[i])
+	}
+	if !equalSeqs(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+	if late := j.Stats().Late; late != resyncAfter-1 {
+		t.Errorf("Expected: %d late drops\r\n Got: %d", resyncAfter-1, late)
+	}
+}
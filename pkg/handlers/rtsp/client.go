@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 	"sync/atomic"
 )
 
@@ -24,8 +25,8 @@ func NewClient(address string, port int) (*Client, error) {
 	return &Client{
 		conn:       conn,
 		seq:        1,
-		localAddr:  conn.LocalAddr().(*net.TCPAddr).IP.To4().String(),
-		remoteAddr: conn.RemoteAddr().(*net.TCPAddr).IP.To4().String(),
+		localAddr:  conn.LocalAddr().(*net.TCPAddr).IP.String(),
+		remoteAddr: conn.RemoteAddr().(*net.TCPAddr).IP.String(),
 	}, nil
 }
 
@@ -45,6 +46,14 @@ func (c *Client) Send(request *Request) (*Response, error) {
 	return resp, nil
 }
 
+// LocalURIHost returns the local address in a form that can be used as the host of an RTSP URI
+func (c *Client) LocalURIHost() string {
+	if strings.Contains(c.localAddr, ":") {
+		return "[" + c.localAddr + "]"
+	}
+	return c.localAddr
+}
+
 // LocalAddress returns the local (our) address
 func (c *Client) LocalAddress() string {
 	return c.localAddr
@@ -0,0 +1,111 @@
+package rtsp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/handlers/sdp"
+	log "github.com/LedFx/ledfx/pkg/logger"
+)
+
+// Packet is an RTP audio packet received by a session
+type Packet struct {
+	Sequence uint16 // RTP sequence number
+	Payload  []byte // payload after decryption
+}
+
+// SequenceGap returns how many packets were lost between sequence numbers prev and next,
+// allowing for wraparound. It returns -1 if next is a duplicate or arrived late.
+func SequenceGap(prev, next uint16) int {
+	d := next - prev
+	if d == 0 || d >= 0x8000 {
+		return -1
+	}
+	return int(d) - 1
+}
+
+// packetFilter drops RTP packets which don't belong to the negotiated stream
+type packetFilter struct {
+	payloadType int // -1 when the session didn't negotiate one
+	ssrc        uint32
+	ssrcSet     bool
+
+	// a run of packets from another SSRC, which the filter switches to once it reaches relockAfter
+	candidate    uint32
+	candidateRun int
+
+	dropped     int
+	lastWarning time.Time
+}
+
+const (
+	// how often dropped packets are reported
+	dropWarningInterval = 5 * time.Second
+	// consecutive packets from one new SSRC that mean the sender restarted its stream
+	relockAfter = 16
+)
+
+func newPacketFilter(description *sdp.SessionDescription) *packetFilter {
+	return &packetFilter{payloadType: negotiatedPayloadType(description)}
+}
+
+// negotiatedPayloadType reads the payload type from the rtpmap attribute, eg. "96 AppleLossless"
+func negotiatedPayloadType(description *sdp.SessionDescription) int {
+	if description == nil {
+		return -1
+	}
+	fields := strings.Fields(description.Attributes["rtpmap"])
+	if len(fields) == 0 {
+		return -1
+	}
+	pt, err := strconv.Atoi(fields[0])
+	if err != nil || pt < 0 || pt > 127 {
+		return -1
+	}
+	return pt
+}
+
+// check validates an RTP header. The first valid packet fixes the SSRC, until relockAfter packets
+// in a row arrive from the same new one.
+func (f *packetFilter) check(packet []byte) error {
+	if len(packet) < rtpHeader {
+		return fmt.Errorf("short packet of %d bytes", len(packet))
+	}
+	if v := packet[0] >> 6; v != 2 {
+		return fmt.Errorf("unsupported RTP version %d", v)
+	}
+	if pt := int(packet[1] & 0x7F); f.payloadType >= 0 && pt != f.payloadType {
+		return fmt.Errorf("unexpected payload type %d, negotiated %d", pt, f.payloadType)
+	}
+	ssrc := binary.BigEndian.Uint32(packet[8:12])
+	switch {
+	case !f.ssrcSet || ssrc == f.ssrc:
+		f.ssrc, f.ssrcSet = ssrc, true
+		f.candidateRun = 0
+		return nil
+	case ssrc != f.candidate || f.candidateRun == 0:
+		f.candidate, f.candidateRun = ssrc, 1
+	default:
+		f.candidateRun++
+	}
+	if f.candidateRun < relockAfter {
+		return fmt.Errorf("unexpected SSRC %08x, stream is %08x", ssrc, f.ssrc)
+	}
+	log.Logger.WithField("context", "RTSP Session").Infof("Stream SSRC changed from %08x to %08x", f.ssrc, ssrc)
+	f.ssrc, f.candidateRun = ssrc, 0
+	return nil
+}
+
+// drop counts a rejected packet, warning at most once per dropWarningInterval
+func (f *packetFilter) drop(err error) {
+	f.dropped++
+	if time.Since(f.lastWarning) < dropWarningInterval {
+		return
+	}
+	log.Logger.WithField("context", "RTSP Session").Warnf("Dropped %d invalid packets, latest: %v", f.dropped, err)
+	f.dropped = 0
+	f.lastWarning = time.Now()
+}
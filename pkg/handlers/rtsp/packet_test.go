@@ -0,0 +1,83 @@
+package rtsp
+
+import (
+	"testing"
+
+	"github.com/LedFx/ledfx/pkg/handlers/sdp"
+)
+
+func TestSequenceGap(t *testing.T) {
+	tests := []struct {
+		prev, next uint16
+		want       int
+	}{
+		{10, 11, 0},
+		{10, 14, 3},
+		{65535, 0, 0},
+		{65534, 1, 2},
+		{10, 10, -1},
+		{10, 9, -1},
+		{1, 65535, -1},
+	}
+	for _, tt := range tests {
+		if got := SequenceGap(tt.prev, tt.next); got != tt.want {
+			t.Errorf("SequenceGap(%d, %d)\r\n Expected: %d\r\n Got: %d", tt.prev, tt.next, tt.want, got)
+		}
+	}
+}
+
+func testRTP(pt byte, ssrc uint32) []byte {
+	return []byte{0x80, 0x80 | pt, 0, 1, 0, 0, 0, 0, byte(ssrc >> 24), byte(ssrc >> 16), byte(ssrc >> 8), byte(ssrc), 0xAA}
+}
+
+func TestPacketFilter(t *testing.T) {
+	f := newPacketFilter(&sdp.SessionDescription{Attributes: map[string]string{"rtpmap": "96 AppleLossless"}})
+	if err := f.check(testRTP(96, 1)); err != nil {
+		t.Errorf("Expected: valid packet\r\n Got: %v", err)
+	}
+	bad := map[string][]byte{
+		"short":        testRTP(96, 1)[:11],
+		"version":      append([]byte{0x40}, testRTP(96, 1)[1:]...),
+		"payload type": testRTP(97, 1),
+		"ssrc":         testRTP(96, 2),
+	}
+	for name, pkt := range bad {
+		if err := f.check(pkt); err == nil {
+			t.Errorf("Expected: %s packet to be rejected", name)
+		}
+	}
+	if err := f.check(testRTP(96, 1)); err != nil {
+		t.Errorf("Expected: valid packet\r\n Got: %v", err)
+	}
+
+	// a sender restarting with a new SSRC is followed once it has sent relockAfter packets in a row
+	for i := 1; i < relockAfter; i++ {
+		if err := f.check(testRTP(96, 3)); err == nil {
+			t.Fatalf("Expected: packet %d from the new SSRC rejected", i)
+		}
+	}
+	if err := f.check(testRTP(96, 3)); err != nil {
+		t.Errorf("Expected: the filter to relock onto the new SSRC\r\n Got: %v", err)
+	}
+	if err := f.check(testRTP(96, 1)); err == nil {
+		t.Errorf("Expected: the old SSRC rejected after relocking")
+	}
+
+	// a matching packet breaks the run
+	f = newPacketFilter(nil)
+	f.check(testRTP(96, 1))
+	for i := 0; i < relockAfter*2; i++ {
+		pkt := testRTP(96, 4)
+		if i%relockAfter == relockAfter-1 {
+			pkt = testRTP(96, 1)
+		}
+		if err := f.check(pkt); err == nil && i%relockAfter != relockAfter-1 {
+			t.Fatalf("Expected: interleaved packet %d from another SSRC rejected", i)
+		}
+	}
+
+	// no negotiated payload type accepts any
+	if err := newPacketFilter(nil).check(testRTP(100, 1)); err != nil {
+		t.Errorf("Expected: valid packet\r\n Got: %v", err)
+	}
+}
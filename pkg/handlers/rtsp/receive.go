@@ -0,0 +1,89 @@
+package rtsp
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/handlers/sdp"
+	log "github.com/LedFx/ledfx/pkg/logger"
+)
+
+// DefaultReceiveBuffer is the milliseconds of audio queued between the UDP read loop and the decoder
+const DefaultReceiveBuffer = 2000
+
+// packet length assumed when the session doesn't describe one: 352 samples at 44.1kHz
+const (
+	defaultFrameLength = 352
+	defaultSampleRate  = 44100
+)
+
+// SetReceiveBuffer sizes the queue between the UDP read loop and the decoder to hold ms milliseconds of audio.
+// A burst longer than that is dropped rather than blocking the read loop. It must be called before StartReceiving.
+func (s *Session) SetReceiveBuffer(ms int) {
+	s.DataChan = make(chan Packet, receiveBufferPackets(s.Description, ms))
+}
+
+// Dropped returns the number of packets dropped because the decoder fell behind
+func (s *Session) Dropped() uint64 {
+	return atomic.LoadUint64(&s.overflowed)
+}
+
+// queues a packet for the decoder without blocking, dropping it if the queue is full
+func (s *Session) enqueue(p Packet) {
+	select {
+	case s.DataChan <- p:
+		return
+	default:
+	}
+	n := atomic.AddUint64(&s.overflowed, 1)
+	if time.Since(s.lastOverflow) < dropWarningInterval {
+		return
+	}
+	log.Logger.WithField("context", "RTSP Session").Warnf("Receive buffer full, decoder is falling behind. %d packets dropped so far", n)
+	s.lastOverflow = time.Now()
+}
+
+// receiveBufferPackets returns how many packets hold ms milliseconds of audio, at least 1
+func receiveBufferPackets(description *sdp.SessionDescription, ms int) int {
+	n := int(time.Duration(ms) * time.Millisecond / packetDuration(description))
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// FrameLength returns the samples per packet negotiated in the fmtp attribute,
+// eg. 352 from "96 352 0 16 40 10 14 2 255 0 0 44100", or 0 if it isn't given
+func FrameLength(description *sdp.SessionDescription) int {
+	return fmtpField(description, 1)
+}
+
+// packetDuration reads the audio length of one packet from the fmtp attribute,
+// falling back to 352 samples at 44.1kHz
+func packetDuration(description *sdp.SessionDescription) time.Duration {
+	frameLength, sampleRate := defaultFrameLength, defaultSampleRate
+	if n := FrameLength(description); n > 0 {
+		frameLength = n
+	}
+	if n := fmtpField(description, 11); n > 0 {
+		sampleRate = n
+	}
+	return time.Duration(frameLength) * time.Second / time.Duration(sampleRate)
+}
+
+// returns field i of a complete ALAC fmtp attribute, 0 if missing or not a positive number
+func fmtpField(description *sdp.SessionDescription, i int) int {
+	if description == nil {
+		return 0
+	}
+	fields := strings.Fields(description.Attributes["fmtp"])
+	if len(fields) < 12 {
+		return 0
+	}
+	if n, err := strconv.Atoi(fields[i]); err == nil && n > 0 {
+		return n
+	}
+	return 0
+}
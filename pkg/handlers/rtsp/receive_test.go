@@ -0,0 +1,38 @@
+package rtsp
+
+import (
+	"testing"
+
+	"github.com/LedFx/ledfx/pkg/handlers/sdp"
+)
+
+func TestReceiveBuffer(t *testing.T) {
+	alac := &sdp.SessionDescription{Attributes: map[string]string{"fmtp": "96 352 0 16 40 10 14 2 255 0 0 44100"}}
+	tests := []struct {
+		description *sdp.SessionDescription
+		ms, want    int
+	}{
+		{alac, 1000, 125}, // 352 samples is just under 8ms
+		{alac, 1, 1},
+		{nil, 1000, 125},
+		{&sdp.SessionDescription{Attributes: map[string]string{"fmtp": "96 4096 0 16 40 10 14 2 255 0 0 48000"}}, 1000, 11},
+	}
+	for _, tt := range tests {
+		if got := receiveBufferPackets(tt.description, tt.ms); got != tt.want {
+			t.Errorf("Expected: %d packets for %dms\r\n Got: %d", tt.want, tt.ms, got)
+		}
+	}
+
+	// a full buffer drops and counts packets instead of blocking
+	s := NewSession(alac, nil)
+	s.SetReceiveBuffer(16)
+	for i := 0; i < 5; i++ {
+		s.enqueue(Packet{Sequence: uint16(i)})
+	}
+	if len(s.DataChan) != 2 || s.Dropped() != 3 {
+		t.Errorf("Expected: 2 queued, 3 dropped\r\n Got: %d queued, %d dropped", len(s.DataChan), s.Dropped())
+	}
+	if p := <-s.DataChan; p.Sequence != 0 {
+		t.Errorf("Expected: oldest packet kept\r\n Got: sequence %d", p.Sequence)
+	}
+}
@@ -1,6 +1,9 @@
 package rtsp
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestMethodExists(t *testing.T) {
 	method, err := getMethod("options")
@@ -46,3 +49,21 @@ func TestStatusDoesNotExist(t *testing.T) {
 		t.Error("Expected error value")
 	}
 }
+
+func TestServerPortInUse(t *testing.T) {
+	first := NewServer(0)
+	first.SetIPv4Only(true)
+	if err := first.Start(make(chan struct{}, 1)); err != nil {
+		t.Fatal(err)
+	}
+	defer first.Stop()
+	if first.Port() == 0 {
+		t.Error("Expected: a free port to be chosen")
+	}
+
+	second := NewServer(first.Port())
+	second.SetIPv4Only(true)
+	if err := second.Start(make(chan struct{}, 1)); !errors.Is(err, ErrPortInUse) {
+		t.Errorf("Expected: %v\r\n Got: %v", ErrPortInUse, err)
+	}
+}
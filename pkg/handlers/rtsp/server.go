@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"syscall"
 
 	"github.com/LedFx/ledfx/pkg/config"
 	log "github.com/LedFx/ledfx/pkg/logger"
@@ -20,6 +21,8 @@ type Server struct {
 	done     chan bool
 	reqChan  chan *Request
 	ip       string
+	bindIP   string // overrides the configured host when set
+	ipv4Only bool
 }
 
 // NewServer instantiates a new RtspServer
@@ -37,22 +40,55 @@ func (r *Server) AddHandler(m Method, rh RequestHandler) {
 	r.handlers[m] = rh
 }
 
+// SetIPv4Only restricts the listener to IPv4. By default the server
+// listens on both address families.
+func (r *Server) SetIPv4Only(v4Only bool) {
+	r.ipv4Only = v4Only
+}
+
+// SetBindIP listens on ip instead of the host from the settings
+func (r *Server) SetBindIP(ip string) {
+	r.bindIP = ip
+}
+
 // Stop stops the RTSP server
 func (r *Server) Stop() {
 	log.Logger.WithField("context", "RTSP Server").Println("Stopping RTSP server")
 	r.done <- true
 }
 
+// ErrPortInUse is returned by Start when another process is listening on the port
+var ErrPortInUse = errors.New("port already in use")
+
+// Port returns the port the server listens on. After Start, a requested port of 0 is replaced with the port chosen.
+func (r *Server) Port() int {
+	return r.port
+}
+
 // Start creates listening socket for the RTSP connection
-func (r *Server) Start(doneCh chan struct{}) {
+func (r *Server) Start(doneCh chan struct{}) error {
 	r.ip = config.GetSettings().Host
-	log.Logger.WithField("context", "RTSP Server").Printf("Starting RTSP server on address: %s:%d", r.ip, r.port)
+	if r.bindIP != "" {
+		r.ip = r.bindIP
+	}
+	network := "tcp"
+	switch {
+	case r.ipv4Only:
+		network = "tcp4"
+	case r.ip == net.IPv4zero.String():
+		// The IPv4 wildcard only covers one family; bind the unspecified address so both are served.
+		r.ip = ""
+	}
+	log.Logger.WithField("context", "RTSP Server").Printf("Starting RTSP server on address: %s (%s)", net.JoinHostPort(r.ip, fmt.Sprint(r.port)), network)
 
-	tcpListen, err := net.Listen("tcp", net.JoinHostPort(r.ip, fmt.Sprint(r.port)))
+	tcpListen, err := net.Listen(network, net.JoinHostPort(r.ip, fmt.Sprint(r.port)))
 	if err != nil {
-		log.Logger.WithField("context", "RTSP Server").Errorln("Error listening:", err.Error())
-		return
+		if errors.Is(err, syscall.EADDRINUSE) {
+			return fmt.Errorf("error listening on RTSP port %d: %w", r.port, ErrPortInUse)
+		}
+		return fmt.Errorf("error listening: %w", err)
 	}
+	r.port = tcpListen.Addr().(*net.TCPAddr).Port
 
 	// Handle TCP connections.
 	go func() {
@@ -74,6 +110,7 @@ func (r *Server) Start(doneCh chan struct{}) {
 		defer tcpListen.Close()
 		doneCh <- struct{}{}
 	}()
+	return nil
 }
 
 func (*Server) read(conn net.Conn, handlers map[Method]RequestHandler) {
@@ -2,10 +2,10 @@ package rtsp
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"net"
-	"strconv"
-	"strings"
+	"time"
 
 	log "github.com/LedFx/ledfx/pkg/logger"
 
@@ -14,6 +14,7 @@ import (
 
 const (
 	readBuffer = 2048
+	rtpHeader  = 12
 )
 
 // Decrypter decrypts a received packet
@@ -31,22 +32,27 @@ type PortSet struct {
 
 // Session a streaming session
 type Session struct {
+	// overflowed counts packets dropped because DataChan was full. Accessed atomically, so kept first for alignment.
+	overflowed   uint64
+	lastOverflow time.Time
+
 	Description *sdp.SessionDescription
 	decrypter   Decrypter
 	RemotePorts PortSet
 	LocalPorts  PortSet
 	dataConn    net.Conn
-	DataChan    chan []byte
+	DataChan    chan Packet
 	stopChan    chan struct{}
 	buf         *bytes.Buffer
 
 	sendBuf    []byte
 	packetChan chan []byte
+	filter     *packetFilter
 }
 
 // NewSession instantiates a new Session
 func NewSession(description *sdp.SessionDescription, decrypter Decrypter) *Session {
-	return &Session{Description: description, decrypter: decrypter, DataChan: make(chan []byte, 1000), buf: bytes.NewBuffer(make([]byte, readBuffer)), sendBuf: make([]byte, 0), packetChan: make(chan []byte)}
+	return &Session{Description: description, decrypter: decrypter, DataChan: make(chan Packet, receiveBufferPackets(description, DefaultReceiveBuffer)), buf: bytes.NewBuffer(make([]byte, readBuffer)), sendBuf: make([]byte, 0), packetChan: make(chan []byte), filter: newPacketFilter(description)}
 }
 
 // InitReceive initializes the session to for receiving
@@ -61,8 +67,7 @@ func (s *Session) InitReceive() error {
 	}
 	// keep track of the actual connection, so we can close it later
 	s.dataConn = conn
-	localAddr := strings.Split(conn.LocalAddr().String(), ":")
-	s.LocalPorts.Data, _ = strconv.Atoi(localAddr[len(localAddr)-1])
+	s.LocalPorts.Data = conn.LocalAddr().(*net.UDPAddr).Port
 	return nil
 }
 
@@ -92,6 +97,11 @@ func (s *Session) StartReceiving() error {
 				break
 			}
 			packet := s.buf.Bytes()[:n]
+			if err := s.filter.check(packet); err != nil {
+				s.filter.drop(err)
+				continue
+			}
+			seq := binary.BigEndian.Uint16(packet[2:4])
 			// send the data to the decoder
 			if s.decrypter != nil {
 				if packet, err = s.decrypter.Decode(packet); err != nil {
@@ -102,7 +112,7 @@ func (s *Session) StartReceiving() error {
 
 			s.sendBuf = make([]byte, len(packet))
 			copy(s.sendBuf, packet)
-			s.DataChan <- s.sendBuf
+			s.enqueue(Packet{Sequence: seq, Payload: s.sendBuf})
 		}
 		log.Logger.WithField("context", "RTSP Session").Infoln("Signalling Session is closed")
 		if s.stopChan != nil {
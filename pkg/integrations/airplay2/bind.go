@@ -0,0 +1,92 @@
+package airplay2
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// resolveBind finds the address and interface the server is restricted to by Config.Interface
+// and Config.BindIP. Both empty means every interface, returned as nil. The returned IP has no
+// zone, so link-local IPv6 addresses must be zoned to the interface to listen on, see raop.BindHost.
+func resolveBind(name, bindIP string, v4Only bool) (net.IP, *net.Interface, error) {
+	if name == "" && bindIP == "" {
+		return nil, nil, nil
+	}
+	var ip net.IP
+	if bindIP != "" {
+		// a link-local address may carry its interface as a zone, eg. "fe80::1%eth0"
+		host, zone, zoned := strings.Cut(bindIP, "%")
+		switch {
+		case !zoned:
+		case name == "":
+			name = zone
+		case name != zone:
+			return nil, nil, fmt.Errorf("'%s' is zoned to another interface than '%s': %w", bindIP, name, ErrInvalidBind)
+		}
+		if ip = net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+			return nil, nil, fmt.Errorf("'%s' is not a host address: %w", bindIP, ErrInvalidBind)
+		}
+		if v4Only && ip.To4() == nil {
+			return nil, nil, fmt.Errorf("'%s' is not an IPv4 address: %w", bindIP, ErrInvalidBind)
+		}
+	}
+
+	var ifaces []net.Interface
+	if name != "" {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("interface '%s' not found: %w", name, ErrInvalidBind)
+		}
+		ifaces = []net.Interface{*iface}
+	} else {
+		var err error
+		if ifaces, err = net.Interfaces(); err != nil {
+			return nil, nil, fmt.Errorf("error listing interfaces: %w", err)
+		}
+	}
+
+	for i := range ifaces {
+		addrs, err := ifaces[i].Addrs()
+		if err != nil {
+			continue
+		}
+		if found := matchAddr(addrs, ip, v4Only); found != nil {
+			return found, &ifaces[i], nil
+		}
+	}
+	if ip != nil {
+		return nil, nil, fmt.Errorf("'%s' is not assigned to %s: %w", bindIP, describeInterfaces(name), ErrInvalidBind)
+	}
+	return nil, nil, fmt.Errorf("interface '%s' has no usable address: %w", name, ErrInvalidBind)
+}
+
+// matchAddr returns want if it is one of addrs. With no address wanted it picks the first
+// IPv4 address, falling back to a global IPv6 one unless v4Only.
+func matchAddr(addrs []net.Addr, want net.IP, v4Only bool) net.IP {
+	var v6 net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		switch {
+		case want != nil:
+			if ipNet.IP.Equal(want) {
+				return want
+			}
+		case ipNet.IP.To4() != nil:
+			return ipNet.IP
+		case v6 == nil && !v4Only && ipNet.IP.IsGlobalUnicast():
+			v6 = ipNet.IP
+		}
+	}
+	return v6
+}
+
+func describeInterfaces(name string) string {
+	if name == "" {
+		return "any interface"
+	}
+	return fmt.Sprintf("interface '%s'", name)
+}
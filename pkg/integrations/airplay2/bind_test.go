@@ -0,0 +1,57 @@
+package airplay2
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/LedFx/ledfx/pkg/handlers/raop"
+)
+
+func TestResolveBind(t *testing.T) {
+	if ip, iface, err := resolveBind("", "", false); ip != nil || iface != nil || err != nil {
+		t.Errorf("Expected: no bind without options\r\n Got: %v %v %v", ip, iface, err)
+	}
+
+	ip, iface, err := resolveBind("", "127.0.0.1", false)
+	if err != nil {
+		t.Fatalf("Error resolving loopback: %v", err)
+	}
+	if ip.String() != "127.0.0.1" || iface == nil {
+		t.Errorf("Expected: 127.0.0.1 on the loopback interface\r\n Got: %v %v", ip, iface)
+	}
+	if ip, _, err := resolveBind(iface.Name, "", true); err != nil || ip.To4() == nil {
+		t.Errorf("Expected: an IPv4 address on %s\r\n Got: %v %v", iface.Name, ip, err)
+	}
+
+	// a zone names the interface
+	if ip, zoned, err := resolveBind("", "127.0.0.1%"+iface.Name, false); err != nil || !ip.Equal(net.IPv4(127, 0, 0, 1)) || zoned.Name != iface.Name {
+		t.Errorf("Expected: 127.0.0.1 on %s\r\n Got: %v %v %v", iface.Name, ip, zoned, err)
+	}
+
+	for _, c := range []struct{ name, ip string }{
+		{"", "not an ip"},
+		{"", "0.0.0.0"},
+		{"", "192.0.2.1"}, // reserved for documentation, never assigned
+		{"no-such-interface0", ""},
+		{iface.Name, "192.0.2.1"},
+		{iface.Name, "127.0.0.1%no-such-interface0"},
+	} {
+		if _, _, err := resolveBind(c.name, c.ip, false); !errors.Is(err, ErrInvalidBind) {
+			t.Errorf("Expected: %v for %q %q\r\n Got: %v", ErrInvalidBind, c.name, c.ip, err)
+		}
+	}
+}
+
+func TestBindHost(t *testing.T) {
+	eth := &net.Interface{Name: "eth0"}
+	for ip, want := range map[string]string{
+		"fe80::1":     "fe80::1%eth0",
+		"2001:db8::1": "2001:db8::1",
+		"192.0.2.1":   "192.0.2.1",
+	} {
+		if got := raop.BindHost(net.ParseIP(ip), eth); got != want {
+			t.Errorf("Expected: %s\r\n Got: %s", want, got)
+		}
+	}
+}
@@ -100,7 +100,7 @@ func (cl *Client) MarshalJSON() ([]byte, error) {
 	}{
 		Name:        cl.Name(),
 		Hostname:    cl.Hostname(),
-		RemoteIP:    cl.RemoteIP().To4().String(),
+		RemoteIP:    cl.RemoteIP().String(),
 		RemotePort:  cl.RemotePort(),
 		Type:        cl.Type(),
 		DeviceModel: cl.DeviceModel(),
@@ -120,7 +120,7 @@ func (cl *Client) SetParam(par interface{}) {
 	var err error
 	req := rtsp.NewRequest()
 	req.Method = rtsp.Set_Parameter
-	req.RequestURI = fmt.Sprintf("rtsp://%s/%d", cl.paramConn.LocalAddress(), time.Now().Unix())
+	req.RequestURI = fmt.Sprintf("rtsp://%s/%d", cl.paramConn.LocalURIHost(), time.Now().Unix())
 
 	switch val := par.(type) {
 	case raop.ParamVolume:
@@ -2,37 +2,168 @@ package codec
 
 import (
 	"strings"
+	"time"
 
 	"github.com/LedFx/ledfx/pkg/handlers/rtsp"
+	log "github.com/LedFx/ledfx/pkg/logger"
 	alac "github.com/carterpeel/go.alac"
+	"go.uber.org/atomic"
 )
 
 // Handler is a function type for receiving raw bytes and decoding them using some codec
 type Handler struct {
 	decoderFn func(data []byte) []byte
 	a         *alac.Alac
+	f         *flacDecoder
+	c         *concealer // nil when packet-loss concealment is disabled
+
+	// verify checks the first decoded packet against OutputFormat
+	verify, verified bool
+
+	// frameLength is the samples per packet negotiated for ALAC, 0 for other codecs
+	frameLength int
+	mismatched  uint64 // packets decoded to a different length
+
+	decodeErrors      atomic.Uint64
+	failRun           int  // failed decodes in a row
+	muting            bool // silence is returned in place of failed packets
+	lastDecodeWarning time.Time
 }
 
 func (h *Handler) Free() {
 	h.a = nil
+	h.c = nil
+	if h.f != nil {
+		h.f.free()
+		h.f = nil
+	}
 }
 
 func (h *Handler) Decode(in []byte) []byte {
-	return h.decoderFn(in)
+	out, ok := h.tryDecode(in)
+	if !ok {
+		return h.failed()
+	}
+	h.recovered()
+	if h.frameLength > 0 && len(out) > 0 {
+		h.checkFrameLength(len(out))
+	}
+	if h.verify && !h.verified && len(out) > 0 {
+		h.verified = true
+		if err := h.OutputFormat().check(len(out)); err != nil {
+			log.Logger.WithField("context", "Codec").Warnf("Decoded audio doesn't match the output format: %v", err)
+		}
+	}
+	if h.c != nil {
+		out = h.c.decoded(out)
+	}
+	return out
+}
+
+// OutputFormat returns the layout of decoded audio
+func (h *Handler) OutputFormat() Format {
+	switch {
+	case h.a != nil:
+		return Format{
+			BitDepth:        h.a.BitDepth(),
+			Channels:        h.a.NumChannels(),
+			SampleRate:      h.a.SampleRate(),
+			MaxFrameSamples: h.frameLength,
+		}
+	case h.f != nil:
+		// FLAC is always converted to 16 bit, the rest comes from STREAMINFO once it has been parsed
+		f := DefaultFormat
+		if h.f.info != nil {
+			f.Channels = int(h.f.info.NChannels)
+			f.SampleRate = int(h.f.info.SampleRate)
+		}
+		return f
+	default:
+		return DefaultFormat
+	}
+}
+
+// SetVerify enables a check of the first decoded packet against OutputFormat, logging a warning on mismatch
+func (h *Handler) SetVerify(enabled bool) {
+	h.verify = enabled
+	h.verified = false
+}
+
+// SetConcealment enables or disables packet-loss concealment
+func (h *Handler) SetConcealment(enabled bool) {
+	switch {
+	case enabled && h.c == nil:
+		h.c = &concealer{}
+	case !enabled:
+		h.c = nil
+	}
+}
+
+// Conceal returns audio to stand in for lost packets: the last good packet faded out, then silence.
+// Returns nil if concealment is disabled, nothing has been decoded yet, or the gap is
+// longer than MaxConcealedPackets.
+func (h *Handler) Conceal(lost int) []byte {
+	if h.c == nil {
+		return nil
+	}
+	return h.c.conceal(lost)
 }
 
-func GetCodec(session *rtsp.Session) (decoder *Handler) {
+// Mismatched returns the number of decoded packets whose length differed from the negotiated frame length
+func (h *Handler) Mismatched() uint64 {
+	return h.mismatched
+}
+
+// compares the samples in a decoded ALAC packet with the frame length from the SDP.
+// The first mismatch is a warning, the rest are only logged at debug level.
+func (h *Handler) checkFrameLength(n int) {
+	size := h.a.BitDepth() / 8 * h.a.NumChannels()
+	if size <= 0 || n/size == h.frameLength {
+		return
+	}
+	h.mismatched++
+	if h.mismatched == 1 {
+		log.Logger.WithField("context", "Codec").Warnf("Packet decoded to %d samples, expected a frame length of %d", n/size, h.frameLength)
+		return
+	}
+	log.Logger.WithField("context", "Codec").Debugf("Packet decoded to %d samples, expected %d. %d mismatched so far", n/size, h.frameLength, h.mismatched)
+}
+
+const (
+	// samples per packet when the SDP doesn't say
+	alacFrameLength = 352
+	// go.alac fixes its frame length at 352 and sizes its buffers for four times that,
+	// which bounds the frame lengths it can decode. Longer ones are unsupported.
+	alacMaxFrameLength = 4 * alacFrameLength
+)
+
+// GetCodec returns a decoder for the session's codec, or ErrUnsupportedFormat as CheckFormat does.
+// For ALAC the frame length negotiated in the SDP fmtp attribute is used to check each decoded packet.
+func GetCodec(session *rtsp.Session) (decoder *Handler, err error) {
+	if err := CheckFormat(session.Description); err != nil {
+		return nil, err
+	}
 	rtpmap := session.Description.Attributes["rtpmap"]
 	if strings.Contains(rtpmap, "AppleLossless") {
 		a, _ := alac.New()
 		decoder = &Handler{
-			decoderFn: func(data []byte) []byte { return a.Decode(data) },
-			a:         a,
+			decoderFn:   func(data []byte) []byte { return a.Decode(data) },
+			a:           a,
+			frameLength: alacFrameLength,
+		}
+		if n := rtsp.FrameLength(session.Description); n > 0 {
+			decoder.frameLength = n
+		}
+	} else if strings.Contains(strings.ToLower(rtpmap), "flac") {
+		f := newFlacDecoder()
+		decoder = &Handler{
+			decoderFn: f.decode,
+			f:         f,
 		}
 	} else {
 		decoder = &Handler{
 			decoderFn: func(data []byte) []byte { return data },
 		}
 	}
-	return decoder
+	return decoder, nil
 }
@@ -0,0 +1,57 @@
+package codec
+
+import "encoding/binary"
+
+const (
+	// MaxConcealedPackets is the longest gap that is concealed.
+	// Anything longer is treated as a restarted stream rather than loss.
+	MaxConcealedPackets = 32
+	// samples faded in after a gap, so playback doesn't click back in
+	fadeInSamples = 128
+)
+
+// concealer stands in for lost packets with a fade out of the last good packet, then silence
+type concealer struct {
+	last   []byte // previous decoded packet
+	fadeIn bool   // fade in the next decoded packet
+}
+
+// keeps a copy of a decoded packet, fading it in if it follows a gap
+func (c *concealer) decoded(pcm []byte) []byte {
+	if c.fadeIn {
+		n := len(pcm) / 2
+		if n > fadeInSamples {
+			n = fadeInSamples
+		}
+		scale(pcm[:n*2], func(i int) float64 { return float64(i) / float64(n) })
+		c.fadeIn = false
+	}
+	if cap(c.last) < len(pcm) {
+		c.last = make([]byte, len(pcm))
+	}
+	c.last = c.last[:len(pcm)]
+	copy(c.last, pcm)
+	return pcm
+}
+
+// returns audio to cover lost packets, each as long as the last good one
+func (c *concealer) conceal(lost int) []byte {
+	if len(c.last) == 0 || lost <= 0 || lost > MaxConcealedPackets {
+		return nil
+	}
+	out := make([]byte, len(c.last)*lost)
+	fade := out[:len(c.last)]
+	copy(fade, c.last)
+	n := len(fade) / 2
+	scale(fade, func(i int) float64 { return 1 - float64(i)/float64(n) })
+	c.fadeIn = true
+	return out
+}
+
+// multiplies each 16 bit little endian sample i by gain(i)
+func scale(pcm []byte, gain func(i int) float64) {
+	for i := 0; i+1 < len(pcm); i += 2 {
+		s := int16(binary.LittleEndian.Uint16(pcm[i:]))
+		binary.LittleEndian.PutUint16(pcm[i:], uint16(int16(float64(s)*gain(i/2))))
+	}
+}
@@ -0,0 +1,54 @@
+package codec
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testConcealHandler() *Handler {
+	h := &Handler{decoderFn: func(b []byte) []byte { return b }}
+	h.SetConcealment(true)
+	return h
+}
+
+func TestConcealFadesOutThenSilence(t *testing.T) {
+	h := testConcealHandler()
+	if got := h.Conceal(1); got != nil {
+		t.Errorf("Expected: nil before anything is decoded\r\n Got: %v", got)
+	}
+	h.Decode(testPCM([]int16{1000, 1000}, []int16{-1000, -1000}))
+
+	got := h.Conceal(2)
+	want := append(testPCM([]int16{1000, 500}, []int16{-750, -250}), make([]byte, 8)...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+	if got := h.Conceal(MaxConcealedPackets + 1); got != nil {
+		t.Errorf("Expected: nil for a gap longer than MaxConcealedPackets\r\n Got: %v", got)
+	}
+}
+
+func TestConcealFadesIn(t *testing.T) {
+	h := testConcealHandler()
+	h.Decode(testPCM([]int16{1000}, []int16{1000}))
+	h.Conceal(1)
+
+	got := h.Decode(testPCM([]int16{1000, 1000}, []int16{1000, 1000}))
+	if want := testPCM([]int16{0, 500}, []int16{250, 750}); !bytes.Equal(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+	// only the packet after the gap is faded
+	got = h.Decode(testPCM([]int16{1000}, []int16{1000}))
+	if want := testPCM([]int16{1000}, []int16{1000}); !bytes.Equal(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+}
+
+func TestConcealDisabled(t *testing.T) {
+	h := testConcealHandler()
+	h.SetConcealment(false)
+	h.Decode(testPCM([]int16{1000}, []int16{1000}))
+	if got := h.Conceal(1); got != nil {
+		t.Errorf("Expected: nil with concealment disabled\r\n Got: %v", got)
+	}
+}
@@ -0,0 +1,79 @@
+package codec
+
+import (
+	"time"
+
+	log "github.com/LedFx/ledfx/pkg/logger"
+)
+
+const (
+	// MaxDecodeErrors is how many failed decodes in a row mute the output.
+	// Fewer are concealed like lost packets when concealment is enabled, and dropped otherwise.
+	MaxDecodeErrors = 4
+	// how often a warning is logged while muted
+	decodeWarningInterval = 5 * time.Second
+)
+
+// DecodeErrorCount returns the number of packets which failed to decode. It is safe to call while decoding.
+func (h *Handler) DecodeErrorCount() uint64 {
+	return h.decodeErrors.Load()
+}
+
+// decodes in, recovering from a decoder panicking on corrupt data. ok is false if the output isn't usable.
+func (h *Handler) tryDecode(in []byte) (out []byte, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Logger.WithField("context", "Codec").Debugf("Decoder panicked: %v", r)
+			out, ok = nil, false
+		}
+	}()
+	out = h.decoderFn(in)
+	// only ALAC yields a whole frame per packet, FLAC may be waiting on the rest of one
+	if h.a == nil {
+		return out, true
+	}
+	if len(out) == 0 || h.OutputFormat().check(len(out)) != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// counts a failed decode. Once MaxDecodeErrors fail in a row, a frame of silence is returned
+// in place of each packet so the output stays muted rather than playing noise.
+func (h *Handler) failed() []byte {
+	n := h.decodeErrors.Inc()
+	h.failRun++
+	if h.failRun < MaxDecodeErrors {
+		return h.concealFailed()
+	}
+	if !h.muting || time.Since(h.lastDecodeWarning) >= decodeWarningInterval {
+		log.Logger.WithField("context", "Codec").Warnf("%d packets in a row failed to decode, muting. %d decode errors so far", h.failRun, n)
+		h.lastDecodeWarning = time.Now()
+	}
+	if !h.muting && h.c != nil {
+		h.c.fadeIn = true
+	}
+	h.muting = true
+	return make([]byte, h.frameLength*h.OutputFormat().FrameSize())
+}
+
+// stands in for a packet that failed to decode as though it were lost: the first of a run fades
+// out the last good packet, the rest are silence. nil if concealment is disabled or nothing has decoded yet.
+func (h *Handler) concealFailed() []byte {
+	if h.c == nil || len(h.c.last) == 0 {
+		return nil
+	}
+	if h.failRun == 1 {
+		return h.c.conceal(1)
+	}
+	return make([]byte, len(h.c.last))
+}
+
+// resets the run of failed decodes after a good one
+func (h *Handler) recovered() {
+	if h.muting {
+		log.Logger.WithField("context", "Codec").Infof("Decoding recovered after %d failed packets", h.failRun)
+		h.muting = false
+	}
+	h.failRun = 0
+}
@@ -0,0 +1,78 @@
+package codec
+
+import (
+	"bytes"
+	"testing"
+
+	alac "github.com/carterpeel/go.alac"
+)
+
+func TestDecodeErrorMute(t *testing.T) {
+	a, _ := alac.New()
+	var fail bool
+	good := bytes.Repeat([]byte{1}, alacFrameLength*4)
+	h := &Handler{a: a, frameLength: alacFrameLength, decoderFn: func(data []byte) []byte {
+		if fail {
+			return nil
+		}
+		return good
+	}}
+
+	fail = true
+	for i := 1; i < MaxDecodeErrors; i++ {
+		if out := h.Decode(nil); out != nil {
+			t.Errorf("Expected: a gap for failed decode %d\r\n Got: %d bytes", i, len(out))
+		}
+	}
+	out := h.Decode(nil)
+	if len(out) != len(good) || !bytes.Equal(out, make([]byte, len(good))) {
+		t.Errorf("Expected: %d bytes of silence once muted\r\n Got: %v", len(good), out)
+	}
+	if h.DecodeErrorCount() != MaxDecodeErrors {
+		t.Errorf("Expected: %d decode errors\r\n Got: %d", MaxDecodeErrors, h.DecodeErrorCount())
+	}
+
+	// a panicking decoder counts as a failure rather than taking the player down
+	h.decoderFn = func(data []byte) []byte { return data[10:] }
+	if out := h.Decode(nil); len(out) != len(good) {
+		t.Errorf("Expected: silence after a decoder panic\r\n Got: %d bytes", len(out))
+	}
+
+	h.decoderFn = func(data []byte) []byte { return good }
+	if out := h.Decode(nil); !bytes.Equal(out, good) || h.muting {
+		t.Errorf("Expected: decoded audio once decoding recovers\r\n Got: %d bytes, muting %v", len(out), h.muting)
+	}
+}
+
+func TestDecodeErrorConcealed(t *testing.T) {
+	a, _ := alac.New()
+	var fail bool
+	good := bytes.Repeat([]byte{0, 0x40}, alacFrameLength*2)
+	h := &Handler{a: a, frameLength: alacFrameLength, decoderFn: func(data []byte) []byte {
+		if fail {
+			return nil
+		}
+		return append([]byte(nil), good...)
+	}}
+	h.SetConcealment(true)
+	h.Decode(nil)
+
+	// the first failure fades out the last good packet, the next is silence
+	fail = true
+	out := h.Decode(nil)
+	if len(out) != len(good) || bytes.Equal(out, good) || bytes.Equal(out, make([]byte, len(good))) {
+		t.Errorf("Expected: %d bytes fading out\r\n Got: %d bytes", len(good), len(out))
+	}
+	if out := h.Decode(nil); !bytes.Equal(out, make([]byte, len(good))) {
+		t.Errorf("Expected: %d bytes of silence\r\n Got: %d bytes", len(good), len(out))
+	}
+
+	// and the packet after is faded back in
+	fail = false
+	if out := h.Decode(nil); len(out) != len(good) || bytes.Equal(out, good) {
+		t.Errorf("Expected: %d bytes fading in\r\n Got: %d bytes", len(good), len(out))
+	}
+	if out := h.Decode(nil); !bytes.Equal(out, good) {
+		t.Errorf("Expected: decoded audio after the fade in\r\n Got: %d bytes", len(out))
+	}
+}
@@ -0,0 +1,6 @@
+package codec
+
+import "fmt"
+
+// ErrUnsupportedFormat is returned for streams the decoders can't turn into 16 bit 44.1kHz PCM
+var ErrUnsupportedFormat = fmt.Errorf("unsupported format")
@@ -0,0 +1,137 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	log "github.com/LedFx/ledfx/pkg/logger"
+
+	"github.com/mewkiz/flac/frame"
+	"github.com/mewkiz/flac/meta"
+)
+
+var flacSignature = []byte("fLaC")
+
+// flacDecoder decodes a FLAC stream which arrives in arbitrary chunks.
+// RTP packets don't line up with FLAC frames, so bytes are buffered until a whole
+// frame can be parsed. The stream may start with the "fLaC" signature and metadata
+// blocks, or go straight to frames.
+type flacDecoder struct {
+	buf  []byte
+	info *meta.StreamInfo
+}
+
+func newFlacDecoder() *flacDecoder {
+	return &flacDecoder{}
+}
+
+// decode buffers data and returns interleaved 16 bit little endian PCM for every frame now complete
+func (d *flacDecoder) decode(data []byte) []byte {
+	d.buf = append(d.buf, data...)
+	if d.info == nil && bytes.HasPrefix(d.buf, flacSignature) {
+		if !d.parseMetadata() {
+			return nil
+		}
+	}
+
+	var out []byte
+	for len(d.buf) > 0 {
+		r := bytes.NewReader(d.buf)
+		pcm, err := d.parseFrame(r)
+		switch {
+		case err == nil:
+			out = append(out, pcm...)
+			d.buf = d.buf[len(d.buf)-r.Len():]
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			// incomplete frame, wait for more data
+			return out
+		default:
+			log.Logger.WithField("context", "FLAC Decoder").Debugf("Dropping corrupt frame data: %v", err)
+			d.resync()
+		}
+	}
+	return out
+}
+
+// parses the signature and metadata blocks. Returns false if they are not yet fully buffered.
+func (d *flacDecoder) parseMetadata() bool {
+	r := bytes.NewReader(d.buf[len(flacSignature):])
+	var info *meta.StreamInfo
+	for {
+		block, err := meta.Parse(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return false
+			}
+			if !errors.Is(err, meta.ErrReservedType) {
+				log.Logger.WithField("context", "FLAC Decoder").Warnf("Error parsing metadata: %v", err)
+				d.resync()
+				return true
+			}
+			// reserved blocks are unparsed, so skip their body to reach the next header
+			if int64(r.Len()) < block.Length {
+				return false
+			}
+			if err := block.Skip(); err != nil {
+				return false
+			}
+		}
+		if si, ok := block.Body.(*meta.StreamInfo); ok {
+			info = si
+		}
+		if block.IsLast {
+			break
+		}
+	}
+	d.info = info
+	d.buf = d.buf[len(d.buf)-r.Len():]
+	return true
+}
+
+func (d *flacDecoder) parseFrame(r io.Reader) ([]byte, error) {
+	f, err := frame.New(r)
+	if err != nil {
+		return nil, err
+	}
+	// a sample size of 0 in the frame header means "see STREAMINFO"
+	if f.BitsPerSample == 0 && d.info != nil {
+		f.BitsPerSample = d.info.BitsPerSample
+	}
+	if err = f.Parse(); err != nil {
+		return nil, err
+	}
+
+	shift := int(f.BitsPerSample) - 16
+	channels := len(f.Subframes)
+	out := make([]byte, 2*channels*int(f.BlockSize))
+	for i := 0; i < int(f.BlockSize); i++ {
+		for c, sf := range f.Subframes {
+			s := sf.Samples[i]
+			if shift > 0 {
+				s >>= uint(shift)
+			} else if shift < 0 {
+				s <<= uint(-shift)
+			}
+			binary.LittleEndian.PutUint16(out[2*(i*channels+c):], uint16(int16(s)))
+		}
+	}
+	return out, nil
+}
+
+// drops buffered bytes up to the next frame sync code
+func (d *flacDecoder) resync() {
+	for i := 1; i+1 < len(d.buf); i++ {
+		if d.buf[i] == 0xFF && d.buf[i+1]&0xFE == 0xF8 {
+			d.buf = d.buf[i:]
+			return
+		}
+	}
+	d.buf = d.buf[:0]
+}
+
+func (d *flacDecoder) free() {
+	d.buf = nil
+	d.info = nil
+}
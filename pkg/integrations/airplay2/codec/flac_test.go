@@ -0,0 +1,138 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// builds a 16 bit stereo 44.1kHz FLAC frame using verbatim subframes
+func testFlacFrame(num byte, left, right []int16) []byte {
+	f := []byte{
+		0xFF, 0xF8, // sync code, fixed blocksize
+		0x69,                // blocksize in 8 bit field at end of header, 44.1kHz
+		0x18,                // left/right stereo, 16 bits per sample
+		num,                 // frame number (UTF-8 coded, <128)
+		byte(len(left) - 1), // blocksize - 1
+	}
+	f = append(f, crc8(f))
+	for _, ch := range [][]int16{left, right} {
+		f = append(f, 0x02) // verbatim subframe, no wasted bits
+		for _, s := range ch {
+			f = append(f, byte(uint16(s)>>8), byte(s))
+		}
+	}
+	crc := crc16(f)
+	return append(f, byte(crc>>8), byte(crc))
+}
+
+// builds the signature and a STREAMINFO block for the frames above
+func testFlacHeader() []byte {
+	h := append([]byte{}, flacSignature...)
+	h = append(h, 0x80, 0, 0, 34) // last block, STREAMINFO, 34 bytes
+	body := make([]byte, 34)
+	binary.BigEndian.PutUint16(body[0:], 16)
+	binary.BigEndian.PutUint16(body[2:], 16)
+	// 20 bits sample rate, 3 bits channels-1, 5 bits bps-1
+	binary.BigEndian.PutUint32(body[10:], 44100<<12|1<<9|15<<4)
+	return append(h, body...)
+}
+
+func crc8(b []byte) byte {
+	var crc byte
+	for _, x := range b {
+		crc ^= x
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func crc16(b []byte) uint16 {
+	var crc uint16
+	for _, x := range b {
+		crc ^= uint16(x) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func testPCM(left, right []int16) []byte {
+	var out []byte
+	for i := range left {
+		out = append(out, byte(left[i]), byte(uint16(left[i])>>8))
+		out = append(out, byte(right[i]), byte(uint16(right[i])>>8))
+	}
+	return out
+}
+
+func TestFlacDecodeSplitFrames(t *testing.T) {
+	left, right := []int16{1, -2, 300, -32768}, []int16{32767, 0, -5, 6}
+	stream := append(testFlacHeader(), testFlacFrame(0, left, right)...)
+	stream = append(stream, testFlacFrame(1, right, left)...)
+	want := append(testPCM(left, right), testPCM(right, left)...)
+
+	// feed the stream in chunks which don't line up with frames
+	d := newFlacDecoder()
+	var got []byte
+	for i := 0; i < len(stream); i += 7 {
+		end := i + 7
+		if end > len(stream) {
+			end = len(stream)
+		}
+		got = append(got, d.decode(stream[i:end])...)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+	if d.info == nil || d.info.SampleRate != 44100 {
+		t.Errorf("Expected: STREAMINFO with 44100 Hz\r\n Got: %+v", d.info)
+	}
+}
+
+func TestFlacDecodeReservedBlock(t *testing.T) {
+	left, right := []int16{1, 2}, []int16{3, 4}
+	// a reserved type 7 block of 5 bytes before the STREAMINFO
+	stream := append([]byte{}, flacSignature...)
+	stream = append(stream, 7, 0, 0, 5, 0xFF, 0xF8, 0x80, 0, 34)
+	stream = append(stream, testFlacHeader()[len(flacSignature):]...)
+	stream = append(stream, testFlacFrame(0, left, right)...)
+
+	d := newFlacDecoder()
+	// split inside the reserved block's body, which must wait for the rest
+	got := d.decode(stream[:len(flacSignature)+6])
+	got = append(got, d.decode(stream[len(flacSignature)+6:])...)
+	if want := testPCM(left, right); !bytes.Equal(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+	if d.info == nil || d.info.SampleRate != 44100 {
+		t.Errorf("Expected: STREAMINFO after the reserved block\r\n Got: %+v", d.info)
+	}
+}
+
+func TestFlacDecodeResync(t *testing.T) {
+	left, right := []int16{1, 2}, []int16{3, 4}
+	corrupt := testFlacFrame(0, left, right)
+	corrupt[len(corrupt)-1] ^= 0xFF // break the CRC
+	stream := append(corrupt, testFlacFrame(1, left, right)...)
+
+	d := newFlacDecoder()
+	if got, want := d.decode(stream), testPCM(left, right); !bytes.Equal(got, want) {
+		t.Errorf("Expected: %v\r\n Got: %v", want, got)
+	}
+	d.free()
+	if d.buf != nil {
+		t.Error("Expected: buffer released by free")
+	}
+}
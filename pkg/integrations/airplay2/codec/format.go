@@ -0,0 +1,39 @@
+package codec
+
+import "fmt"
+
+// Format describes the PCM a Handler decodes to
+type Format struct {
+	BitDepth   int
+	Channels   int
+	SampleRate int
+	// MaxFrameSamples is the most samples per channel in one decoded packet, 0 if unbounded
+	MaxFrameSamples int
+}
+
+// DefaultFormat is the layout the rest of the pipeline assumes: 16 bit stereo at 44.1kHz
+var DefaultFormat = Format{
+	BitDepth:   16,
+	Channels:   2,
+	SampleRate: 44100,
+}
+
+// FrameSize returns the number of bytes in one sample across all channels
+func (f Format) FrameSize() int {
+	return f.BitDepth / 8 * f.Channels
+}
+
+// checks that n bytes of decoded audio line up with the format
+func (f Format) check(n int) error {
+	size := f.FrameSize()
+	if size <= 0 {
+		return fmt.Errorf("invalid format %d bit, %d channels", f.BitDepth, f.Channels)
+	}
+	if n%size != 0 {
+		return fmt.Errorf("%d bytes is not a whole number of %d byte frames (%d bit, %d channels)", n, size, f.BitDepth, f.Channels)
+	}
+	if f.MaxFrameSamples > 0 && n/size > f.MaxFrameSamples {
+		return fmt.Errorf("%d samples per channel exceeds the declared frame length of %d", n/size, f.MaxFrameSamples)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/LedFx/ledfx/pkg/handlers/rtsp"
+	"github.com/LedFx/ledfx/pkg/handlers/sdp"
+
+	alac "github.com/carterpeel/go.alac"
+)
+
+func TestOutputFormat(t *testing.T) {
+	a, _ := alac.New()
+	alacFormat := (&Handler{a: a, frameLength: alacFrameLength}).OutputFormat()
+	if want := (Format{16, 2, 44100, alacFrameLength}); alacFormat != want {
+		t.Errorf("Expected: %+v\r\n Got: %+v", want, alacFormat)
+	}
+
+	d := newFlacDecoder()
+	h := &Handler{decoderFn: d.decode, f: d}
+	h.Decode(testFlacHeader())
+	if got := h.OutputFormat(); got != DefaultFormat {
+		t.Errorf("Expected: %+v\r\n Got: %+v", DefaultFormat, got)
+	}
+}
+
+func TestFormatCheck(t *testing.T) {
+	f := Format{BitDepth: 16, Channels: 2, SampleRate: 44100, MaxFrameSamples: 352}
+	tests := []struct {
+		n  int
+		ok bool
+	}{
+		{352 * 4, true},
+		{4, true},
+		{6, false},               // odd number of stereo samples
+		{353 * 4, false},         // longer than a frame
+		{352 * 4 * 3 / 2, false}, // e.g. 24 bit output read as 16 bit
+	}
+	for _, tt := range tests {
+		if err := f.check(tt.n); (err == nil) != tt.ok {
+			t.Errorf("Expected: ok=%v for %d bytes\r\n Got: %v", tt.ok, tt.n, err)
+		}
+	}
+
+	h := &Handler{decoderFn: func(data []byte) []byte { return data }}
+	h.SetVerify(true)
+	h.Decode(nil)
+	if h.verified {
+		t.Error("Expected: empty packets not verified")
+	}
+	h.Decode([]byte{1, 2, 3})
+	if !h.verified {
+		t.Error("Expected: first packet verified")
+	}
+}
+
+func TestFrameLength(t *testing.T) {
+	session := func(fmtp string) *rtsp.Session {
+		return rtsp.NewSession(&sdp.SessionDescription{Attributes: map[string]string{
+			"rtpmap": "96 AppleLossless",
+			"fmtp":   fmtp,
+		}}, nil)
+	}
+	h, err := GetCodec(session("96 704 0 16 40 10 14 2 255 0 0 44100"))
+	if err != nil || h.OutputFormat().MaxFrameSamples != 704 {
+		t.Errorf("Expected: frame length 704 from fmtp\r\n Got: %+v %v", h, err)
+	}
+	if h, err = GetCodec(session("")); err != nil {
+		t.Fatalf("Error getting codec: %v", err)
+	}
+	if got := h.OutputFormat().MaxFrameSamples; got != alacFrameLength {
+		t.Errorf("Expected: default frame length %d\r\n Got: %d", alacFrameLength, got)
+	}
+
+	h.checkFrameLength(alacFrameLength * 4)
+	h.checkFrameLength(100 * 4)
+	h.checkFrameLength(200 * 4)
+	if h.Mismatched() != 2 {
+		t.Errorf("Expected: 2 mismatched packets\r\n Got: %d", h.Mismatched())
+	}
+}
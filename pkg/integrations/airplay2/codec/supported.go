@@ -0,0 +1,82 @@
+package codec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/LedFx/ledfx/pkg/handlers/sdp"
+)
+
+// CheckFormat inspects the SDP of an announced stream, returning ErrUnsupportedFormat unless it
+// is one the decoders handle: 16 bit ALAC, FLAC or 16 bit PCM, in mono or stereo at 44.1kHz.
+// High resolution and DSD streams are rejected rather than decoded into noise.
+func CheckFormat(description *sdp.SessionDescription) error {
+	if description == nil {
+		return fmt.Errorf("no session description: %w", ErrUnsupportedFormat)
+	}
+	rtpmap := description.Attributes["rtpmap"]
+	switch {
+	case strings.Contains(rtpmap, "AppleLossless"):
+		return checkALAC(description.Attributes["fmtp"])
+	case strings.Contains(strings.ToLower(rtpmap), "flac"):
+		return checkRTPMap("FLAC", rtpmap)
+	case strings.Contains(rtpmap, "L16"):
+		return checkRTPMap("PCM", rtpmap)
+	default:
+		return fmt.Errorf("codec '%s': %w", rtpmap, ErrUnsupportedFormat)
+	}
+}
+
+// checks an ALAC fmtp attribute, eg. "96 352 0 16 40 10 14 2 255 0 0 44100".
+// A missing one leaves the decoder's defaults, which are supported.
+func checkALAC(fmtp string) error {
+	fields := strings.Fields(fmtp)
+	if len(fields) == 0 {
+		return nil
+	}
+	if len(fields) < 12 {
+		return fmt.Errorf("ALAC fmtp '%s' has %d fields, expected 12: %w", fmtp, len(fields), ErrUnsupportedFormat)
+	}
+	field := func(i int) int {
+		n, _ := strconv.Atoi(fields[i])
+		return n
+	}
+	frameLength, bitDepth, channels, sampleRate := field(1), field(3), field(7), field(11)
+	switch {
+	case frameLength <= 0 || frameLength > alacMaxFrameLength:
+		return fmt.Errorf("ALAC frame length %d: %w", frameLength, ErrUnsupportedFormat)
+	case bitDepth != DefaultFormat.BitDepth:
+		return fmt.Errorf("ALAC at %d bit: %w", bitDepth, ErrUnsupportedFormat)
+	case channels != DefaultFormat.Channels:
+		// the ALAC decoder is fixed at stereo
+		return fmt.Errorf("ALAC with %d channels: %w", channels, ErrUnsupportedFormat)
+	}
+	return checkLayout("ALAC", channels, sampleRate)
+}
+
+// checks a codec whose rtpmap gives the layout, eg. "96 L16/44100/2" or "96 FLAC/44100/2".
+// Channels default to 1 when left out, as RTP specifies.
+func checkRTPMap(codec, rtpmap string) error {
+	_, encoding, _ := strings.Cut(rtpmap, " ")
+	parts := strings.Split(encoding, "/")
+	channels := 1
+	if len(parts) < 2 {
+		return fmt.Errorf("%s '%s' has no sample rate: %w", codec, rtpmap, ErrUnsupportedFormat)
+	}
+	sampleRate, _ := strconv.Atoi(parts[1])
+	if len(parts) > 2 {
+		channels, _ = strconv.Atoi(parts[2])
+	}
+	return checkLayout(codec, channels, sampleRate)
+}
+
+func checkLayout(codec string, channels, sampleRate int) error {
+	if channels < 1 || channels > DefaultFormat.Channels {
+		return fmt.Errorf("%s with %d channels: %w", codec, channels, ErrUnsupportedFormat)
+	}
+	if sampleRate != DefaultFormat.SampleRate {
+		return fmt.Errorf("%s at %dHz: %w", codec, sampleRate, ErrUnsupportedFormat)
+	}
+	return nil
+}
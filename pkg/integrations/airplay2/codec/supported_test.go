@@ -0,0 +1,40 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/LedFx/ledfx/pkg/handlers/sdp"
+)
+
+func TestCheckFormat(t *testing.T) {
+	tests := []struct {
+		rtpmap, fmtp string
+		ok           bool
+	}{
+		{"96 AppleLossless", "96 352 0 16 40 10 14 2 255 0 0 44100", true},
+		{"96 AppleLossless", "", true},
+		{"96 AppleLossless", "96 352 0 24 40 10 14 2 255 0 0 96000", false}, // high resolution
+		{"96 AppleLossless", "96 352 0 16 40 10 14 2 255 0 0 48000", false},
+		{"96 AppleLossless", "96 352 0 16 40 10 14 6 255 0 0 44100", false}, // surround
+		{"96 AppleLossless", "96 352 0 16 40 10 14 1 255 0 0 44100", false}, // mono
+		{"96 AppleLossless", "96 4096 0 16 40 10 14 2 255 0 0 44100", false},
+		{"96 AppleLossless", "96 352 0 16", false},
+		{"96 FLAC/44100/2", "", true},
+		{"96 FLAC/44100", "", true},
+		{"96 FLAC/96000/2", "", false},
+		{"96 FLAC/44100/6", "", false},
+		{"96 FLAC", "", false},
+		{"96 L16/44100/2", "", true},
+		{"96 L16/48000/2", "", false},
+		{"96 mpeg4-generic/44100/2", "mode=AAC-eld", false},
+		{"96 DSD64/2822400/2", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		err := CheckFormat(&sdp.SessionDescription{Attributes: map[string]string{"rtpmap": tt.rtpmap, "fmtp": tt.fmtp}})
+		if tt.ok && err != nil || !tt.ok && !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("Expected: supported=%v for %q %q\r\n Got: %v", tt.ok, tt.rtpmap, tt.fmtp, err)
+		}
+	}
+}
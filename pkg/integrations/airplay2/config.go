@@ -2,5 +2,41 @@ package airplay2
 
 type Config struct {
 	AdvertisementName string
-	Port              int
+	// RTSPPort is the RTSP listen port. 0 picks any free port, which is advertised over mDNS
+	// and reported by Server.Port once the server has started.
+	RTSPPort int
+
+	// IPv4Only disables IPv6 for the RTSP listener and the mDNS advertisement.
+	IPv4Only bool
+
+	// Interface restricts the RTSP listener and the mDNS advertisement to one network interface,
+	// by name such as "eth0". Empty uses every interface.
+	Interface string
+	// BindIP restricts them to one of the host's addresses. With Interface set it must be
+	// assigned to that interface; without it, the interface holding it is used.
+	BindIP string
+
+	// DisableConcealment passes lost packets through as gaps instead of fading out over them.
+	DisableConcealment bool
+
+	// VerifyFormat checks the first decoded packet of each session against the codec's
+	// declared output format and logs a warning if they disagree. Meant for debugging.
+	VerifyFormat bool
+
+	// JitterDepth is the number of packets held to reorder late arrivals.
+	// Higher values smooth out network jitter at the cost of latency. 0 uses rtp.DefaultDepth.
+	JitterDepth int
+
+	// ReceiveBuffer is the milliseconds of audio queued between the network and the decoder, absorbing
+	// decoder stalls. Packets beyond it are dropped and counted. 0 uses rtsp.DefaultReceiveBuffer.
+	ReceiveBuffer int
+
+	// MaxClients limits the number of AirPlay clients the stream is relayed to. 0 is unlimited.
+	MaxClients int
+
+	// VolumeCurve maps the sender's volume to the gain applied to the audio. Empty is VolumeCurveLinear.
+	VolumeCurve VolumeCurve
+
+	// StatePath, if set, is a file saved with Server.SaveState to restore the name, volume and max clients from.
+	StatePath string
 }
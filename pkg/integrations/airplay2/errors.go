@@ -4,4 +4,11 @@ import "fmt"
 
 var (
 	ErrDeviceNotFound = fmt.Errorf("device not found")
+	ErrMaxClients     = fmt.Errorf("maximum number of clients reached")
+	ErrClientNotFound = fmt.Errorf("client not found")
+
+	ErrInvalidVolume      = fmt.Errorf("volume must be between 0 and 1")
+	ErrInvalidVolumeCurve = fmt.Errorf("invalid volume curve")
+
+	ErrInvalidBind = fmt.Errorf("invalid AirPlay bind address")
 )
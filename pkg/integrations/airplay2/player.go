@@ -3,37 +3,73 @@ package airplay2
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"sync"
 	"unsafe"
 
 	"github.com/LedFx/ledfx/pkg/audio"
 	"github.com/LedFx/ledfx/pkg/handlers/player"
 	"github.com/LedFx/ledfx/pkg/handlers/raop"
+	"github.com/LedFx/ledfx/pkg/handlers/rtp"
 	"github.com/LedFx/ledfx/pkg/handlers/rtsp"
+	"github.com/LedFx/ledfx/pkg/handlers/sdp"
 	"github.com/LedFx/ledfx/pkg/integrations/airplay2/codec"
 	log "github.com/LedFx/ledfx/pkg/logger"
+
+	"go.uber.org/atomic"
 )
 
 type audioPlayer struct {
 	/* Variables that are looped through often belong at the top of the struct */
 	wg sync.WaitGroup
 
-	byteWriter *audio.AsyncMultiWriter
+	input      io.Writer               // decoded audio is written here, on its way to byteWriter
+	byteWriter *audio.AsyncMultiWriter // the outputs, which relay clients are added to
+
+	sessionActive, muted bool
+
+	// routed indicates whether decoded audio is forwarded to input.
+	routed *atomic.Bool
+
+	// conceal fills gaps in the RTP sequence with faded audio
+	conceal bool
 
-	hasClients, sessionActive, muted bool
+	// verifyFormat checks each session's first decoded packet against the codec's output format
+	verifyFormat bool
 
+	// jitterDepth is the number of packets held for reordering.
+	// jitter is the current session's buffer, if any.
+	jitterDepth int
+	jitter      atomic.Value
+
+	// session is the current RTSP session, if any
+	session atomic.Value
+	// decoder is the current session's codec, if any
+	decoder atomic.Value
+
+	// clientsMu guards the client registry, which changes as clients connect and disconnect
+	clientsMu  sync.RWMutex
 	numClients int
 	apClients  []*Client
 
 	quit chan bool
 
+	// sessionChanged, if set, is run as each sender's session starts and ends
+	sessionChanged func(addr string, active bool)
+
 	artwork []byte
 
 	title  string
 	artist string
 	album  string
 
-	volume float64
+	// volume is as reported by the sender, between 0 and 1. gain is volume mapped through curve,
+	// and is read for every packet played. volumeMu guards volume and curve, which are set from
+	// both the RTSP handler and the server.
+	volumeMu sync.Mutex
+	volume   float64
+	gain     *atomic.Float64
+	curve    VolumeCurve
 }
 
 func (p *audioPlayer) MarshalJSON() (b []byte, err error) {
@@ -42,75 +78,137 @@ func (p *audioPlayer) MarshalJSON() (b []byte, err error) {
 		Artist string `json:"artist"`
 		Album  string `json:"album"`
 
-		Volume float64 `json:"volume"`
+		Volume      float64     `json:"volume"`
+		VolumeCurve VolumeCurve `json:"volume_curve"`
 
 		HasClients    bool `json:"has_clients"`
 		NumClients    int  `json:"num_clients"`
 		SessionActive bool `json:"session_active"`
 		Muted         bool `json:"muted"`
+		Routed        bool `json:"routed"`
+
+		Jitter       rtp.JitterStats `json:"jitter"`
+		Dropped      uint64          `json:"dropped"`
+		DecodeErrors uint64          `json:"decode_errors"`
 	}{
 		Title:         p.title,
 		Artist:        p.artist,
 		Album:         p.album,
-		Volume:        p.volume,
-		HasClients:    p.hasClients,
-		NumClients:    p.numClients,
+		Volume:        p.Volume(),
+		VolumeCurve:   p.VolumeCurve(),
+		HasClients:    p.NumClients() > 0,
+		NumClients:    p.NumClients(),
 		SessionActive: p.sessionActive,
 		Muted:         p.muted,
+		Routed:        p.routed.Load(),
+		Jitter:        p.JitterStats(),
+		Dropped:       p.Dropped(),
+		DecodeErrors:  p.DecodeErrors(),
 	})
 }
-func newPlayer(byteWriter *audio.AsyncMultiWriter) *audioPlayer {
+func newPlayer(input io.Writer, byteWriter *audio.AsyncMultiWriter) *audioPlayer {
 	p := &audioPlayer{
 		apClients:  make([]*Client, 0),
 		volume:     1,
+		gain:       atomic.NewFloat64(1),
+		curve:      VolumeCurveLinear,
+		routed:     atomic.NewBool(true),
 		quit:       make(chan bool),
 		wg:         sync.WaitGroup{},
+		input:      input,
 		byteWriter: byteWriter,
 	}
 
 	return p
 }
 
+// CheckFormat rejects streams the codecs can't decode, so the server can refuse them when announced
+func (p *audioPlayer) CheckFormat(description *sdp.SessionDescription) error {
+	return codec.CheckFormat(description)
+}
+
 func (p *audioPlayer) Play(session *rtsp.Session) {
+	decoder, err := codec.GetCodec(session)
+	if err != nil {
+		log.Logger.WithField("context", "AirPlay Player").Errorf("Not playing session: %v", err)
+		return
+	}
 	log.Logger.WithField("context", "AirPlay Player").Warnf("Starting new session")
 	p.sessionActive = true
-	decoder := codec.GetCodec(session)
+	decoder.SetConcealment(p.conceal)
+	decoder.SetVerify(p.verifyFormat)
+	jitter := rtp.NewJitterBuffer(p.jitterDepth)
+	p.jitter.Store(jitter)
+	p.session.Store(session)
+	p.decoder.Store(decoder)
+	addr := session.Description.ConnectData.ConnectionAddress
+	if p.sessionChanged != nil {
+		p.sessionChanged(addr, true)
+	}
 	go func(dc *codec.Handler) {
 		defer func() {
 			p.sessionActive = false
+			if p.sessionChanged != nil {
+				p.sessionChanged(addr, false)
+			}
 		}()
+		var lastSeq uint16
+		var started bool
 		for {
 			select {
-			case recvBuf, ok := <-session.DataChan:
-				switch {
-				case !ok:
+			case recv, ok := <-session.DataChan:
+				if !ok {
 					return
-				case p.muted:
-					continue
-				default:
-					func() {
-						defer func() {
-							if err := recover(); err != nil {
-								log.Logger.WithField("context", "AirPlay Player").Errorf("Recovered from panic during playStream: %v\n", err)
-							}
-						}()
-
-						recvBuf = dc.Decode(recvBuf)
-						codec.NormalizeAudio(recvBuf, p.volume)
-
-						if _, err := p.byteWriter.Write(recvBuf); err != nil {
-							log.Logger.WithField("context", "AirPlay Player").Errorf("Error writing to byteWriter: %v", err)
-						}
-					}()
+				}
+				for _, pkt := range jitter.Push(recv) {
+					// sequence numbers are tracked even while muted or unrouted, so resuming isn't seen as loss
+					lost := 0
+					if started {
+						lost = rtsp.SequenceGap(lastSeq, pkt.Sequence)
+					}
+					lastSeq, started = pkt.Sequence, true
+					switch {
+					case p.muted:
+						continue
+					case !p.routed.Load():
+						continue
+					default:
+						p.playPacket(dc, pkt, lost)
+					}
 				}
 			case <-p.quit:
-				log.Logger.WithField("context", "AirPlay Player").Warnf("Session with peer '%s' closed", session.Description.ConnectData.ConnectionAddress)
+				log.Logger.WithField("context", "AirPlay Player").Warnf("Session with peer '%s' closed", addr)
 				return
 			}
 		}
 	}(decoder)
 }
 
+// decodes and writes a packet, first concealing any packets lost before it
+func (p *audioPlayer) playPacket(dc *codec.Handler, pkt rtsp.Packet, lost int) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Logger.WithField("context", "AirPlay Player").Errorf("Recovered from panic during playStream: %v\n", err)
+		}
+	}()
+
+	if lost > 0 {
+		if fill := dc.Conceal(lost); fill != nil {
+			codec.NormalizeAudio(fill, p.gain.Load())
+			if _, err := p.input.Write(fill); err != nil {
+				log.Logger.WithField("context", "AirPlay Player").Errorf("Error writing decoded audio: %v", err)
+			}
+		}
+	}
+
+	recvBuf := dc.Decode(pkt.Payload)
+	codec.NormalizeAudio(recvBuf, p.gain.Load())
+
+	if _, err := p.input.Write(recvBuf); err != nil {
+		log.Logger.WithField("context", "AirPlay Player").Errorf("Error writing decoded audio: %v", err)
+	}
+}
+
 func bytesToAudioBufferUnsafe(p []byte) (out audio.Buffer) {
 	out = make([]int16, len(p))
 	var offset int
@@ -124,26 +222,85 @@ func twoBytesToInt16Unsafe(p []byte) (out int16) {
 	return *(*int16)(unsafe.Pointer(&p[0]))
 }
 
-func (p *audioPlayer) AddClient(client *Client) (err error) {
-	p.hasClients = true
-	p.apClients = append(p.apClients, client)
-	if err := p.byteWriter.AddWriter(p.apClients[p.numClients], client.WriterID()); err != nil {
+// AddClient registers a client and relays the stream to it. max limits the number of clients, 0 is unlimited.
+func (p *audioPlayer) AddClient(client *Client, max int) (err error) {
+	p.clientsMu.Lock()
+	defer p.clientsMu.Unlock()
+	if max > 0 && p.numClients >= max {
+		return fmt.Errorf("error adding client: %w", ErrMaxClients)
+	}
+	if err := p.byteWriter.AddWriter(client, client.WriterID()); err != nil {
 		return fmt.Errorf("error adding writer: %w", err)
 	}
+	p.apClients = append(p.apClients, client)
 	p.numClients++
 	return nil
 }
 
+// RemoveClient stops relaying the stream to a client and drops it from the registry
+func (p *audioPlayer) RemoveClient(client *Client) error {
+	p.clientsMu.Lock()
+	defer p.clientsMu.Unlock()
+	for i := range p.apClients {
+		if p.apClients[i] != client {
+			continue
+		}
+		p.apClients = append(p.apClients[:i], p.apClients[i+1:]...)
+		p.numClients--
+		if err := p.byteWriter.RemoveWriter(client.WriterID()); err != nil {
+			return fmt.Errorf("error removing writer: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("error removing client: %w", ErrClientNotFound)
+}
+
+// Clients returns a copy of the registered clients
+func (p *audioPlayer) Clients() []*Client {
+	p.clientsMu.RLock()
+	defer p.clientsMu.RUnlock()
+	return append([]*Client(nil), p.apClients...)
+}
+
+func (p *audioPlayer) NumClients() int {
+	p.clientsMu.RLock()
+	defer p.clientsMu.RUnlock()
+	return p.numClients
+}
+
 func (p *audioPlayer) SetVolume(volume float64) {
+	p.volumeMu.Lock()
 	p.volume = volume
-	if p.hasClients {
+	p.gain.Store(p.curve.gain(volume))
+	p.volumeMu.Unlock()
+	if p.NumClients() > 0 {
 		p.broadcastParam(raop.ParamVolume(prepareVolume(volume)))
 	}
 }
 
+// setVolumeCurve changes how volume maps to gain, keeping the current volume
+func (p *audioPlayer) setVolumeCurve(curve VolumeCurve) {
+	p.volumeMu.Lock()
+	defer p.volumeMu.Unlock()
+	p.curve = curve
+	p.gain.Store(curve.gain(p.volume))
+}
+
+func (p *audioPlayer) Volume() float64 {
+	p.volumeMu.Lock()
+	defer p.volumeMu.Unlock()
+	return p.volume
+}
+
+func (p *audioPlayer) VolumeCurve() VolumeCurve {
+	p.volumeMu.Lock()
+	defer p.volumeMu.Unlock()
+	return p.curve
+}
+
 func (p *audioPlayer) SetMute(isMuted bool) {
 	p.muted = isMuted
-	if p.hasClients {
+	if p.NumClients() > 0 {
 		p.broadcastParam(raop.ParamMuted(isMuted))
 	}
 	if isMuted {
@@ -151,6 +308,46 @@ func (p *audioPlayer) SetMute(isMuted bool) {
 	}
 }
 
+// SetRouted attaches (true) or detaches (false) the decoded stream from the
+// shared byteWriter. The session itself is left untouched.
+func (p *audioPlayer) SetRouted(routed bool) {
+	if p.routed.Swap(routed) != routed {
+		if routed {
+			log.Logger.WithField("context", "AirPlay Player").Infoln("Routing stream to outputs...")
+		} else {
+			log.Logger.WithField("context", "AirPlay Player").Infoln("Unrouting stream from outputs...")
+		}
+	}
+}
+
+// JitterStats returns the current session's jitter buffer stats
+func (p *audioPlayer) JitterStats() rtp.JitterStats {
+	if j, ok := p.jitter.Load().(*rtp.JitterBuffer); ok {
+		return j.Stats()
+	}
+	return rtp.JitterStats{Depth: p.jitterDepth}
+}
+
+// Dropped returns the number of packets the current session dropped because decoding fell behind
+func (p *audioPlayer) Dropped() uint64 {
+	if s, ok := p.session.Load().(*rtsp.Session); ok {
+		return s.Dropped()
+	}
+	return 0
+}
+
+// DecodeErrors returns the number of packets the current session failed to decode
+func (p *audioPlayer) DecodeErrors() uint64 {
+	if dc, ok := p.decoder.Load().(*codec.Handler); ok {
+		return dc.DecodeErrorCount()
+	}
+	return 0
+}
+
+func (p *audioPlayer) IsRouted() bool {
+	return p.routed.Load()
+}
+
 func (p *audioPlayer) GetIsMuted() bool {
 	return p.muted
 }
@@ -159,7 +356,7 @@ func (p *audioPlayer) SetTrack(album string, artist string, title string) {
 	p.album = album
 	p.artist = artist
 	p.title = title
-	if p.hasClients {
+	if p.NumClients() > 0 {
 		p.broadcastParam(raop.ParamTrackInfo{
 			Album:  album,
 			Artist: artist,
@@ -170,7 +367,7 @@ func (p *audioPlayer) SetTrack(album string, artist string, title string) {
 
 func (p *audioPlayer) SetAlbumArt(artwork []byte) {
 	p.artwork = artwork
-	if p.hasClients {
+	if p.NumClients() > 0 {
 		p.broadcastParam(raop.ParamAlbumArt(artwork))
 	}
 }
@@ -209,7 +406,7 @@ func (p *audioPlayer) Close() {
 }
 
 func (p *audioPlayer) broadcastParam(par interface{}) {
-	for i := 0; i < p.numClients; i++ {
-		p.apClients[i].SetParam(par)
+	for _, client := range p.Clients() {
+		client.SetParam(par)
 	}
 }
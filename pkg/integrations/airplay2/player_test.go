@@ -0,0 +1,100 @@
+package airplay2
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/LedFx/ledfx/pkg/audio"
+	"github.com/LedFx/ledfx/pkg/handlers/rtsp"
+)
+
+func testClient(i int) *Client {
+	return &Client{session: &rtsp.Session{RemotePorts: rtsp.PortSet{Address: fmt.Sprintf("10.0.0.%d", i)}}}
+}
+
+// run with -race to catch unguarded access to the client registry
+func TestClientsConcurrent(t *testing.T) {
+	w := audio.NewAsyncMultiWriter()
+	s := NewServer(Config{}, w, w)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cl := testClient(i)
+			for n := 0; n < 100; n++ {
+				if err := s.AddClient(cl); err != nil {
+					t.Errorf("Expected: client added\r\n Got: %v", err)
+					return
+				}
+				if err := s.RemoveClient(cl); err != nil {
+					t.Errorf("Expected: client removed\r\n Got: %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		select {
+		case <-done:
+			if got := s.Clients(); len(got) != 0 {
+				t.Errorf("Expected: no clients\r\n Got: %d", len(got))
+			}
+			return
+		default:
+			for _, cl := range s.Clients() {
+				_ = cl.WriterID()
+			}
+		}
+	}
+}
+
+func TestRemoveUnknownClient(t *testing.T) {
+	w := audio.NewAsyncMultiWriter()
+	s := NewServer(Config{}, w, w)
+	if err := s.AddClient(testClient(1)); err != nil {
+		t.Fatal(err)
+	}
+	clients := s.Clients()
+	clients[0] = nil // the copy is the caller's own
+	if s.Clients()[0] == nil {
+		t.Error("Expected: Clients to return a copy")
+	}
+	if err := s.RemoveClient(testClient(2)); err == nil {
+		t.Error("Expected: error removing a client that was never added")
+	}
+}
+
+func TestClientCallbacks(t *testing.T) {
+	w := audio.NewAsyncMultiWriter()
+	s := NewServer(Config{}, w, w)
+	var events []string
+	s.OnClientConnect(func(addr string) {
+		events = append(events, "connect "+addr)
+		// callbacks may call back into the server
+		_ = s.Clients()
+	})
+	s.OnClientDisconnect(func(addr string) { events = append(events, "disconnect "+addr) })
+
+	// relay outputs aren't senders
+	cl := testClient(1)
+	if err := s.AddClient(cl); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveClient(cl); err != nil {
+		t.Fatal(err)
+	}
+
+	s.player.sessionChanged("10.0.0.1", true)
+	s.player.sessionChanged("10.0.0.1", false)
+	if want := "[connect 10.0.0.1 disconnect 10.0.0.1]"; fmt.Sprint(events) != want {
+		t.Errorf("Expected: %s\r\n Got: %v", want, events)
+	}
+}
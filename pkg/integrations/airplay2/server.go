@@ -2,12 +2,15 @@ package airplay2
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/LedFx/ledfx/pkg/audio"
 	"github.com/LedFx/ledfx/pkg/handlers/raop"
+	"github.com/LedFx/ledfx/pkg/handlers/rtp"
 	log "github.com/LedFx/ledfx/pkg/logger"
 )
 
@@ -23,17 +26,31 @@ type Server struct {
 	svc     *raop.AirplayServer
 	stopped bool
 
+	// where the server is bound once started, empty when on every interface
+	bindIP    string
+	bindIface string
+
+	// run as senders, such as phones, start and end sessions
+	onConnect    []func(addr string)
+	onDisconnect []func(addr string)
+
 	done chan struct{}
 }
 
 func (s *Server) MarshalJSON() (b []byte, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return json.Marshal(&struct {
 		AdvertName string       `json:"advertisement_name"`
 		Port       int          `json:"port"`
+		BindIP     string       `json:"bind_ip,omitempty"`
+		Interface  string       `json:"interface,omitempty"`
 		Player     *audioPlayer `json:"player"`
 	}{
 		AdvertName: s.conf.AdvertisementName,
-		Port:       s.conf.Port,
+		Port:       s.conf.RTSPPort,
+		BindIP:     s.bindIP,
+		Interface:  s.bindIface,
 		Player:     s.player,
 	})
 }
@@ -42,11 +59,27 @@ func (s *Server) Artwork() (b []byte) {
 	return s.player.GetAlbumArt()
 }
 
-func NewServer(conf Config, byteWriter *audio.AsyncMultiWriter) (s *Server) {
-	pl := newPlayer(byteWriter)
+// NewServer writes decoded audio to input and adds relay clients to byteWriter, the outputs
+func NewServer(conf Config, input io.Writer, byteWriter *audio.AsyncMultiWriter) (s *Server) {
+	pl := newPlayer(input, byteWriter)
+	pl.conceal = !conf.DisableConcealment
+	pl.verifyFormat = conf.VerifyFormat
+
+	curve, err := ParseVolumeCurve(string(conf.VolumeCurve))
+	if err != nil {
+		log.Logger.WithField("context", "AirPlay Server").Warnf("Using linear volume: %v", err)
+		curve = VolumeCurveLinear
+	}
+	conf.VolumeCurve = curve
+	pl.setVolumeCurve(curve)
 
-	if conf.Port == 0 {
-		conf.Port = 7000
+	if conf.JitterDepth == 0 {
+		conf.JitterDepth = rtp.DefaultDepth
+	}
+	pl.jitterDepth = conf.JitterDepth
+
+	if conf.StatePath != "" {
+		pl.SetVolume(loadInitialState(&conf))
 	}
 
 	if conf.AdvertisementName == "" {
@@ -58,23 +91,163 @@ func NewServer(conf Config, byteWriter *audio.AsyncMultiWriter) (s *Server) {
 		conf:   &conf,
 		player: pl, // Port range: 1024 through 65530
 		done:   make(chan struct{}),
-		svc:    raop.NewAirplayServer(conf.Port, conf.AdvertisementName, pl),
+		svc:    raop.NewAirplayServer(conf.RTSPPort, conf.AdvertisementName, pl),
 	}
+	pl.sessionChanged = s.sessionChanged
+	s.svc.SetIPv4Only(conf.IPv4Only)
+	s.svc.SetReceiveBuffer(conf.ReceiveBuffer)
 
 	return s
 }
 
 func (s *Server) AddClient(client *Client) error {
-	return s.player.AddClient(client)
+	s.mu.Lock()
+	max := s.conf.MaxClients
+	s.mu.Unlock()
+	return s.player.AddClient(client, max)
+}
+
+// RemoveClient stops relaying the stream to a client added with AddClient
+func (s *Server) RemoveClient(client *Client) error {
+	return s.player.RemoveClient(client)
+}
+
+// OnClientConnect registers fn to run each time a sender, such as a phone, starts a session
+// streaming to the server. fn is given the sender's address. Callbacks run in the order
+// registered, on the session's goroutine, so they should return quickly.
+// Clients added with AddClient are where the stream is relayed to, and don't run these.
+func (s *Server) OnClientConnect(fn func(addr string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onConnect = append(s.onConnect, fn)
+}
+
+// OnClientDisconnect registers fn to run each time a sender's session ends
+func (s *Server) OnClientDisconnect(fn func(addr string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onDisconnect = append(s.onDisconnect, fn)
+}
+
+// runs the callbacks for a session starting or ending, outside the lock so they can call back into the server
+func (s *Server) sessionChanged(addr string, active bool) {
+	s.mu.Lock()
+	callbacks := s.onDisconnect
+	if active {
+		callbacks = s.onConnect
+	}
+	fns := make([]func(string), len(callbacks))
+	copy(fns, callbacks)
+	s.mu.Unlock()
+	for _, fn := range fns {
+		fn(addr)
+	}
+}
+
+// SimulateSession runs the player's session callbacks as a sender's session starting or
+// ending would, so code built on the server's callbacks can be tested without a sender.
+func (s *Server) SimulateSession(addr string, active bool) {
+	s.player.sessionChanged(addr, active)
+}
+
+// Clients returns a copy of the clients the stream is relayed to.
+// It is safe to call while clients are added and removed.
+func (s *Server) Clients() []*Client {
+	return s.player.Clients()
+}
+
+// Config returns a copy of the server's options, with the port resolved once started
+func (s *Server) Config() Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.conf
+}
+
+// Port returns the RTSP port. With RTSPPort 0 it is only known once the server has started.
+func (s *Server) Port() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conf.RTSPPort
+}
+
+// RouteToOutputs attaches the AirPlay stream to the shared AsyncMultiWriter.
+func (s *Server) RouteToOutputs() {
+	s.player.SetRouted(true)
 }
 
+// UnrouteFromOutputs detaches the AirPlay stream from the shared AsyncMultiWriter
+// without stopping the server, so senders can still connect.
+func (s *Server) UnrouteFromOutputs() {
+	s.player.SetRouted(false)
+}
+
+// Routed reports whether the AirPlay stream is currently attached to the outputs.
+func (s *Server) Routed() bool {
+	return s.player.IsRouted()
+}
+
+// Play asks the connected sender to start playback over DACP
+func (s *Server) Play() error {
+	return s.remote((*raop.DacpClient).Play)
+}
+
+// Pause asks the connected sender to pause playback over DACP
+func (s *Server) Pause() error {
+	return s.remote((*raop.DacpClient).Pause)
+}
+
+// Next asks the connected sender to skip to the next track over DACP
+func (s *Server) Next() error {
+	return s.remote((*raop.DacpClient).Next)
+}
+
+// Previous asks the connected sender to go back to the previous track over DACP
+func (s *Server) Previous() error {
+	return s.remote((*raop.DacpClient).Previous)
+}
+
+func (s *Server) remote(cmd func(*raop.DacpClient) error) error {
+	client, err := s.svc.Remote()
+	if err != nil {
+		return err
+	}
+	if err := cmd(client); err != nil {
+		return fmt.Errorf("error sending DACP command: %w", err)
+	}
+	return nil
+}
+
+// ReannounceService re-registers the server's mDNS records with the host's current addresses.
+func (s *Server) ReannounceService() error {
+	return s.svc.ReannounceService()
+}
+
+// Start listens and advertises the server. It returns ErrInvalidBind if Config.Interface
+// or Config.BindIP don't match this host.
 func (s *Server) Start() error {
+	ip, iface, err := resolveBind(s.conf.Interface, s.conf.BindIP, s.conf.IPv4Only)
+	if err != nil {
+		return err
+	}
+	if ip != nil {
+		s.svc.SetBind(ip, iface)
+		s.mu.Lock()
+		s.bindIP, s.bindIface = raop.BindHost(ip, iface), iface.Name
+		s.mu.Unlock()
+		log.Logger.WithField("context", "AirPlay Server").Infof("Binding to %s on interface %s", s.bindIP, iface.Name)
+	}
+
 	errCh := make(chan error)
 	go func() {
 		defer func() {
 			s.done <- struct{}{}
 		}()
 		err := s.svc.Start(true)
+		if err == nil {
+			s.mu.Lock()
+			s.conf.RTSPPort = s.svc.Port()
+			s.mu.Unlock()
+		}
 		errCh <- err
 		if err != nil {
 			log.Logger.WithField("context", "AirPlay Server").Errorf("Error starting AirPlay server: %v", err)
@@ -89,6 +262,16 @@ func (s *Server) Wait() {
 	<-s.done
 }
 
+// WaitTimeout waits up to d for the server to finish, returning false if it hasn't
+func (s *Server) WaitTimeout(d time.Duration) bool {
+	select {
+	case <-s.done:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
 func (s *Server) Stop() {
 	s.stopped = true
 	if s.svc != nil {
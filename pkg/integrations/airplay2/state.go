@@ -0,0 +1,150 @@
+package airplay2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	log "github.com/LedFx/ledfx/pkg/logger"
+)
+
+// State is the part of the server configuration which can change at runtime
+// and is kept across restarts
+type State struct {
+	Name       string  `json:"name"`
+	Volume     float64 `json:"volume"`
+	MaxClients int     `json:"max_clients"`
+}
+
+// State returns the server's current state
+func (s *Server) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return State{
+		Name:       s.conf.AdvertisementName,
+		Volume:     s.player.Volume(),
+		MaxClients: s.conf.MaxClients,
+	}
+}
+
+// SaveState writes the server's current state to path as JSON
+func (s *Server) SaveState(path string) error {
+	b, err := json.MarshalIndent(s.State(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling state: %w", err)
+	}
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("error writing state file '%s': %w", path, err)
+	}
+	return nil
+}
+
+// LoadState reads a state saved by SaveState and applies it to the running server
+func (s *Server) LoadState(path string) error {
+	st, err := readState(path)
+	if err != nil {
+		return err
+	}
+	if err := s.SetName(st.Name); err != nil {
+		return err
+	}
+	if err := s.SetVolume(st.Volume); err != nil {
+		return err
+	}
+	return s.SetMaxClients(st.MaxClients)
+}
+
+// SetName changes the advertised name of the server
+func (s *Server) SetName(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.svc.ChangeName(name); err != nil {
+		return fmt.Errorf("error changing name: %w", err)
+	}
+	s.conf.AdvertisementName = name
+	return nil
+}
+
+// SetVolume sets the playback volume, between 0 and 1
+func (s *Server) SetVolume(volume float64) error {
+	if volume < 0 || volume > 1 {
+		return fmt.Errorf("%w, got %f", ErrInvalidVolume, volume)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.player.SetVolume(volume)
+	return nil
+}
+
+// Volume returns the playback volume, between 0 and 1, before it is mapped through the volume curve
+func (s *Server) Volume() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.player.Volume()
+}
+
+// SetVolumeCurve changes how the volume maps to the gain applied to the audio.
+// The volume itself is unchanged, so senders and Volume see the same value.
+func (s *Server) SetVolumeCurve(curve VolumeCurve) error {
+	curve, err := ParseVolumeCurve(string(curve))
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conf.VolumeCurve = curve
+	s.player.setVolumeCurve(curve)
+	return nil
+}
+
+func (s *Server) VolumeCurve() VolumeCurve {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.player.VolumeCurve()
+}
+
+// SetMaxClients limits the number of AirPlay clients the stream is relayed to. 0 is unlimited.
+// Clients already added are kept.
+func (s *Server) SetMaxClients(n int) error {
+	if n < 0 {
+		return fmt.Errorf("max clients %d must not be negative", n)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conf.MaxClients = n
+	return nil
+}
+
+func readState(path string) (st State, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return st, fmt.Errorf("error reading state file '%s': %w", path, err)
+	}
+	if err := json.Unmarshal(b, &st); err != nil {
+		return st, fmt.Errorf("error unmarshalling state: %w", err)
+	}
+	return st, nil
+}
+
+// applies a saved state to conf before the server is created. A missing file is not an error.
+func loadInitialState(conf *Config) (volume float64) {
+	volume = 1
+	st, err := readState(conf.StatePath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Logger.WithField("context", "AirPlay Server").Warnf("Ignoring saved state: %v", err)
+		}
+		return volume
+	}
+	if st.Name != "" {
+		conf.AdvertisementName = st.Name
+	}
+	if st.Volume >= 0 && st.Volume <= 1 {
+		volume = st.Volume
+	}
+	if st.MaxClients >= 0 {
+		conf.MaxClients = st.MaxClients
+	}
+	return volume
+}
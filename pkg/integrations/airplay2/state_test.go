@@ -0,0 +1,58 @@
+package airplay2
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoadState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "airplay.json")
+
+	s := NewServer(Config{}, nil, nil)
+	if err := s.SetName("Living Room"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetVolume(0.5); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetMaxClients(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveState(path); err != nil {
+		t.Fatal(err)
+	}
+
+	want := State{Name: "Living Room", Volume: 0.5, MaxClients: 2}
+	restored := NewServer(Config{StatePath: path}, nil, nil)
+	if got := restored.State(); got != want {
+		t.Errorf("Expected: %+v\r\n Got: %+v", want, got)
+	}
+
+	other := NewServer(Config{}, nil, nil)
+	if err := other.LoadState(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := other.State(); got != want {
+		t.Errorf("Expected: %+v\r\n Got: %+v", want, got)
+	}
+}
+
+func TestMissingState(t *testing.T) {
+	s := NewServer(Config{StatePath: filepath.Join(t.TempDir(), "missing.json")}, nil, nil)
+	want := State{Name: "LedFX", Volume: 1}
+	if got := s.State(); got != want {
+		t.Errorf("Expected: %+v\r\n Got: %+v", want, got)
+	}
+	if err := s.LoadState("missing.json"); err == nil {
+		t.Error("Expected: error loading a missing state file")
+	}
+}
+
+func TestMaxClients(t *testing.T) {
+	s := NewServer(Config{MaxClients: 1}, nil, nil)
+	s.player.numClients = 1
+	if err := s.AddClient(&Client{}); !errors.Is(err, ErrMaxClients) {
+		t.Errorf("Expected: %v\r\n Got: %v", ErrMaxClients, err)
+	}
+}
@@ -0,0 +1,54 @@
+package airplay2
+
+import (
+	"fmt"
+	"math"
+)
+
+// VolumeCurve maps the volume reported by a sender, between 0 and 1, to the gain applied to the audio
+type VolumeCurve string
+
+const (
+	// VolumeCurveLinear applies the volume as the gain directly
+	VolumeCurveLinear VolumeCurve = "linear"
+	// VolumeCurveLogarithmic spreads the volume over 60dB, so equal steps sound equally loud
+	VolumeCurveLogarithmic VolumeCurve = "logarithmic"
+	// VolumeCurveAirPlay follows the AirPlay slider, which spans -30dB to 0dB
+	VolumeCurveAirPlay VolumeCurve = "airplay"
+)
+
+// decibels spanned by the volume range of each curve
+const (
+	logarithmicRange = 60
+	airPlayRange     = 30
+)
+
+// ParseVolumeCurve returns the curve named s. An empty name is VolumeCurveLinear.
+func ParseVolumeCurve(s string) (VolumeCurve, error) {
+	switch c := VolumeCurve(s); c {
+	case "":
+		return VolumeCurveLinear, nil
+	case VolumeCurveLinear, VolumeCurveLogarithmic, VolumeCurveAirPlay:
+		return c, nil
+	default:
+		return "", fmt.Errorf("%w '%s'", ErrInvalidVolumeCurve, s)
+	}
+}
+
+// gain returns the multiplier applied to samples at volume, between 0 and 1
+func (c VolumeCurve) gain(volume float64) float64 {
+	switch {
+	case volume <= 0:
+		return 0
+	case volume >= 1:
+		return 1
+	}
+	switch c {
+	case VolumeCurveLogarithmic:
+		return math.Pow(10, (volume-1)*logarithmicRange/20)
+	case VolumeCurveAirPlay:
+		return math.Pow(10, (volume-1)*airPlayRange/20)
+	default:
+		return volume
+	}
+}
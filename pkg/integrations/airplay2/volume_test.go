@@ -0,0 +1,76 @@
+package airplay2
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestVolumeCurve(t *testing.T) {
+	tests := []struct {
+		curve  VolumeCurve
+		volume float64
+		gain   float64
+	}{
+		{VolumeCurveLinear, 0.5, 0.5},
+		{VolumeCurveLogarithmic, 0.5, math.Pow(10, -30.0/20)},
+		{VolumeCurveAirPlay, 0.5, math.Pow(10, -15.0/20)},
+		{VolumeCurveAirPlay, 0, 0},
+		{VolumeCurveAirPlay, 1, 1},
+	}
+	for _, tt := range tests {
+		if got := tt.curve.gain(tt.volume); math.Abs(got-tt.gain) > 1e-9 {
+			t.Errorf("Expected: %s gain %f at %f\r\n Got: %f", tt.curve, tt.gain, tt.volume, got)
+		}
+	}
+
+	if _, err := ParseVolumeCurve("cubic"); !errors.Is(err, ErrInvalidVolumeCurve) {
+		t.Errorf("Expected: %v\r\n Got: %v", ErrInvalidVolumeCurve, err)
+	}
+}
+
+func TestServerVolumeCurve(t *testing.T) {
+	s := NewServer(Config{VolumeCurve: VolumeCurveAirPlay}, nil, nil)
+	if err := s.SetVolume(0.5); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.player.gain.Load(), VolumeCurveAirPlay.gain(0.5); got != want {
+		t.Errorf("Expected: gain %f\r\n Got: %f", want, got)
+	}
+
+	// changing the curve keeps the reported volume and remaps the gain
+	if err := s.SetVolumeCurve(VolumeCurveLinear); err != nil {
+		t.Fatal(err)
+	}
+	if s.Volume() != 0.5 || s.player.gain.Load() != 0.5 {
+		t.Errorf("Expected: volume and gain 0.5\r\n Got: %f, %f", s.Volume(), s.player.gain.Load())
+	}
+	if err := s.SetVolume(2); !errors.Is(err, ErrInvalidVolume) {
+		t.Errorf("Expected: %v\r\n Got: %v", ErrInvalidVolume, err)
+	}
+}
+
+// TestVolumeCurveConcurrent sets the volume as the sender does while the curve changes, for -race
+func TestVolumeCurveConcurrent(t *testing.T) {
+	s := NewServer(Config{}, nil, nil)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			s.player.SetVolume(0.5)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = s.SetVolumeCurve(VolumeCurveAirPlay)
+		}
+	}()
+	wg.Wait()
+
+	if got, want := s.player.gain.Load(), VolumeCurveAirPlay.gain(0.5); got != want {
+		t.Errorf("Expected: gain %f\r\n Got: %f", want, got)
+	}
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultRingLines is how many recent lines Recent can return
+const DefaultRingLines = 500
+
+// Line is a log entry kept by a RingHook
+type Line struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Context string    `json:"context,omitempty"`
+	Message string    `json:"msg"`
+}
+
+// RingHook keeps the most recent log lines in memory, so they can be fetched without access
+// to the log output. Once full, each new line replaces the oldest, so logging never waits on it.
+type RingHook struct {
+	mu    sync.Mutex
+	lines []Line
+	next  int // index the next line is written to
+	count int // lines kept, up to len(lines)
+}
+
+// NewRingHook creates a hook keeping the last n lines. n below 1 keeps just the last line.
+func NewRingHook(n int) *RingHook {
+	if n < 1 {
+		n = 1
+	}
+	return &RingHook{lines: make([]Line, n)}
+}
+
+func (*RingHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (r *RingHook) Fire(e *logrus.Entry) error {
+	context, _ := e.Data["context"].(string)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines[r.next] = Line{
+		Time:    e.Time,
+		Level:   e.Level.String(),
+		Context: context,
+		Message: e.Message,
+	}
+	r.next = (r.next + 1) % len(r.lines)
+	if r.count < len(r.lines) {
+		r.count++
+	}
+	return nil
+}
+
+// Recent returns up to n of the latest lines, oldest first. n <= 0 returns every line kept.
+func (r *RingHook) Recent(n int) []Line {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n <= 0 || n > r.count {
+		n = r.count
+	}
+	lines := make([]Line, n)
+	start := (r.next - n + len(r.lines)) % len(r.lines)
+	for i := range lines {
+		lines[i] = r.lines[(start+i)%len(r.lines)]
+	}
+	return lines
+}
+
+// Ring keeps the latest lines logged by Logger at its level
+var Ring = NewRingHook(DefaultRingLines)
+
+func init() {
+	Logger.AddHook(Ring)
+}
@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func fireLines(r *RingHook, n int) {
+	for i := 0; i < n; i++ {
+		_ = r.Fire(&logrus.Entry{
+			Level:   logrus.InfoLevel,
+			Message: fmt.Sprintf("line %d", i),
+			Data:    logrus.Fields{"context": "Test"},
+		})
+	}
+}
+
+func TestRingHookRecent(t *testing.T) {
+	cases := []struct {
+		size, fired, n int
+		want           []string
+	}{
+		{3, 0, 0, []string{}},
+		{3, 2, 0, []string{"line 0", "line 1"}},
+		{3, 5, 0, []string{"line 2", "line 3", "line 4"}},
+		{3, 5, 2, []string{"line 3", "line 4"}},
+		{3, 5, 10, []string{"line 2", "line 3", "line 4"}},
+		// sizes below 1 keep the last line rather than panicking
+		{0, 3, 0, []string{"line 2"}},
+		{-1, 3, 0, []string{"line 2"}},
+	}
+	for _, c := range cases {
+		r := NewRingHook(c.size)
+		fireLines(r, c.fired)
+		lines := r.Recent(c.n)
+		got := make([]string, len(lines))
+		for i, l := range lines {
+			got[i] = l.Message
+			if l.Context != "Test" || l.Level != "info" {
+				t.Errorf("Expected: context 'Test', level 'info'\r\n Got: %+v", l)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(c.want) {
+			t.Errorf("Expected: %v from a ring of %d after %d lines, Recent(%d)\r\n Got: %v", c.want, c.size, c.fired, c.n, got)
+		}
+	}
+}
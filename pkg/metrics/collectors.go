@@ -0,0 +1,26 @@
+package metrics
+
+// Metrics recorded by the audio subsystems
+var (
+	AudioRMS = NewGauge(
+		"ledfx_audio_rms",
+		"RMS level of the most recently analysed audio buffer, from 0 to 1",
+	)
+	CaptureOverflows = NewCounter(
+		"ledfx_capture_overflows_total",
+		"Number of local capture callbacks that reported an input overflow",
+	)
+	AirPlayClients = NewGauge(
+		"ledfx_airplay_clients",
+		"Number of AirPlay outputs the bridge is streaming to",
+	)
+	PlaybackDriftPPM = NewGauge(
+		"ledfx_playback_drift_ppm",
+		"Measured clock drift between the audio source and local playback, in parts per million",
+	)
+	DroppedFrames = NewCounterVec(
+		"ledfx_multiwriter_dropped_frames_total",
+		"Number of audio frames an AsyncMultiWriter output failed to accept",
+		"output",
+	)
+)
@@ -0,0 +1,203 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/LedFx/ledfx/pkg/logger"
+
+	"go.uber.org/atomic"
+)
+
+const Path = "/metrics"
+
+// Registry holds a set of metrics and serves them once enabled. Until then every update
+// to its metrics is a no-op, so subsystems can record unconditionally.
+type Registry struct {
+	enabled atomic.Bool
+	once    sync.Once
+
+	mu      sync.Mutex
+	metrics []*Metric
+}
+
+// NewRegistry returns an empty, disabled registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Default is the registry the package level functions use, and which the
+// subsystems' collectors are registered with.
+var Default = NewRegistry()
+
+// Enable registers the Prometheus exporter for r on mux.
+func (r *Registry) Enable(mux *http.ServeMux) {
+	r.once.Do(func() {
+		mux.Handle(Path, r)
+		r.enabled.Store(true)
+		log.Logger.WithField("context", "Metrics").Infof("Serving Prometheus metrics at %s", Path)
+	})
+}
+
+// Enabled reports whether the exporter for r has been registered.
+func (r *Registry) Enabled() bool {
+	return r.enabled.Load()
+}
+
+// Enable registers the Prometheus exporter for the Default registry on mux.
+func Enable(mux *http.ServeMux) {
+	Default.Enable(mux)
+}
+
+// Enabled reports whether the exporter for the Default registry has been registered.
+func Enabled() bool {
+	return Default.Enabled()
+}
+
+type metricType string
+
+const (
+	typeCounter metricType = "counter"
+	typeGauge   metricType = "gauge"
+)
+
+// Metric is a counter or gauge, optionally split by a single label.
+type Metric struct {
+	name  string
+	help  string
+	typ   metricType
+	label string
+	reg   *Registry
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func (r *Registry) newMetric(name, help, label string, typ metricType) *Metric {
+	m := &Metric{
+		name:   name,
+		help:   help,
+		typ:    typ,
+		label:  label,
+		reg:    r,
+		values: make(map[string]float64),
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+// NewCounter registers a monotonically increasing metric with r.
+func (r *Registry) NewCounter(name, help string) *Metric {
+	return r.newMetric(name, help, "", typeCounter)
+}
+
+// NewCounterVec registers a counter split by the provided label name with r.
+func (r *Registry) NewCounterVec(name, help, label string) *Metric {
+	return r.newMetric(name, help, label, typeCounter)
+}
+
+// NewGauge registers a metric that can go up and down with r.
+func (r *Registry) NewGauge(name, help string) *Metric {
+	return r.newMetric(name, help, "", typeGauge)
+}
+
+// NewCounter registers a monotonically increasing metric with the Default registry.
+func NewCounter(name, help string) *Metric {
+	return Default.NewCounter(name, help)
+}
+
+// NewCounterVec registers a counter split by the provided label name with the Default registry.
+func NewCounterVec(name, help, label string) *Metric {
+	return Default.NewCounterVec(name, help, label)
+}
+
+// NewGauge registers a metric that can go up and down with the Default registry.
+func NewGauge(name, help string) *Metric {
+	return Default.NewGauge(name, help)
+}
+
+// Inc increments an unlabelled metric by 1.
+func (m *Metric) Inc() {
+	m.AddLabel("", 1)
+}
+
+// IncLabel increments the series for labelValue by 1.
+func (m *Metric) IncLabel(labelValue string) {
+	m.AddLabel(labelValue, 1)
+}
+
+// AddLabel adds delta to the series for labelValue.
+func (m *Metric) AddLabel(labelValue string, delta float64) {
+	if !m.reg.Enabled() {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[labelValue] += delta
+}
+
+// Set sets an unlabelled gauge.
+func (m *Metric) Set(value float64) {
+	if !m.reg.Enabled() {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[""] = value
+}
+
+func (m *Metric) writeTo(sb *strings.Builder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintf(sb, "# HELP %s %s\n", m.name, m.help)
+	fmt.Fprintf(sb, "# TYPE %s %s\n", m.name, m.typ)
+	if len(m.values) == 0 && m.label == "" {
+		fmt.Fprintf(sb, "%s 0\n", m.name)
+		return
+	}
+
+	keys := make([]string, 0, len(m.values))
+	for k := range m.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if m.label == "" {
+			fmt.Fprintf(sb, "%s %s\n", m.name, formatValue(m.values[k]))
+		} else {
+			fmt.Fprintf(sb, "%s{%s=%q} %s\n", m.name, m.label, k, formatValue(m.values[k]))
+		}
+	}
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ServeHTTP writes every metric in r in the Prometheus text format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	sb := &strings.Builder{}
+	r.mu.Lock()
+	for _, m := range r.metrics {
+		m.writeTo(sb)
+	}
+	r.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(sb.String()))
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDisabledIsNoop(t *testing.T) {
+	m := NewRegistry().NewCounter("test_disabled_total", "help")
+	m.Inc()
+	if len(m.values) != 0 {
+		t.Fatalf("Expected no values to be recorded before Enable, got %v", m.values)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	r := NewRegistry()
+	r.Enable(mux)
+
+	c := r.NewCounterVec("test_requests_total", "Requests", "output")
+	c.IncLabel("a")
+	c.IncLabel("a")
+	c.IncLabel("b")
+	g := r.NewGauge("test_level", "Level")
+	g.Set(0.5)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE test_requests_total counter",
+		`test_requests_total{output="a"} 2`,
+		`test_requests_total{output="b"} 1`,
+		"# TYPE test_level gauge",
+		"test_level 0.5",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected body to contain %q, got:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "ledfx_") {
+		t.Errorf("Expected only this registry's metrics, got:\n%s", body)
+	}
+}
@@ -0,0 +1,103 @@
+package render
+
+import (
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/color"
+)
+
+// cycles per second an idle palette scrolls along the pixels
+const idleScrollSpeed = 0.02
+
+// Idle takes over the outputs while audio is silent. Once audio has been silent for Timeout,
+// the loop crossfades from the effect to a solid color or a slowly scrolling palette,
+// and crossfades back when audio returns.
+type Idle struct {
+	Timeout   time.Duration        // silence before going idle
+	Fade      time.Duration        // crossfade time in each direction
+	Color     color.Color          // solid idle color, used when Palette is nil
+	Palette   *color.Palette       // slow gradient scrolled along the pixels
+	SilentFor func() time.Duration // how long audio has been silent
+}
+
+// idleState is the loop's progress into idle. Only touched by the loop goroutine.
+type idleState struct {
+	mix   float64 // 0 shows the effect, 1 shows the idle pattern
+	last  time.Time
+	start time.Time               // when the palette started scrolling
+	bufs  map[string]color.Pixels // blended output, so the effect's own pixels are untouched
+}
+
+// advance moves the crossfade towards the idle pattern while silent, and back otherwise
+func (s *idleState) advance(idle *Idle, now time.Time) {
+	dt := now.Sub(s.last)
+	if s.last.IsZero() {
+		dt = 0
+	}
+	s.last = now
+
+	target := 0.0
+	if idle.SilentFor != nil && idle.SilentFor() >= idle.Timeout {
+		target = 1
+	}
+	step := 1.0
+	if idle.Fade > 0 {
+		step = float64(dt) / float64(idle.Fade)
+	}
+	switch {
+	case s.mix < target:
+		if s.mix == 0 {
+			s.start = now
+		}
+		s.mix += step
+		if s.mix > target {
+			s.mix = target
+		}
+	case s.mix > target:
+		s.mix -= step
+		if s.mix < target {
+			s.mix = target
+		}
+	}
+}
+
+// groupOffsets returns where each member starts along the group, and the group's length
+func groupOffsets(pg *PixelGroup) (map[string]int, int) {
+	offsets := make(map[string]int, len(pg.Order))
+	total := 0
+	for _, id := range pg.Order {
+		offsets[id] = total
+		total += len(pg.Group[id])
+	}
+	return offsets, total
+}
+
+// blend returns the pixels for one member of the group, mixed with the idle pattern.
+// offset is the member's first pixel along the whole group.
+func (s *idleState) blend(idle *Idle, id string, p color.Pixels, offset, total int, now time.Time) color.Pixels {
+	if s.mix == 0 {
+		return p
+	}
+	if s.bufs == nil {
+		s.bufs = map[string]color.Pixels{}
+	}
+	out := s.bufs[id]
+	if len(out) != len(p) {
+		out = make(color.Pixels, len(p))
+		s.bufs[id] = out
+	}
+	if total < offset+len(p) {
+		total = offset + len(p)
+	}
+	scroll := now.Sub(s.start).Seconds() * idleScrollSpeed
+	for i := range p {
+		c := idle.Color
+		if idle.Palette != nil {
+			c = idle.Palette.Get(float64(offset+i)/float64(total) + scroll)
+		}
+		for k := range c {
+			out[i][k] = p[i][k]*(1-s.mix) + c[k]*s.mix
+		}
+	}
+	return out
+}
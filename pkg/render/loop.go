@@ -0,0 +1,210 @@
+package render
+
+import (
+	"sync"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/color"
+	log "github.com/LedFx/ledfx/pkg/logger"
+)
+
+// Renderer draws a frame onto a pixel group. Effects implement this.
+type Renderer interface {
+	Render(pg *PixelGroup)
+}
+
+// Output receives the rendered pixels for one member of the pixel group. Devices implement this.
+type Output interface {
+	Send(p color.Pixels) error
+}
+
+// Frame timing for a loop. A frame is dropped when rendering and sending it overruns its budget.
+type LoopStats struct {
+	Frames    uint64        `json:"frames"`
+	Dropped   uint64        `json:"dropped"`
+	Budget    time.Duration `json:"budget"`
+	LastFrame time.Duration `json:"last_frame"`
+	MaxFrame  time.Duration `json:"max_frame"`
+}
+
+// Loop ticks at a fixed framerate, renders the active renderer onto the pixel group,
+// and sends each device's pixels to its output.
+type Loop struct {
+	pixels  *PixelGroup
+	outputs map[string]Output // keyed by pixel group id
+	budget  time.Duration
+
+	mu         sync.Mutex
+	renderer   Renderer
+	idle       *Idle
+	transition *Transition
+	stats      LoopStats
+
+	// set by SetRenderer for the loop to begin a transition from fadeFrom, or cut when nil
+	fadeFrom    Renderer
+	fadePending bool
+
+	// held while a frame renders, so renderers can be released from the loop between frames
+	frameMu         sync.Mutex
+	idleState       idleState
+	transitionState transitionState // guarded by frameMu
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func NewLoop(fps int, pixels *PixelGroup, outputs map[string]Output) *Loop {
+	budget := time.Second / time.Duration(fps)
+	return &Loop{
+		pixels:  pixels,
+		outputs: outputs,
+		budget:  budget,
+		stats:   LoopStats{Budget: budget},
+	}
+}
+
+// SetRenderer swaps the active renderer. It takes effect from the next frame, crossfading
+// from the previous renderer if a transition is set. A nil renderer pauses rendering without
+// stopping the loop. Swapping again mid transition crossfades from the renderer being replaced.
+func (l *Loop) SetRenderer(r Renderer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fadeFrom, l.fadePending = nil, true
+	if l.transition != nil && l.transition.Duration > 0 && l.renderer != nil && r != nil && r != l.renderer {
+		l.fadeFrom = l.renderer
+	}
+	l.renderer = r
+}
+
+// Release stops any crossfade from r, so r can be rendered by another loop.
+// It waits for a frame in progress, so r is not rendered by this loop once it returns.
+func (l *Loop) Release(r Renderer) {
+	l.mu.Lock()
+	if l.fadeFrom == r {
+		l.fadeFrom = nil
+	}
+	l.mu.Unlock()
+	l.frameMu.Lock()
+	defer l.frameMu.Unlock()
+	if l.transitionState.from == r {
+		l.transitionState.begin(nil)
+	}
+}
+
+// Transitioning reports whether the loop is crossfading between renderers
+func (l *Loop) Transitioning() bool {
+	l.frameMu.Lock()
+	defer l.frameMu.Unlock()
+	return l.transitionState.from != nil
+}
+
+// SetTransition sets how the loop changes between renderers. nil cuts straight to the new one.
+func (l *Loop) SetTransition(t *Transition) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.transition = t
+}
+
+// SetIdle sets what the loop shows while audio is silent. nil always shows the renderer.
+func (l *Loop) SetIdle(idle *Idle) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.idle = idle
+}
+
+func (l *Loop) Stats() LoopStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// Start begins ticking. Calling Start on a running loop does nothing.
+func (l *Loop) Start() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done != nil {
+		return
+	}
+	l.done = make(chan struct{})
+	l.stopped = make(chan struct{})
+	go l.run(l.done, l.stopped)
+}
+
+// Stop halts the loop and waits for any frame in progress to finish,
+// so nothing is sent to the outputs after it returns.
+func (l *Loop) Stop() {
+	l.mu.Lock()
+	done, stopped := l.done, l.stopped
+	l.done, l.stopped = nil, nil
+	l.mu.Unlock()
+	if done == nil {
+		return
+	}
+	close(done)
+	<-stopped
+}
+
+func (l *Loop) run(done, stopped chan struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(l.budget)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			l.frame()
+		}
+	}
+}
+
+func (l *Loop) frame() {
+	l.frameMu.Lock()
+	defer l.frameMu.Unlock()
+	l.mu.Lock()
+	r, idle, transition := l.renderer, l.idle, l.transition
+	if l.fadePending {
+		l.transitionState.begin(l.fadeFrom)
+		l.fadeFrom, l.fadePending = nil, false
+	}
+	l.mu.Unlock()
+	if r == nil {
+		return
+	}
+
+	start := time.Now()
+	r.Render(l.pixels)
+	l.transitionState.blend(transition, l.pixels, start)
+	if idle != nil {
+		l.idleState.advance(idle, start)
+	} else {
+		l.idleState.mix, l.idleState.last = 0, time.Time{}
+	}
+	var offsets map[string]int
+	var total int
+	if l.idleState.mix > 0 {
+		offsets, total = groupOffsets(l.pixels)
+	}
+	for id, o := range l.outputs {
+		p := l.pixels.Group[id]
+		if l.idleState.mix > 0 {
+			p = l.idleState.blend(idle, id, p, offsets[id], total, start)
+		}
+		if err := o.Send(p); err != nil {
+			log.Logger.WithField("context", "Render Loop").Debugf("Error sending to %s: %v", id, err)
+		}
+	}
+	elapsed := time.Since(start)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.Frames++
+	l.stats.LastFrame = elapsed
+	if elapsed > l.stats.MaxFrame {
+		l.stats.MaxFrame = elapsed
+	}
+	if elapsed > l.budget {
+		l.stats.Dropped++
+		log.Logger.WithField("context", "Render Loop").Debugf("Frame overran its budget [%s > %s]", elapsed, l.budget)
+	}
+}
@@ -0,0 +1,228 @@
+package render
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/color"
+)
+
+type countRenderer struct {
+	mu     sync.Mutex
+	frames int
+	delay  time.Duration
+}
+
+func (r *countRenderer) Render(pg *PixelGroup) {
+	time.Sleep(r.delay)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frames++
+	pg.Group["a"][0] = color.Color{1, 0, 0}
+}
+
+type countOutput struct {
+	mu   sync.Mutex
+	sent int
+}
+
+func (o *countOutput) Send(p color.Pixels) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sent++
+	return nil
+}
+
+func TestLoop(t *testing.T) {
+	pg := &PixelGroup{Group: map[string]color.Pixels{"a": make(color.Pixels, 1)}, Order: []string{"a"}}
+	out := &countOutput{}
+	l := NewLoop(100, pg, map[string]Output{"a": out})
+	r := &countRenderer{}
+	l.SetRenderer(r)
+	l.Start()
+	time.Sleep(100 * time.Millisecond)
+	l.Stop()
+
+	stats := l.Stats()
+	if stats.Frames == 0 || stats.Frames != uint64(r.frames) || out.sent != r.frames {
+		t.Errorf("Expected: equal non-zero frames, renders and sends\r\n Got: %d, %d, %d", stats.Frames, r.frames, out.sent)
+	}
+	if stats.Dropped != 0 {
+		t.Errorf("Expected: 0 dropped\r\n Got: %d", stats.Dropped)
+	}
+	// nothing is rendered after Stop returns
+	frames := r.frames
+	time.Sleep(30 * time.Millisecond)
+	if r.frames != frames {
+		t.Errorf("Expected: %d frames after stop\r\n Got: %d", frames, r.frames)
+	}
+}
+
+func TestLoopDropsSlowFrames(t *testing.T) {
+	pg := &PixelGroup{Group: map[string]color.Pixels{"a": make(color.Pixels, 1)}, Order: []string{"a"}}
+	l := NewLoop(100, pg, map[string]Output{})
+	l.SetRenderer(&countRenderer{delay: 20 * time.Millisecond})
+	l.Start()
+	time.Sleep(100 * time.Millisecond)
+	l.Stop()
+	if stats := l.Stats(); stats.Dropped == 0 || stats.Dropped != stats.Frames {
+		t.Errorf("Expected: every frame dropped\r\n Got: %d of %d", stats.Dropped, stats.Frames)
+	}
+}
+
+type lastOutput struct {
+	mu   sync.Mutex
+	last color.Color
+}
+
+func (o *lastOutput) Send(p color.Pixels) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.last = p[0]
+	return nil
+}
+
+func (o *lastOutput) get() color.Color {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.last
+}
+
+func TestLoopIdle(t *testing.T) {
+	pg := &PixelGroup{Group: map[string]color.Pixels{"a": make(color.Pixels, 1)}, Order: []string{"a"}}
+	out := &lastOutput{}
+	l := NewLoop(100, pg, map[string]Output{"a": out})
+	l.SetRenderer(&countRenderer{})
+
+	var mu sync.Mutex
+	silent := time.Duration(0)
+	setSilent := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		silent = d
+	}
+	l.SetIdle(&Idle{
+		Timeout: time.Second,
+		Fade:    50 * time.Millisecond,
+		Color:   color.Color{0, 0, 1},
+		SilentFor: func() time.Duration {
+			mu.Lock()
+			defer mu.Unlock()
+			return silent
+		},
+	})
+	l.Start()
+
+	time.Sleep(50 * time.Millisecond)
+	if got := out.get(); got != (color.Color{1, 0, 0}) {
+		t.Errorf("Expected: effect shown while audio plays\r\n Got: %v", got)
+	}
+	setSilent(2 * time.Second)
+	time.Sleep(150 * time.Millisecond)
+	if got := out.get(); got != (color.Color{0, 0, 1}) {
+		t.Errorf("Expected: idle color after the crossfade\r\n Got: %v", got)
+	}
+	setSilent(0)
+	time.Sleep(150 * time.Millisecond)
+	if got := out.get(); got != (color.Color{1, 0, 0}) {
+		t.Errorf("Expected: effect back once audio returns\r\n Got: %v", got)
+	}
+	l.Stop()
+	if got := pg.Group["a"][0]; got != (color.Color{1, 0, 0}) {
+		t.Errorf("Expected: effect pixels left untouched\r\n Got: %v", got)
+	}
+}
+
+type solidRenderer struct {
+	c      color.Color
+	frames int
+}
+
+func (r *solidRenderer) Render(pg *PixelGroup) {
+	r.frames++
+	for _, p := range pg.Group {
+		for i := range p {
+			p[i] = r.c
+		}
+	}
+}
+
+func TestTransition(t *testing.T) {
+	pg := &PixelGroup{Group: map[string]color.Pixels{"a": make(color.Pixels, 2)}, Order: []string{"a"}}
+	from, to := &solidRenderer{c: color.Color{1, 0, 0}}, &solidRenderer{c: color.Color{0, 0, 1}}
+	tr := &Transition{Duration: time.Second}
+	s := transitionState{}
+	s.begin(from)
+
+	start := time.Now()
+	for _, step := range []struct {
+		at   time.Duration
+		want color.Color
+	}{
+		{0, color.Color{1, 0, 0}},
+		{250 * time.Millisecond, color.Color{0.75, 0, 0.25}},
+		{time.Second, color.Color{0, 0, 1}},
+	} {
+		to.Render(pg)
+		s.blend(tr, pg, start.Add(step.at))
+		if got := pg.Group["a"][1]; got != step.want {
+			t.Errorf("Expected: %v at %s\r\n Got: %v", step.want, step.at, got)
+		}
+	}
+	// the outgoing renderer is released once the crossfade completes
+	if s.from != nil || from.frames != 2 {
+		t.Errorf("Expected: outgoing renderer released after 2 frames\r\n Got: %v after %d", s.from, from.frames)
+	}
+
+	tr.Easing = EaseInOut
+	s.begin(from)
+	to.Render(pg)
+	s.blend(tr, pg, start)
+	to.Render(pg)
+	s.blend(tr, pg, start.Add(250*time.Millisecond))
+	if got, want := pg.Group["a"][0][2], EaseInOut(0.25); got != want {
+		t.Errorf("Expected: eased mix %v\r\n Got: %v", want, got)
+	}
+}
+
+func TestLoopTransition(t *testing.T) {
+	pg := &PixelGroup{Group: map[string]color.Pixels{"a": make(color.Pixels, 1)}, Order: []string{"a"}}
+	l := NewLoop(100, pg, map[string]Output{})
+	l.SetTransition(&Transition{Duration: time.Second})
+	from, to := &solidRenderer{c: color.Color{1, 0, 0}}, &solidRenderer{c: color.Color{0, 0, 1}}
+	l.SetRenderer(from)
+	l.frame()
+	l.SetRenderer(to)
+	l.frame()
+	if l.transitionState.from != from || pg.Group["a"][0] != from.c {
+		t.Errorf("Expected: crossfade starting from the outgoing renderer\r\n Got: %v", pg.Group["a"][0])
+	}
+	if !l.Transitioning() {
+		t.Error("Expected: transitioning")
+	}
+	// released renderers stop being crossfaded from
+	l.Release(from)
+	if l.Transitioning() {
+		t.Error("Expected: crossfade stopped once the outgoing renderer is released")
+	}
+	frames := from.frames
+	l.frame()
+	if from.frames != frames || pg.Group["a"][0] != to.c {
+		t.Errorf("Expected: only the incoming renderer after release\r\n Got: %v", pg.Group["a"][0])
+	}
+	// no transition from nothing, or without a transition set
+	l.SetRenderer(nil)
+	l.frame()
+	l.SetRenderer(to)
+	l.frame()
+	if l.transitionState.from != nil {
+		t.Error("Expected: cut after a nil renderer")
+	}
+	l.SetTransition(nil)
+	l.SetRenderer(from)
+	l.frame()
+	if l.transitionState.from != nil || pg.Group["a"][0] != from.c {
+		t.Errorf("Expected: cut with no transition set\r\n Got: %v", pg.Group["a"][0])
+	}
+}
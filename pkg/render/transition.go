@@ -0,0 +1,93 @@
+package render
+
+import (
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/color"
+)
+
+// Transition crossfades from the outgoing renderer to the incoming one when the loop's
+// renderer changes. Both render until the crossfade completes, then the outgoing one is released.
+type Transition struct {
+	Duration time.Duration
+	Easing   Easing // shapes the crossfade. nil is linear
+}
+
+// Easing maps progress through a transition, 0 to 1, to the incoming renderer's share of the mix
+type Easing func(t float64) float64
+
+func EaseLinear(t float64) float64 {
+	return t
+}
+
+// EaseInOut starts and ends the crossfade gently
+func EaseInOut(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+// transitionState is the loop's progress through a transition
+type transitionState struct {
+	from   Renderer    // outgoing renderer, nil when not transitioning
+	start  time.Time   // set on the first frame of the transition
+	pixels *PixelGroup // the outgoing renderer draws here, shaped like the loop's pixel group
+}
+
+// begin starts crossfading from r, replacing any transition in progress. nil cuts straight to the incoming renderer.
+func (s *transitionState) begin(r Renderer) {
+	s.from = r
+	s.start = time.Time{}
+	if r == nil {
+		s.pixels = nil
+	}
+}
+
+// blend renders the outgoing renderer and mixes it into pg, which holds the incoming renderer's frame.
+// The outgoing renderer is released once the transition completes.
+func (s *transitionState) blend(t *Transition, pg *PixelGroup, now time.Time) {
+	if s.from == nil {
+		return
+	}
+	if s.start.IsZero() {
+		s.start = now
+	}
+	if t == nil || t.Duration <= 0 || now.Sub(s.start) >= t.Duration {
+		s.begin(nil)
+		return
+	}
+	mix := float64(now.Sub(s.start)) / float64(t.Duration)
+	if t.Easing != nil {
+		mix = t.Easing(mix)
+	}
+
+	s.pixels = shapeLike(s.pixels, pg)
+	s.from.Render(s.pixels)
+	for id, p := range pg.Group {
+		from := s.pixels.Group[id]
+		for i := range p {
+			for k := range p[i] {
+				p[i][k] = from[i][k]*(1-mix) + p[i][k]*mix
+			}
+		}
+	}
+}
+
+// shapeLike returns a pixel group with the same members and sizes as pg, reusing dst where it already matches
+func shapeLike(dst, pg *PixelGroup) *PixelGroup {
+	if dst == nil {
+		dst = &PixelGroup{Group: map[string]color.Pixels{}}
+	}
+	for id := range dst.Group {
+		if _, ok := pg.Group[id]; !ok {
+			delete(dst.Group, id)
+		}
+	}
+	for id, p := range pg.Group {
+		if len(dst.Group[id]) != len(p) {
+			dst.Group[id] = make(color.Pixels, len(p))
+		}
+	}
+	dst.Order = pg.Order
+	dst.Largest, dst.Smallest = pg.Largest, pg.Smallest
+	dst.LargestLen, dst.TotalLen = pg.LargestLen, pg.TotalLen
+	return dst
+}
@@ -0,0 +1,135 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/LedFx/ledfx/pkg/audio"
+	"github.com/LedFx/ledfx/pkg/logger"
+	"github.com/LedFx/ledfx/pkg/tickpool"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	LevelsPath        = "/websocket/levels"
+	DefaultLevelsRate = 30
+	// frames queued per subscriber before new ones are dropped
+	levelsQueueLen = 2
+)
+
+// levelsHub samples the analyzer from a single goroutine and fans the frames out to
+// every subscriber. The goroutine only runs while there is at least one subscriber.
+type levelsHub struct {
+	interval time.Duration
+	mu       sync.Mutex
+	subs     map[*levelsSub]struct{}
+	running  bool
+}
+
+type levelsSub struct {
+	conn   *websocket.Conn
+	frames chan []byte
+}
+
+// ServeLevels registers a websocket endpoint which pushes audio levels at rate frames per second
+func ServeLevels(mux *http.ServeMux, rate int) {
+	if rate <= 0 {
+		rate = DefaultLevelsRate
+	}
+	h := &levelsHub{
+		interval: time.Second / time.Duration(rate),
+		subs:     make(map[*levelsSub]struct{}),
+	}
+	mux.HandleFunc(LevelsPath, h.handle)
+}
+
+func (h *levelsHub) handle(w http.ResponseWriter, r *http.Request) {
+	conn, err := Upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Logger.WithField("context", "Levels Websocket").Error(err)
+		return
+	}
+	logger.Logger.WithField("context", "Levels Websocket").Debugf("Connection established with %s", r.RemoteAddr)
+	s := &levelsSub{
+		conn:   conn,
+		frames: make(chan []byte, levelsQueueLen),
+	}
+	h.add(s)
+	go s.writeLoop()
+	// block until the client goes away, then clean up
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+	h.remove(s)
+	logger.Logger.WithField("context", "Levels Websocket").Debugf("Closed connection with %s", r.RemoteAddr)
+}
+
+func (h *levelsHub) add(s *levelsSub) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[s] = struct{}{}
+	if !h.running {
+		h.running = true
+		audio.Analyzer.EnableLevels(true)
+		go h.run()
+	}
+}
+
+func (h *levelsHub) remove(s *levelsSub) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.frames)
+	}
+}
+
+func (h *levelsHub) run() {
+	tick := tickpool.Get(h.interval)
+	defer tickpool.Put(tick)
+
+	for range tick.C {
+		b, err := json.Marshal(audio.Analyzer.Levels())
+		if err != nil {
+			logger.Logger.WithField("context", "Levels Websocket").Error(err)
+			continue
+		}
+		if !h.broadcast(b) {
+			return
+		}
+	}
+}
+
+// broadcast queues a frame for every subscriber, dropping it for any that are falling behind.
+// Returns false, and stops metering, once there is nobody left to send to.
+func (h *levelsHub) broadcast(b []byte) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.subs) == 0 {
+		h.running = false
+		audio.Analyzer.EnableLevels(false)
+		return false
+	}
+	for s := range h.subs {
+		select {
+		case s.frames <- b:
+		default:
+		}
+	}
+	return true
+}
+
+func (s *levelsSub) writeLoop() {
+	defer s.conn.Close()
+	for b := range s.frames {
+		if err := s.conn.WriteMessage(websocket.TextMessage, b); err != nil {
+			logger.Logger.WithField("context", "Levels Websocket").Debug(err)
+			return
+		}
+	}
+}
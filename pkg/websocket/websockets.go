@@ -81,9 +81,9 @@ func New(w http.ResponseWriter, r *http.Request) {
 	}
 	logger.Logger.WithField("context", "Websocket").Debugf("Connection established with %s", r.RemoteAddr)
 	// subscribe to the events we want
-	// there are eight event types so we'll just ask for all of them
+	// we'll just ask for all of the event types
 	var i event.EventType
-	for i = 0; i <= 11; i++ {
+	for i = 0; i <= event.AudioClipping; i++ {
 		// sub and also defer calling the unsubscribe function
 		defer event.Subscribe(i, ws.handleEvent)()
 	}